@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+)
+
+// runCommand executes a command line subcommand instead of starting the server.
+func runCommand(args []string, db *sqlx.DB) error {
+	switch args[0] {
+	case "reconcile":
+		return reconcileCommand(args[1:], stock.NewStockRepository(db))
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// reconcileCommand prints the inventory's reconciliation report and, with -fix,
+// resolves the differences.
+//
+//	reconcile -inventory <id> [-fix adjustment|unlocated] [-products id,id]
+func reconcileCommand(args []string, repo *stock.StockRepository) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	inventoryFlag := flags.String("inventory", "", "inventory ID to reconcile")
+	fixFlag := flags.String("fix", "", "fix differences by 'adjustment' or 'unlocated'")
+	productsFlag := flags.String("products", "", "comma-separated product IDs to fix (default all)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inventoryId, err := uuid.Parse(*inventoryFlag)
+	if err != nil {
+		return fmt.Errorf("invalid inventory ID %q", *inventoryFlag)
+	}
+
+	var entries []domain.ReconciliationEntry
+	switch mode := domain.ReconciliationFixMode(*fixFlag); mode {
+	case "":
+		entries, err = repo.ReconcileInventory(inventoryId)
+	case domain.ReconciliationFixAdjustment, domain.ReconciliationFixUnlocated:
+		var productIds []uuid.UUID
+		for _, value := range strings.Split(*productsFlag, ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			productId, err := uuid.Parse(value)
+			if err != nil {
+				return fmt.Errorf("invalid product ID %q", value)
+			}
+			productIds = append(productIds, productId)
+		}
+
+		requestId := "cli:reconcile"
+		entries, err = repo.FixReconciliation(inventoryId, mode, productIds, domain.Actor{RequestId: &requestId})
+	default:
+		return fmt.Errorf("unknown fix mode %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "SKU\tPRODUCT\tON HAND\tLOCATED\tDIFFERENCE\tSTORAGES")
+	for _, entry := range entries {
+		storages := make([]string, len(entry.Storages))
+		for i, located := range entry.Storages {
+			storages[i] = fmt.Sprintf("%s=%d", located.StorageName, located.Quantity)
+		}
+		fmt.Fprintf(out, "%s\t%s\t%d\t%d\t%d\t%s\n", entry.SKU, entry.ProductName,
+			entry.OnHand, entry.Located, entry.Difference, strings.Join(storages, ", "))
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	if *fixFlag != "" {
+		fmt.Printf("Fixed %d product(s) by %s\n", len(entries), *fixFlag)
+	} else {
+		fmt.Printf("%d product(s) out of balance\n", len(entries))
+	}
+
+	return nil
+}
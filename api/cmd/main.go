@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"os"
+	"time"
 
 	"github.com/ventry/cmd/server"
 	"github.com/ventry/config"
@@ -9,9 +11,13 @@ import (
 	"github.com/ventry/internal/features/categories"
 	"github.com/ventry/internal/features/deliveries"
 	"github.com/ventry/internal/features/inventories"
+	"github.com/ventry/internal/features/labels"
 	"github.com/ventry/internal/features/products"
+	"github.com/ventry/internal/features/purchases"
 	"github.com/ventry/internal/features/sales"
+	"github.com/ventry/internal/features/stock"
 	"github.com/ventry/internal/features/storages"
+	"github.com/ventry/internal/features/suppliers"
 	"github.com/ventry/internal/pkg/auth"
 	"github.com/ventry/internal/pkg/logger"
 )
@@ -29,6 +35,17 @@ func main() {
 	db := database.Connect(*config)
 	defer db.Close()
 
+	// Run a command line subcommand instead of the server when one is given
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:], db); err != nil {
+			logger.Fatal(context.Background(), "Command failed",
+				logger.Field{Key: "command", Value: os.Args[1]},
+				logger.Field{Key: "error", Value: err.Error()},
+			)
+		}
+		return
+	}
+
 	// Add startup logging
 	logger.Info(context.Background(), "Starting application...",
 		logger.Field{Key: "environment", Value: config.Environment},
@@ -41,8 +58,12 @@ func main() {
 	storageRepo := storages.NewStorageRepository(db)
 	productRepo := products.NewProductRepository(db)
 	categoryRepo := categories.NewCategoryRepository(db)
-	deliveryRepo := deliveries.NewDeliveryRepository(db)
+	deliveryRepo := deliveries.NewDeliveryRepository(db, config.ReservationTTL)
 	saleRepo := sales.NewSaleRepository(db)
+	stockRepo := stock.NewStockRepository(db)
+	supplierRepo := suppliers.NewSupplierRepository(db)
+	purchaseRepo := purchases.NewPurchaseRepository(db)
+	labelRepo := labels.NewLabelRepository(db)
 
 	// Declare dependencies
 	dependencies := server.ServerDependencies{
@@ -54,8 +75,16 @@ func main() {
 		CategoryController:  categories.NewCategoryController(categoryRepo),
 		DeliveryController:  deliveries.NewDeliveryController(deliveryRepo),
 		SaleController:      sales.NewSaleController(saleRepo),
+		StockController:     stock.NewStockController(stockRepo),
+		SupplierController:  suppliers.NewSupplierController(supplierRepo),
+		PurchaseController:  purchases.NewPurchaseController(purchaseRepo),
+		LabelController:     labels.NewLabelController(labelRepo),
 	}
 
+	// Background jobs
+	go stockRepo.RunReservationExpiry(context.Background(), time.Minute)
+	go productRepo.RunPriceSchedule(context.Background(), time.Minute)
+
 	e := server.Run(dependencies)
 
 	port := ":5000"
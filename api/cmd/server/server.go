@@ -7,9 +7,13 @@ import (
 	"github.com/ventry/internal/features/categories"
 	"github.com/ventry/internal/features/deliveries"
 	"github.com/ventry/internal/features/inventories"
+	"github.com/ventry/internal/features/labels"
 	"github.com/ventry/internal/features/products"
+	"github.com/ventry/internal/features/purchases"
 	"github.com/ventry/internal/features/sales"
+	"github.com/ventry/internal/features/stock"
 	"github.com/ventry/internal/features/storages"
+	"github.com/ventry/internal/features/suppliers"
 	"github.com/ventry/internal/pkg/auth"
 	"github.com/ventry/internal/pkg/logger"
 	"github.com/ventry/internal/router"
@@ -25,6 +29,10 @@ type ServerDependencies struct {
 	CategoryController  *categories.CategoryController
 	DeliveryController  *deliveries.DeliveryController
 	SaleController      *sales.SaleController
+	StockController     *stock.StockController
+	SupplierController  *suppliers.SupplierController
+	PurchaseController  *purchases.PurchaseController
+	LabelController     *labels.LabelController
 }
 
 func Run(deps ServerDependencies) *echo.Echo {
@@ -52,6 +60,10 @@ func Run(deps ServerDependencies) *echo.Echo {
 	router.CategoryRoutes(e, *deps.CategoryController, *deps.AuthService)
 	router.DeliveryRoutes(e, *deps.DeliveryController, *deps.AuthService)
 	router.SaleRoutes(e, *deps.SaleController, *deps.AuthService)
+	router.StockRoutes(e, *deps.StockController, *deps.AuthService)
+	router.SupplierRoutes(e, *deps.SupplierController, *deps.AuthService)
+	router.PurchaseRoutes(e, *deps.PurchaseController, *deps.AuthService)
+	router.LabelRoutes(e, *deps.LabelController, *deps.AuthService)
 
 	return e
 }
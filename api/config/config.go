@@ -3,15 +3,17 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Variables struct {
-	DatabaseUrl string
-	RedisUrl    string
-	JWTSecret   string
-	Environment string
+	DatabaseUrl    string
+	RedisUrl       string
+	JWTSecret      string
+	Environment    string
+	ReservationTTL time.Duration
 }
 
 func LoadEnv() *Variables {
@@ -24,11 +26,22 @@ func LoadEnv() *Variables {
 		env = "development"
 	}
 
+	// How long pending deliveries hold stock before the reservation lapses
+	reservationTTL := 72 * time.Hour
+	if value := os.Getenv("RESERVATION_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid RESERVATION_TTL %q: %s", value, err)
+		}
+		reservationTTL = ttl
+	}
+
 	config := &Variables{
-		DatabaseUrl: os.Getenv("DATABASE_URL"),
-		RedisUrl:    os.Getenv("REDIS_URL"),
-		JWTSecret:   os.Getenv("JWT_SECRET"),
-		Environment: env,
+		DatabaseUrl:    os.Getenv("DATABASE_URL"),
+		RedisUrl:       os.Getenv("REDIS_URL"),
+		JWTSecret:      os.Getenv("JWT_SECRET"),
+		Environment:    env,
+		ReservationTTL: reservationTTL,
 	}
 
 	return config
@@ -0,0 +1,27 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    storage_unit_id UUID,
+    delta INTEGER NOT NULL,
+    balance INTEGER NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    source_type VARCHAR(30) NOT NULL,
+    source_id UUID,
+    user_id UUID,
+    request_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stock_movements_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_movements_storage_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stock_movements_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Indexes
+CREATE INDEX idx_stock_movements_product_date ON stock_movements (product_id, created_at);
+CREATE INDEX idx_stock_movements_source ON stock_movements (source_type, source_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS stock_movements CASCADE;
@@ -0,0 +1,8 @@
+-- +goose Up
+
+ALTER TABLE inventories ADD COLUMN IF NOT EXISTS allow_backorders BOOLEAN NOT NULL DEFAULT false;
+
+
+-- +goose Down
+
+ALTER TABLE inventories DROP COLUMN IF EXISTS allow_backorders;
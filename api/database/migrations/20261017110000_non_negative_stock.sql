@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- Product quantities may only go negative in inventories that allow backorders
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_non_negative_stock() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.quantity < 0 AND NOT EXISTS (
+        SELECT 1 FROM inventories WHERE id = NEW.inventory_id AND allow_backorders
+    ) THEN
+        RAISE EXCEPTION 'new row for relation "products" violates check constraint "products_quantity_non_negative"'
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'products_quantity_non_negative';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_products_quantity_non_negative
+    BEFORE INSERT OR UPDATE OF quantity ON products
+    FOR EACH ROW EXECUTE FUNCTION enforce_non_negative_stock();
+
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS trg_products_quantity_non_negative ON products;
+DROP FUNCTION IF EXISTS enforce_non_negative_stock();
@@ -0,0 +1,21 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS delivery_status_history (
+    id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL,
+    from_status delivery_status,
+    to_status delivery_status NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    changed_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_delivery_status_history_delivery FOREIGN KEY (delivery_id) REFERENCES deliveries (id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_status_history_user FOREIGN KEY (changed_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Indexes
+CREATE INDEX idx_delivery_status_history_delivery ON delivery_status_history (delivery_id, created_at);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS delivery_status_history CASCADE;
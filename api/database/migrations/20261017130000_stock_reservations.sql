@@ -0,0 +1,33 @@
+-- +goose Up
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS available INTEGER GENERATED ALWAYS AS (quantity - reserved) STORED;
+
+-- Deliveries created before reservations existed have already taken their stock
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS stock_deducted BOOLEAN NOT NULL DEFAULT false;
+UPDATE deliveries SET stock_deducted = true WHERE status <> 'cancelled';
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    delivery_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stock_reservations_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_reservations_delivery FOREIGN KEY (delivery_id) REFERENCES deliveries (id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_stock_reservations_delivery ON stock_reservations (delivery_id, status);
+CREATE INDEX idx_stock_reservations_expiry ON stock_reservations (expires_at) WHERE status = 'active';
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS stock_reservations CASCADE;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS stock_deducted;
+ALTER TABLE products DROP COLUMN IF EXISTS available;
+ALTER TABLE products DROP COLUMN IF EXISTS reserved;
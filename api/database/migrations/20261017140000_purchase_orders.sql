@@ -0,0 +1,79 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    contact_name VARCHAR(100) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (name, inventory_id),
+    CONSTRAINT fk_suppliers_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    supplier_id UUID NOT NULL,
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(30) NOT NULL DEFAULT 'draft',
+    order_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expected_date TIMESTAMP WITH TIME ZONE,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purchase_orders_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_orders_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers (id) ON DELETE RESTRICT
+);
+
+-- variance is negative while a line is under-received and positive once it is over-received
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id UUID PRIMARY KEY,
+    purchase_order_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity_ordered INTEGER NOT NULL CHECK (quantity_ordered > 0),
+    quantity_received INTEGER NOT NULL DEFAULT 0 CHECK (quantity_received >= 0),
+    variance INTEGER GENERATED ALWAYS AS (quantity_received - quantity_ordered) STORED,
+    unit_cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (purchase_order_id, product_id),
+    CONSTRAINT fk_purchase_order_lines_order FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_order_lines_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_receipts (
+    id UUID PRIMARY KEY,
+    purchase_order_id UUID NOT NULL,
+    line_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    storage_unit_id UUID,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    note TEXT NOT NULL DEFAULT '',
+    received_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purchase_order_receipts_order FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_order_receipts_line FOREIGN KEY (line_id) REFERENCES purchase_order_lines (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_order_receipts_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_order_receipts_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_purchase_order_receipts_user FOREIGN KEY (received_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Indexes
+CREATE INDEX idx_suppliers_inventory ON suppliers (inventory_id);
+CREATE INDEX idx_purchase_orders_inventory ON purchase_orders (inventory_id, status);
+CREATE INDEX idx_purchase_orders_supplier ON purchase_orders (supplier_id);
+CREATE INDEX idx_purchase_order_lines_order ON purchase_order_lines (purchase_order_id);
+CREATE INDEX idx_purchase_order_receipts_order ON purchase_order_receipts (purchase_order_id, created_at);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS purchase_order_receipts CASCADE;
+DROP TABLE IF EXISTS purchase_order_lines CASCADE;
+DROP TABLE IF EXISTS purchase_orders CASCADE;
+DROP TABLE IF EXISTS suppliers CASCADE;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS product_suppliers (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    supplier_id UUID NOT NULL,
+    supplier_sku VARCHAR(50) NOT NULL DEFAULT '',
+    unit_cost DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (unit_cost >= 0),
+    min_order_quantity INTEGER NOT NULL DEFAULT 1 CHECK (min_order_quantity >= 1),
+    pack_size INTEGER NOT NULL DEFAULT 1 CHECK (pack_size >= 1),
+    lead_time_days INTEGER NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0),
+    is_preferred BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, supplier_id),
+    CONSTRAINT fk_product_suppliers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_suppliers_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers (id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_product_suppliers_supplier ON product_suppliers (supplier_id);
+CREATE UNIQUE INDEX idx_product_suppliers_preferred ON product_suppliers (product_id) WHERE is_preferred;
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS product_suppliers CASCADE;
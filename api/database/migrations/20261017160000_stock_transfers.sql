@@ -0,0 +1,28 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    from_unit_id UUID,
+    to_unit_id UUID,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    note TEXT NOT NULL DEFAULT '',
+    user_id UUID,
+    request_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stock_transfers_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_transfers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_transfers_from_unit FOREIGN KEY (from_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stock_transfers_to_unit FOREIGN KEY (to_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stock_transfers_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Indexes
+CREATE INDEX idx_stock_transfers_inventory_date ON stock_transfers (inventory_id, created_at);
+CREATE INDEX idx_stock_transfers_product ON stock_transfers (product_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS stock_transfers CASCADE;
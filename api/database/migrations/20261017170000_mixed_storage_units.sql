@@ -0,0 +1,70 @@
+-- +goose Up
+
+-- Optional limits on what a unit can hold; NULL means unlimited
+ALTER TABLE storage_units ADD COLUMN IF NOT EXISTS capacity_count INTEGER CHECK (capacity_count > 0);
+ALTER TABLE storage_units ADD COLUMN IF NOT EXISTS capacity_volume DECIMAL(12, 3) CHECK (capacity_volume > 0);
+ALTER TABLE storage_units ADD COLUMN IF NOT EXISTS capacity_weight DECIMAL(12, 3) CHECK (capacity_weight > 0);
+
+-- Per-item size used to check volume and weight capacity
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit_volume DECIMAL(12, 3) NOT NULL DEFAULT 0 CHECK (unit_volume >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit_weight DECIMAL(12, 3) NOT NULL DEFAULT 0 CHECK (unit_weight >= 0);
+
+-- A unit holds each product at most once, with its own quantity
+WITH merged AS (
+    SELECT storage_unit_id, product_id, MIN(id::text)::uuid AS keep_id, SUM(quantity) AS quantity
+    FROM unit_items
+    GROUP BY storage_unit_id, product_id
+    HAVING COUNT(*) > 1
+)
+UPDATE unit_items ui SET quantity = merged.quantity
+FROM merged WHERE ui.id = merged.keep_id;
+
+DELETE FROM unit_items ui
+USING unit_items other
+WHERE ui.storage_unit_id = other.storage_unit_id
+    AND ui.product_id = other.product_id
+    AND ui.id::text > other.id::text;
+
+DELETE FROM unit_items WHERE quantity = 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unit_items_unit_product ON unit_items (storage_unit_id, product_id);
+
+-- is_occupied follows the unit's contents
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION sync_unit_occupancy() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE storage_units
+        SET is_occupied = EXISTS (SELECT 1 FROM unit_items WHERE storage_unit_id = OLD.storage_unit_id AND quantity > 0)
+        WHERE id = OLD.storage_unit_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE storage_units
+        SET is_occupied = EXISTS (SELECT 1 FROM unit_items WHERE storage_unit_id = NEW.storage_unit_id AND quantity > 0)
+        WHERE id = NEW.storage_unit_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_unit_items_occupancy
+    AFTER INSERT OR UPDATE OR DELETE ON unit_items
+    FOR EACH ROW EXECUTE FUNCTION sync_unit_occupancy();
+
+UPDATE storage_units su
+SET is_occupied = EXISTS (SELECT 1 FROM unit_items WHERE storage_unit_id = su.id AND quantity > 0);
+
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS trg_unit_items_occupancy ON unit_items;
+DROP FUNCTION IF EXISTS sync_unit_occupancy();
+DROP INDEX IF EXISTS idx_unit_items_unit_product;
+ALTER TABLE products DROP COLUMN IF EXISTS unit_weight;
+ALTER TABLE products DROP COLUMN IF EXISTS unit_volume;
+ALTER TABLE storage_units DROP COLUMN IF EXISTS capacity_weight;
+ALTER TABLE storage_units DROP COLUMN IF EXISTS capacity_volume;
+ALTER TABLE storage_units DROP COLUMN IF EXISTS capacity_count;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Each inventory may have one system storage holding stock whose location is unknown
+ALTER TABLE storages ADD COLUMN IF NOT EXISTS is_unlocated BOOLEAN NOT NULL DEFAULT false;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_storages_unlocated ON storages (inventory_id) WHERE is_unlocated;
+
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_storages_unlocated;
+ALTER TABLE storages DROP COLUMN IF EXISTS is_unlocated;
@@ -0,0 +1,60 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    scope VARCHAR(20) NOT NULL DEFAULT 'inventory',
+    storage_id UUID,
+    blind BOOLEAN NOT NULL DEFAULT false,
+    note TEXT NOT NULL DEFAULT '',
+    created_by UUID,
+    posted_by UUID,
+    posted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stocktakes_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stocktakes_storage FOREIGN KEY (storage_id) REFERENCES storages (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stocktakes_created_by FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stocktakes_posted_by FOREIGN KEY (posted_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS stocktake_units (
+    stocktake_id UUID NOT NULL,
+    storage_unit_id UUID NOT NULL,
+    PRIMARY KEY (stocktake_id, storage_unit_id),
+    CONSTRAINT fk_stocktake_units_stocktake FOREIGN KEY (stocktake_id) REFERENCES stocktakes (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stocktake_units_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE CASCADE
+);
+
+-- Lines without a storage unit count a product's inventory-wide total
+CREATE TABLE IF NOT EXISTS stocktake_lines (
+    id UUID PRIMARY KEY,
+    stocktake_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    storage_unit_id UUID,
+    expected_quantity INTEGER NOT NULL,
+    counted_quantity INTEGER CHECK (counted_quantity >= 0),
+    variance INTEGER GENERATED ALWAYS AS (counted_quantity - expected_quantity) STORED,
+    approved BOOLEAN NOT NULL DEFAULT true,
+    counted_by UUID,
+    counted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stocktake_lines_stocktake FOREIGN KEY (stocktake_id) REFERENCES stocktakes (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stocktake_lines_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stocktake_lines_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stocktake_lines_counted_by FOREIGN KEY (counted_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Indexes
+CREATE INDEX idx_stocktakes_inventory ON stocktakes (inventory_id, status);
+CREATE INDEX idx_stocktake_lines_stocktake ON stocktake_lines (stocktake_id, product_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS stocktake_lines CASCADE;
+DROP TABLE IF EXISTS stocktake_units CASCADE;
+DROP TABLE IF EXISTS stocktakes CASCADE;
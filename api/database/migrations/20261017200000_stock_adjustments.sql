@@ -0,0 +1,57 @@
+-- +goose Up
+
+-- direction limits which way a reason may move stock: increase, decrease or any
+CREATE TABLE IF NOT EXISTS adjustment_reasons (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    direction VARCHAR(20) NOT NULL DEFAULT 'any',
+    is_shrinkage BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (code, inventory_id),
+    CONSTRAINT fk_adjustment_reasons_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+-- unit_cost is the product's cost when the adjustment was made, for valuing shrinkage
+CREATE TABLE IF NOT EXISTS stock_adjustments (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    storage_unit_id UUID,
+    reason_id UUID NOT NULL,
+    delta INTEGER NOT NULL CHECK (delta <> 0),
+    unit_cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    user_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_stock_adjustments_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_adjustments_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_adjustments_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_stock_adjustments_reason FOREIGN KEY (reason_id) REFERENCES adjustment_reasons (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_stock_adjustments_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Default reasons for existing inventories; new inventories get them on creation
+INSERT INTO adjustment_reasons (id, inventory_id, code, name, direction, is_shrinkage)
+SELECT md5(i.id::text || r.code)::uuid, i.id, r.code, r.name, r.direction, r.is_shrinkage
+FROM inventories i
+CROSS JOIN (VALUES
+    ('damaged', 'Damaged', 'decrease', true),
+    ('expired', 'Expired', 'decrease', true),
+    ('lost', 'Lost', 'decrease', true),
+    ('found', 'Found', 'increase', false),
+    ('correction', 'Correction', 'any', false)
+) AS r (code, name, direction, is_shrinkage)
+ON CONFLICT (code, inventory_id) DO NOTHING;
+
+-- Indexes
+CREATE INDEX idx_stock_adjustments_inventory_date ON stock_adjustments (inventory_id, created_at);
+CREATE INDEX idx_stock_adjustments_reason ON stock_adjustments (reason_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS stock_adjustments CASCADE;
+DROP TABLE IF EXISTS adjustment_reasons CASCADE;
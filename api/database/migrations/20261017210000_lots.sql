@@ -0,0 +1,77 @@
+-- +goose Up
+
+-- quantity is the lot's share of the product's on-hand stock; where it sits is
+-- recorded on unit_items
+CREATE TABLE IF NOT EXISTS lots (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    lot_number VARCHAR(100) NOT NULL,
+    manufactured_on DATE,
+    expires_on DATE,
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, lot_number),
+    CHECK (expires_on IS NULL OR manufactured_on IS NULL OR expires_on >= manufactured_on),
+    CONSTRAINT fk_lots_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Which lots a sale or delivery took its stock from, so returns go back to the same lots
+CREATE TABLE IF NOT EXISTS lot_allocations (
+    id UUID PRIMARY KEY,
+    lot_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    source_type VARCHAR(30) NOT NULL,
+    source_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_lot_allocations_lot FOREIGN KEY (lot_id) REFERENCES lots (id) ON DELETE CASCADE,
+    CONSTRAINT fk_lot_allocations_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+ALTER TABLE unit_items ADD COLUMN IF NOT EXISTS lot_id UUID;
+ALTER TABLE unit_items ADD CONSTRAINT fk_unit_items_lot FOREIGN KEY (lot_id) REFERENCES lots (id) ON DELETE SET NULL;
+
+ALTER TABLE purchase_order_receipts ADD COLUMN IF NOT EXISTS lot_id UUID;
+ALTER TABLE purchase_order_receipts ADD CONSTRAINT fk_purchase_order_receipts_lot FOREIGN KEY (lot_id) REFERENCES lots (id) ON DELETE SET NULL;
+
+ALTER TABLE stock_adjustments ADD COLUMN IF NOT EXISTS lot_id UUID;
+ALTER TABLE stock_adjustments ADD CONSTRAINT fk_stock_adjustments_lot FOREIGN KEY (lot_id) REFERENCES lots (id) ON DELETE SET NULL;
+
+-- A unit holds each lot of a product once; stock without a lot shares one row
+DROP INDEX IF EXISTS idx_unit_items_unit_product;
+CREATE UNIQUE INDEX idx_unit_items_unit_product_lot
+    ON unit_items (storage_unit_id, product_id, COALESCE(lot_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+-- Indexes
+CREATE INDEX idx_lots_product_expiry ON lots (product_id, expires_on);
+CREATE INDEX idx_lots_expiry ON lots (expires_on) WHERE quantity > 0;
+CREATE INDEX idx_lot_allocations_source ON lot_allocations (source_type, source_id, product_id);
+
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_unit_items_unit_product_lot;
+
+WITH merged AS (
+    SELECT storage_unit_id, product_id, MIN(id::text)::uuid AS keep_id, SUM(quantity) AS quantity
+    FROM unit_items
+    GROUP BY storage_unit_id, product_id
+    HAVING COUNT(*) > 1
+)
+UPDATE unit_items ui SET quantity = merged.quantity
+FROM merged WHERE ui.id = merged.keep_id;
+
+DELETE FROM unit_items ui
+USING unit_items other
+WHERE ui.storage_unit_id = other.storage_unit_id
+    AND ui.product_id = other.product_id
+    AND ui.id::text > other.id::text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unit_items_unit_product ON unit_items (storage_unit_id, product_id);
+
+ALTER TABLE stock_adjustments DROP COLUMN IF EXISTS lot_id;
+ALTER TABLE purchase_order_receipts DROP COLUMN IF EXISTS lot_id;
+ALTER TABLE unit_items DROP COLUMN IF EXISTS lot_id;
+DROP TABLE IF EXISTS lot_allocations CASCADE;
+DROP TABLE IF EXISTS lots CASCADE;
@@ -0,0 +1,70 @@
+-- +goose Up
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_serialized BOOLEAN NOT NULL DEFAULT false;
+
+-- One row per physical item of a serialized product. storage_unit_id is where
+-- the item is while it is in stock; source_* is the document currently holding it
+CREATE TABLE IF NOT EXISTS serial_numbers (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    serial_number VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_stock',
+    storage_unit_id UUID,
+    lot_id UUID,
+    source_type VARCHAR(30),
+    source_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, serial_number),
+    CONSTRAINT fk_serial_numbers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_serial_numbers_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_serial_numbers_lot FOREIGN KEY (lot_id) REFERENCES lots (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS serial_events (
+    id UUID PRIMARY KEY,
+    serial_id UUID NOT NULL,
+    event VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    storage_unit_id UUID,
+    source_type VARCHAR(30) NOT NULL,
+    source_id UUID,
+    user_id UUID,
+    request_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_serial_events_serial FOREIGN KEY (serial_id) REFERENCES serial_numbers (id) ON DELETE CASCADE,
+    CONSTRAINT fk_serial_events_unit FOREIGN KEY (storage_unit_id) REFERENCES storage_units (id) ON DELETE SET NULL,
+    CONSTRAINT fk_serial_events_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS sale_item_serials (
+    sale_item_id UUID NOT NULL,
+    serial_id UUID NOT NULL,
+    PRIMARY KEY (sale_item_id, serial_id),
+    CONSTRAINT fk_sale_item_serials_item FOREIGN KEY (sale_item_id) REFERENCES sale_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_item_serials_serial FOREIGN KEY (serial_id) REFERENCES serial_numbers (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS delivery_item_serials (
+    delivery_item_id UUID NOT NULL,
+    serial_id UUID NOT NULL,
+    PRIMARY KEY (delivery_item_id, serial_id),
+    CONSTRAINT fk_delivery_item_serials_item FOREIGN KEY (delivery_item_id) REFERENCES delivery_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_item_serials_serial FOREIGN KEY (serial_id) REFERENCES serial_numbers (id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_serial_numbers_serial ON serial_numbers (serial_number);
+CREATE INDEX idx_serial_numbers_unit ON serial_numbers (storage_unit_id);
+CREATE INDEX idx_serial_events_serial ON serial_events (serial_id, created_at);
+CREATE INDEX idx_sale_item_serials_serial ON sale_item_serials (serial_id);
+CREATE INDEX idx_delivery_item_serials_serial ON delivery_item_serials (serial_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS delivery_item_serials CASCADE;
+DROP TABLE IF EXISTS sale_item_serials CASCADE;
+DROP TABLE IF EXISTS serial_events CASCADE;
+DROP TABLE IF EXISTS serial_numbers CASCADE;
+ALTER TABLE products DROP COLUMN IF EXISTS is_serialized;
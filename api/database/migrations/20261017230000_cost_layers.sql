@@ -0,0 +1,58 @@
+-- +goose Up
+
+ALTER TABLE inventories ADD COLUMN IF NOT EXISTS costing_method VARCHAR(20) NOT NULL DEFAULT 'fifo'
+    CHECK (costing_method IN ('fifo', 'average'));
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS average_cost DECIMAL(12, 4) NOT NULL DEFAULT 0;
+
+-- Every ledger entry carries the cost it moved stock in or out at, so the value
+-- on hand at any date is the sum of values up to it
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS unit_cost DECIMAL(12, 4) NOT NULL DEFAULT 0;
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS value DECIMAL(14, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS cogs DECIMAL(14, 4) NOT NULL DEFAULT 0;
+
+-- Stock received at one cost; remaining is what has not been consumed yet.
+-- Opening layers created by this migration have no source.
+CREATE TABLE IF NOT EXISTS cost_layers (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    source_type VARCHAR(30),
+    source_id UUID,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    unit_cost DECIMAL(12, 4) NOT NULL CHECK (unit_cost >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_cost_layers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Existing stock is valued at the product's current cost
+UPDATE products SET average_cost = cost;
+
+UPDATE stock_movements m
+SET unit_cost = p.cost, value = m.delta * p.cost
+FROM products p
+WHERE p.id = m.product_id;
+
+UPDATE sale_items si
+SET cogs = si.quantity * p.cost
+FROM products p
+WHERE p.id = si.product_id;
+
+INSERT INTO cost_layers (id, product_id, quantity, remaining, unit_cost)
+SELECT md5(random()::text || id::text)::uuid, id, quantity, quantity, cost
+FROM products
+WHERE quantity > 0;
+
+-- Indexes
+CREATE INDEX idx_cost_layers_product_open ON cost_layers (product_id, created_at) WHERE remaining > 0;
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS cost_layers CASCADE;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS cogs;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS value;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE products DROP COLUMN IF EXISTS average_cost;
+ALTER TABLE inventories DROP COLUMN IF EXISTS costing_method;
@@ -0,0 +1,40 @@
+-- +goose Up
+
+-- Variants are ordinary products pointing at the parent they were generated
+-- from; the parent only describes them and holds no stock of its own
+ALTER TABLE products ADD COLUMN IF NOT EXISTS parent_id UUID;
+ALTER TABLE products ADD CONSTRAINT fk_products_parent FOREIGN KEY (parent_id) REFERENCES products (id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS product_variant_axes (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    options TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name),
+    CONSTRAINT fk_product_variant_axes_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- The option a variant has on each of its parent's axes
+CREATE TABLE IF NOT EXISTS product_variant_options (
+    product_id UUID NOT NULL,
+    axis_id UUID NOT NULL,
+    value VARCHAR(50) NOT NULL,
+    PRIMARY KEY (product_id, axis_id),
+    CONSTRAINT fk_product_variant_options_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_variant_options_axis FOREIGN KEY (axis_id) REFERENCES product_variant_axes (id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_products_parent ON products (parent_id) WHERE parent_id IS NOT NULL;
+CREATE INDEX idx_product_variant_options_axis ON product_variant_options (axis_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS product_variant_options CASCADE;
+DROP TABLE IF EXISTS product_variant_axes CASCADE;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS fk_products_parent;
+ALTER TABLE products DROP COLUMN IF EXISTS parent_id;
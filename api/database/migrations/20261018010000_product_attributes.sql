@@ -0,0 +1,30 @@
+-- +goose Up
+
+-- Custom fields an inventory defines for its products; options lists the
+-- allowed values of enum attributes
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    key VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'boolean', 'enum', 'date')),
+    options JSONB NOT NULL DEFAULT '[]',
+    is_required BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (inventory_id, key),
+    CONSTRAINT fk_attribute_definitions_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+-- Values keyed by attribute key
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+-- Indexes
+CREATE INDEX idx_products_attributes ON products USING GIN (attributes jsonb_path_ops);
+
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_products_attributes;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS attribute_definitions CASCADE;
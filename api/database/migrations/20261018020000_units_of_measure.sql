@@ -0,0 +1,46 @@
+-- +goose Up
+
+-- Every product counts its stock in a base unit
+ALTER TABLE products ADD COLUMN IF NOT EXISTS base_unit VARCHAR(50) NOT NULL DEFAULT 'each';
+
+-- Pack sizes a product is bought, stored or sold in; factor is the number of
+-- base units in one pack. Variants use their parent's units
+CREATE TABLE IF NOT EXISTS product_units (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    factor INTEGER NOT NULL CHECK (factor > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name),
+    CONSTRAINT fk_product_units_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Lines keep the unit and quantity they were entered in; quantity stays in
+-- base units
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS unit VARCHAR(50);
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS unit_quantity INTEGER;
+ALTER TABLE delivery_items ADD COLUMN IF NOT EXISTS unit VARCHAR(50);
+ALTER TABLE delivery_items ADD COLUMN IF NOT EXISTS unit_quantity INTEGER;
+ALTER TABLE purchase_order_receipts ADD COLUMN IF NOT EXISTS unit VARCHAR(50);
+ALTER TABLE purchase_order_receipts ADD COLUMN IF NOT EXISTS unit_quantity INTEGER;
+
+UPDATE sale_items SET unit = 'each', unit_quantity = quantity;
+UPDATE delivery_items SET unit = 'each', unit_quantity = quantity;
+UPDATE purchase_order_receipts SET unit = 'each', unit_quantity = quantity;
+
+ALTER TABLE sale_items ALTER COLUMN unit SET NOT NULL, ALTER COLUMN unit_quantity SET NOT NULL;
+ALTER TABLE delivery_items ALTER COLUMN unit SET NOT NULL, ALTER COLUMN unit_quantity SET NOT NULL;
+ALTER TABLE purchase_order_receipts ALTER COLUMN unit SET NOT NULL, ALTER COLUMN unit_quantity SET NOT NULL;
+
+
+-- +goose Down
+
+ALTER TABLE purchase_order_receipts DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE purchase_order_receipts DROP COLUMN IF EXISTS unit;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS unit;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS unit;
+DROP TABLE IF EXISTS product_units CASCADE;
+ALTER TABLE products DROP COLUMN IF EXISTS base_unit;
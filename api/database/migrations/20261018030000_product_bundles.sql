@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- Bundles are sold as one item but hold no stock; what they can sell comes
+-- from their components
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, product_id),
+    CONSTRAINT fk_bundle_components_bundle FOREIGN KEY (bundle_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_bundle_components_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE RESTRICT
+);
+
+-- The component stock each bundle line took, kept so returns put back exactly
+-- that even after the bundle changes
+CREATE TABLE IF NOT EXISTS sale_item_components (
+    sale_item_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (sale_item_id, product_id),
+    CONSTRAINT fk_sale_item_components_item FOREIGN KEY (sale_item_id) REFERENCES sale_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_item_components_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS delivery_item_components (
+    delivery_item_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (delivery_item_id, product_id),
+    CONSTRAINT fk_delivery_item_components_item FOREIGN KEY (delivery_item_id) REFERENCES delivery_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_item_components_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE RESTRICT
+);
+
+-- Indexes
+CREATE INDEX idx_bundle_components_product ON bundle_components (product_id);
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS delivery_item_components CASCADE;
+DROP TABLE IF EXISTS sale_item_components CASCADE;
+DROP TABLE IF EXISTS bundle_components CASCADE;
+ALTER TABLE products DROP COLUMN IF EXISTS is_bundle;
@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Blank codes are stored as NULL so they do not collide in the unique index
+UPDATE products SET code = NULL WHERE btrim(code) = '';
+
+-- A code must identify one product per inventory. Rather than guess which of
+-- several products sharing a code should keep it, stop and list them so they
+-- can be resolved by hand before migrating again.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(format('inventory %s, code %L: %s', inventory_id, code, products), E'\n')
+    INTO conflicts
+    FROM (
+        SELECT inventory_id, code, string_agg(format('%s (%s)', name, id), ', ' ORDER BY created_at, id) AS products
+        FROM products
+        WHERE code IS NOT NULL
+        GROUP BY inventory_id, code
+        HAVING COUNT(*) > 1
+    ) duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'products share codes within an inventory; give each a distinct code or clear it, then migrate again'
+            USING DETAIL = conflicts;
+    END IF;
+END;
+$$;
+-- +goose StatementEnd
+
+-- Indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_inventory_code ON products (inventory_id, code) WHERE code IS NOT NULL;
+
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_products_inventory_code;
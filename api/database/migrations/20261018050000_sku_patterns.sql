@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- The pattern new products' SKUs are generated from when none is given; empty
+-- leaves SKUs manual
+ALTER TABLE inventories ADD COLUMN IF NOT EXISTS sku_pattern VARCHAR(50) NOT NULL DEFAULT '';
+
+-- The last {SEQ} value handed out per inventory. Claiming a value updates the
+-- inventory's row, so concurrent product creations take turns and never share
+-- a number.
+CREATE TABLE IF NOT EXISTS sku_sequences (
+    inventory_id UUID PRIMARY KEY,
+    last_value BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT fk_sku_sequences_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS sku_sequences;
+ALTER TABLE inventories DROP COLUMN IF EXISTS sku_pattern;
@@ -0,0 +1,59 @@
+-- +goose Up
+
+-- Price and cost changes planned ahead; a NULL price or cost is left as it is.
+-- A change with an end date is temporary, e.g. a promotion: the values it
+-- replaced, kept in previous_price and previous_cost, come back when it ends.
+-- status moves from pending to active (temporary) or completed, or to
+-- cancelled
+CREATE TABLE IF NOT EXISTS scheduled_price_changes (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    price DECIMAL(10, 2) CHECK (price >= 0),
+    cost DECIMAL(10, 2) CHECK (cost >= 0),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    previous_price DECIMAL(10, 2),
+    previous_cost DECIMAL(10, 2),
+    note TEXT NOT NULL DEFAULT '',
+    user_id UUID,
+    applied_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (price IS NOT NULL OR cost IS NOT NULL),
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CONSTRAINT fk_scheduled_price_changes_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_scheduled_price_changes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Every price and cost a product has had, written whenever either changes.
+-- source tells what changed it: created, manual or schedule
+CREATE TABLE IF NOT EXISTS price_history (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    cost DECIMAL(10, 2) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    schedule_id UUID,
+    user_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_price_history_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_price_history_schedule FOREIGN KEY (schedule_id) REFERENCES scheduled_price_changes (id) ON DELETE SET NULL,
+    CONSTRAINT fk_price_history_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- Start the history of existing products from their current price and cost
+INSERT INTO price_history (id, product_id, price, cost, source, created_at)
+SELECT md5(p.id::text || 'price_history')::uuid, p.id, p.price, p.cost, 'created', p.updated_at
+FROM products p;
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history (product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_price_changes_due ON scheduled_price_changes (status, starts_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_price_changes_product ON scheduled_price_changes (product_id);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS price_history;
+DROP TABLE IF EXISTS scheduled_price_changes;
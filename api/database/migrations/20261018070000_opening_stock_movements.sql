@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- Stock that was on hand before the ledger existed has no movement, so the
+-- ledger sum falls short of the product total and valuations miss it. Book the
+-- gap as an opening movement dated before the product's first entry, valued at
+-- the cost the existing stock was given when cost layers were introduced.
+INSERT INTO stock_movements (
+    id, product_id, delta, balance, reason, source_type, unit_cost, value, created_at
+)
+SELECT md5(random()::text || p.id::text)::uuid, p.id,
+    p.quantity - COALESCE(l.total, 0),
+    p.quantity - COALESCE(l.total, 0),
+    'opening balance before stock ledger', 'adjustment',
+    p.cost,
+    (p.quantity - COALESCE(l.total, 0)) * p.cost,
+    LEAST(p.created_at, COALESCE(l.first_at - INTERVAL '1 microsecond', p.created_at))
+FROM products p
+LEFT JOIN (
+    SELECT product_id, SUM(delta) AS total, MIN(created_at) AS first_at
+    FROM stock_movements
+    GROUP BY product_id
+) l ON l.product_id = p.id
+WHERE p.quantity <> COALESCE(l.total, 0);
+
+
+-- +goose Down
+
+DELETE FROM stock_movements WHERE reason = 'opening balance before stock ledger' AND source_id IS NULL;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Stock backordered from a lot-tracked product is owed by its unassigned lot,
+-- the only lot that may go negative, so the lots keep summing to the product
+ALTER TABLE lots DROP CONSTRAINT IF EXISTS lots_quantity_check;
+ALTER TABLE lots ADD CONSTRAINT lots_quantity_check CHECK (quantity >= 0 OR lot_number = 'UNASSIGNED');
+
+
+-- +goose Down
+
+ALTER TABLE lots DROP CONSTRAINT IF EXISTS lots_quantity_check;
+ALTER TABLE lots ADD CONSTRAINT lots_quantity_check CHECK (quantity >= 0);
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdjustmentDirection string
+
+const (
+	AdjustmentDirectionIncrease AdjustmentDirection = "increase"
+	AdjustmentDirectionDecrease AdjustmentDirection = "decrease"
+	AdjustmentDirectionAny      AdjustmentDirection = "any"
+)
+
+// Allows reports whether a stock change of delta fits the direction.
+func (direction AdjustmentDirection) Allows(delta int) bool {
+	switch direction {
+	case AdjustmentDirectionIncrease:
+		return delta > 0
+	case AdjustmentDirectionDecrease:
+		return delta < 0
+	default:
+		return delta != 0
+	}
+}
+
+// AdjustmentReason is a reason code stock may be adjusted for. Each inventory
+// keeps its own list; shrinkage reasons count as losses in the shrinkage report.
+type AdjustmentReason struct {
+	Id          uuid.UUID           `db:"id" json:"id"`
+	InventoryId uuid.UUID           `db:"inventory_id" json:"inventoryId"`
+	Code        string              `db:"code" json:"code"`
+	Name        string              `db:"name" json:"name"`
+	Direction   AdjustmentDirection `db:"direction" json:"direction"`
+	IsShrinkage bool                `db:"is_shrinkage" json:"isShrinkage"`
+	CreatedAt   time.Time           `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time           `db:"updated_at" json:"updatedAt"`
+}
+
+// DefaultAdjustmentReasons returns the reason codes every new inventory starts with.
+func DefaultAdjustmentReasons(inventoryId uuid.UUID) []AdjustmentReason {
+	defaults := []AdjustmentReason{
+		{Code: "damaged", Name: "Damaged", Direction: AdjustmentDirectionDecrease, IsShrinkage: true},
+		{Code: "expired", Name: "Expired", Direction: AdjustmentDirectionDecrease, IsShrinkage: true},
+		{Code: "lost", Name: "Lost", Direction: AdjustmentDirectionDecrease, IsShrinkage: true},
+		{Code: "found", Name: "Found", Direction: AdjustmentDirectionIncrease},
+		{Code: "correction", Name: "Correction", Direction: AdjustmentDirectionAny},
+	}
+
+	for i := range defaults {
+		defaults[i].Id = uuid.New()
+		defaults[i].InventoryId = inventoryId
+		defaults[i].CreatedAt = time.Now()
+		defaults[i].UpdatedAt = time.Now()
+	}
+
+	return defaults
+}
+
+type StockAdjustment struct {
+	Id            uuid.UUID  `db:"id" json:"id"`
+	InventoryId   uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	ProductId     uuid.UUID  `db:"product_id" json:"productId"`
+	StorageUnitId *uuid.UUID `db:"storage_unit_id" json:"storageUnitId"`
+	LotId         *uuid.UUID `db:"lot_id" json:"lotId"`
+	ReasonId      uuid.UUID  `db:"reason_id" json:"reasonId"`
+	ReasonCode    string     `db:"reason_code" json:"reasonCode"`
+	Delta         int        `db:"delta" json:"delta"`
+	UnitCost      float64    `db:"unit_cost" json:"unitCost"`
+	Note          string     `db:"note" json:"note"`
+	UserId        *uuid.UUID `db:"user_id" json:"userId"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	Serials       []string   `db:"-" json:"serialNumbers,omitempty"`
+}
+
+// ShrinkageEntry totals the adjustments made for one reason over a period.
+// Value is the net quantity at the cost recorded with each adjustment.
+type ShrinkageEntry struct {
+	ReasonId      uuid.UUID `db:"reason_id" json:"reasonId"`
+	Code          string    `db:"code" json:"code"`
+	Name          string    `db:"name" json:"name"`
+	IsShrinkage   bool      `db:"is_shrinkage" json:"isShrinkage"`
+	Adjustments   int       `db:"adjustments" json:"adjustments"`
+	QuantityLost  int       `db:"quantity_lost" json:"quantityLost"`
+	QuantityFound int       `db:"quantity_found" json:"quantityFound"`
+	NetQuantity   int       `db:"net_quantity" json:"netQuantity"`
+	NetValue      float64   `db:"net_value" json:"netValue"`
+}
+
+// DTOs
+type AdjustmentReasonRequest struct {
+	InventoryId uuid.UUID           `json:"inventoryId" validate:"required"`
+	Code        string              `json:"code" validate:"required,min=2,max=50"`
+	Name        string              `json:"name" validate:"required,min=2,max=100"`
+	Direction   AdjustmentDirection `json:"direction" validate:"required,oneof=increase decrease any"`
+	IsShrinkage bool                `json:"isShrinkage"`
+}
+
+// StockAdjustmentRequest changes stock by Delta. Serialized products must name
+// the serial numbers of the items found or written off.
+type StockAdjustmentRequest struct {
+	ProductId     uuid.UUID  `json:"productId" validate:"required"`
+	StorageUnitId *uuid.UUID `json:"storageUnitId"`
+	LotId         *uuid.UUID `json:"lotId"`
+	Delta         int        `json:"delta" validate:"required"`
+	ReasonCode    string     `json:"reasonCode" validate:"required"`
+	Note          string     `json:"note"`
+	Serials       []string   `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
+}
+
+func (req *AdjustmentReasonRequest) ToCreateAdjustmentReasonRequest() *AdjustmentReason {
+	return &AdjustmentReason{
+		Id:          uuid.New(),
+		InventoryId: req.InventoryId,
+		Code:        req.Code,
+		Name:        req.Name,
+		Direction:   req.Direction,
+		IsShrinkage: req.IsShrinkage,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func (req *AdjustmentReasonRequest) ToUpdateAdjustmentReasonRequest(existing *AdjustmentReason) *AdjustmentReason {
+	existing.Code = req.Code
+	existing.Name = req.Name
+	existing.Direction = req.Direction
+	existing.IsShrinkage = req.IsShrinkage
+	existing.UpdatedAt = time.Now()
+	return existing
+}
+
+func (req *StockAdjustmentRequest) ToStockAdjustment(actor Actor) *StockAdjustment {
+	return &StockAdjustment{
+		Id:            uuid.New(),
+		ProductId:     req.ProductId,
+		StorageUnitId: req.StorageUnitId,
+		LotId:         req.LotId,
+		Delta:         req.Delta,
+		Note:          req.Note,
+		UserId:        actor.UserId,
+		CreatedAt:     time.Now(),
+		Serials:       req.Serials,
+	}
+}
+
+func (req *AdjustmentReasonRequest) Sanitize() {
+	req.Code = strings.ToLower(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+}
+
+func (req *StockAdjustmentRequest) Sanitize() {
+	req.ReasonCode = strings.ToLower(strings.TrimSpace(req.ReasonCode))
+	req.Note = strings.TrimSpace(req.Note)
+}
@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttributeType string
+
+const (
+	AttributeTypeText    AttributeType = "text"
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeBoolean AttributeType = "boolean"
+	AttributeTypeEnum    AttributeType = "enum"
+	AttributeTypeDate    AttributeType = "date"
+)
+
+// maxAttributeTextLength bounds text values so attributes stay short fields
+// rather than free-form notes.
+const maxAttributeTextLength = 255
+
+// AttributeDefinition is a custom product field defined by an inventory.
+// Products store their values under Key.
+type AttributeDefinition struct {
+	Id          uuid.UUID        `db:"id" json:"id"`
+	InventoryId uuid.UUID        `db:"inventory_id" json:"inventoryId"`
+	Key         string           `db:"key" json:"key"`
+	Name        string           `db:"name" json:"name"`
+	Type        AttributeType    `db:"type" json:"type"`
+	Options     AttributeOptions `db:"options" json:"options"`
+	IsRequired  bool             `db:"is_required" json:"isRequired"`
+	CreatedAt   time.Time        `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time        `db:"updated_at" json:"updatedAt"`
+}
+
+// AttributeValues holds a product's attribute values by key, stored as JSONB.
+type AttributeValues map[string]any
+
+// AttributeOptions lists the values an enum attribute allows, stored as JSONB.
+type AttributeOptions []string
+
+// ProductFilter narrows and orders a product listing. Attribute filters and
+// the sort key refer to attribute keys.
+type ProductFilter struct {
+	Search     string
+	Attributes []AttributeFilter
+	SortKey    string
+	SortDesc   bool
+}
+
+type AttributeFilterOp string
+
+const (
+	AttributeFilterEq  AttributeFilterOp = "eq"
+	AttributeFilterMin AttributeFilterOp = "min"
+	AttributeFilterMax AttributeFilterOp = "max"
+)
+
+// AttributeFilter compares one attribute against a value; min and max are
+// inclusive and apply to number and date attributes.
+type AttributeFilter struct {
+	Key   string
+	Op    AttributeFilterOp
+	Value string
+}
+
+// DTOs
+type AttributeDefinitionRequest struct {
+	InventoryId uuid.UUID     `json:"inventoryId" validate:"required"`
+	Key         string        `json:"key" validate:"required,max=50"`
+	Name        string        `json:"name" validate:"required,max=100"`
+	Type        AttributeType `json:"type" validate:"required,oneof=text number boolean enum date"`
+	Options     []string      `json:"options" validate:"required_if=Type enum,dive,required,max=100"`
+	IsRequired  bool          `json:"isRequired"`
+}
+
+func (req *AttributeDefinitionRequest) ToCreateAttributeDefinitionRequest() *AttributeDefinition {
+	return &AttributeDefinition{
+		Id:          uuid.New(),
+		InventoryId: req.InventoryId,
+		Key:         req.Key,
+		Name:        req.Name,
+		Type:        req.Type,
+		Options:     req.options(),
+		IsRequired:  req.IsRequired,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func (req *AttributeDefinitionRequest) ToUpdateAttributeDefinitionRequest(existing *AttributeDefinition) *AttributeDefinition {
+	existing.Key = req.Key
+	existing.Name = req.Name
+	existing.Type = req.Type
+	existing.Options = req.options()
+	existing.IsRequired = req.IsRequired
+	existing.UpdatedAt = time.Now()
+	return existing
+}
+
+// options keeps the option list only for enums.
+func (req *AttributeDefinitionRequest) options() AttributeOptions {
+	if req.Type != AttributeTypeEnum {
+		return AttributeOptions{}
+	}
+	return AttributeOptions(req.Options)
+}
+
+func (req *AttributeDefinitionRequest) Sanitize() {
+	req.Key = strings.ToLower(strings.TrimSpace(req.Key))
+	req.Name = strings.TrimSpace(req.Name)
+	for i := range req.Options {
+		req.Options[i] = strings.TrimSpace(req.Options[i])
+	}
+}
+
+// Normalize checks a value against the definition's type, returning it in the
+// form it is stored in: trimmed text, a number, a boolean, an allowed option
+// or a YYYY-MM-DD date.
+func (def *AttributeDefinition) Normalize(value any) (any, bool) {
+	switch def.Type {
+	case AttributeTypeText:
+		text, ok := value.(string)
+		text = strings.TrimSpace(text)
+		return text, ok && len(text) <= maxAttributeTextLength
+	case AttributeTypeNumber:
+		number, ok := value.(float64)
+		return number, ok
+	case AttributeTypeBoolean:
+		flag, ok := value.(bool)
+		return flag, ok
+	case AttributeTypeEnum:
+		option, ok := value.(string)
+		return option, ok && slices.Contains(def.Options, option)
+	case AttributeTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(text))
+		if err != nil {
+			return nil, false
+		}
+		return date.Format(time.DateOnly), true
+	}
+
+	return nil, false
+}
+
+func (values AttributeValues) Value() (driver.Value, error) {
+	if values == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(values)
+}
+
+func (values *AttributeValues) Scan(src any) error {
+	return scanJSON(src, values)
+}
+
+func (options AttributeOptions) Value() (driver.Value, error) {
+	if options == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(options)
+}
+
+func (options *AttributeOptions) Scan(src any) error {
+	return scanJSON(src, options)
+}
+
+func scanJSON(src any, dest any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dest)
+	}
+}
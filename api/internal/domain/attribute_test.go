@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributeNormalize(t *testing.T) {
+	text := AttributeDefinition{Type: AttributeTypeText}
+	number := AttributeDefinition{Type: AttributeTypeNumber}
+	boolean := AttributeDefinition{Type: AttributeTypeBoolean}
+	enum := AttributeDefinition{Type: AttributeTypeEnum, Options: AttributeOptions{"cotton", "wool"}}
+	date := AttributeDefinition{Type: AttributeTypeDate}
+
+	// Values arrive decoded from JSON, so numbers are float64
+	tests := []struct {
+		name       string
+		definition AttributeDefinition
+		value      any
+		want       any
+		wantOk     bool
+	}{
+		{"text", text, "Acme", "Acme", true},
+		{"text is trimmed", text, "  Acme  ", "Acme", true},
+		{"longest text", text, strings.Repeat("a", 255), strings.Repeat("a", 255), true},
+		{"text too long", text, strings.Repeat("a", 256), nil, false},
+		{"number as text", text, 12.0, nil, false},
+		{"number", number, 12.5, 12.5, true},
+		{"negative number", number, -3.0, -3.0, true},
+		{"text as number", number, "12", nil, false},
+		{"true", boolean, true, true, true},
+		{"false", boolean, false, false, true},
+		{"text as boolean", boolean, "true", nil, false},
+		{"allowed option", enum, "wool", "wool", true},
+		{"unknown option", enum, "silk", nil, false},
+		{"option in another case", enum, "Wool", nil, false},
+		{"date", date, "2026-03-07", "2026-03-07", true},
+		{"date is trimmed", date, " 2026-03-07 ", "2026-03-07", true},
+		{"impossible date", date, "2026-02-30", nil, false},
+		{"date with time", date, "2026-03-07T10:00:00Z", nil, false},
+		{"number as date", date, 20260307.0, nil, false},
+		{"nil", text, nil, nil, false},
+		{"unknown type", AttributeDefinition{Type: "colour"}, "red", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.definition.Normalize(test.value)
+			if ok != test.wantOk {
+				t.Fatalf("Normalize(%v) ok = %v, want %v", test.value, ok, test.wantOk)
+			}
+			if ok && got != test.want {
+				t.Errorf("Normalize(%v) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// BundleComponent is a product and how many of it go into one bundle.
+type BundleComponent struct {
+	BundleId  uuid.UUID `db:"bundle_id" json:"-"`
+	ProductId uuid.UUID `db:"product_id" json:"productId"`
+	Quantity  int       `db:"quantity" json:"quantity"`
+	Name      string    `db:"name" json:"name"`
+	SKU       string    `db:"sku" json:"sku"`
+	Available int       `db:"available" json:"available"`
+}
+
+// LineComponent is the component stock one bundle line of a sale or delivery
+// took, in the components' base units.
+type LineComponent struct {
+	ProductId uuid.UUID `db:"product_id" json:"productId"`
+	Quantity  int       `db:"quantity" json:"quantity"`
+}
+
+// DTOs
+type BundleComponentRequest struct {
+	ProductId uuid.UUID `json:"productId" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,min=1"`
+}
+
+// BundleRequest replaces a bundle's components. An empty list turns the
+// product back into an ordinary one.
+type BundleRequest struct {
+	Components []BundleComponentRequest `json:"components" validate:"max=50,dive"`
+}
@@ -17,20 +17,43 @@ const (
 	DeliveryStatusCancelled  DeliveryStatus = "cancelled"
 )
 
+// deliveryTransitions lists the statuses each delivery status may move to.
+var deliveryTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryStatusPending:    {DeliveryStatusProcessing, DeliveryStatusCancelled},
+	DeliveryStatusProcessing: {DeliveryStatusShipped, DeliveryStatusCancelled},
+	DeliveryStatusShipped:    {DeliveryStatusDelivered},
+}
+
+func (status DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	for _, allowed := range deliveryTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEditable reports whether the delivery's items and details may still change.
+func (status DeliveryStatus) IsEditable() bool {
+	return status == DeliveryStatusPending || status == DeliveryStatusProcessing
+}
+
 type Delivery struct {
-	Id               uuid.UUID      `db:"id" json:"id"`
-	InventoryId      uuid.UUID      `db:"inventory_id" json:"inventoryId"`
-	Status           DeliveryStatus `db:"status" json:"status"`
-	OrderDate        time.Time      `db:"order_date" json:"orderDate"`
-	DeliveredAt      *time.Time     `db:"delivered_at" json:"deliveredAt"`
-	RecipientName    string         `db:"recipient_name" json:"recipientName"`
-	RecipientAddress string         `db:"recipient_address" json:"recipientAddress"`
-	RecipientPhone   string         `db:"recipient_phone" json:"recipientPhone"`
-	TrackingNumber   string         `db:"tracking_number" json:"trackingNumber"`
-	Note             string         `db:"note" json:"note"`
-	CreatedAt        time.Time      `db:"created_at" json:"createdAt"`
-	UpdatedAt        time.Time      `db:"updated_at" json:"updatedAt"`
-	Items            []DeliveryItem `json:"items"`
+	Id               uuid.UUID              `db:"id" json:"id"`
+	InventoryId      uuid.UUID              `db:"inventory_id" json:"inventoryId"`
+	Status           DeliveryStatus         `db:"status" json:"status"`
+	OrderDate        time.Time              `db:"order_date" json:"orderDate"`
+	DeliveredAt      *time.Time             `db:"delivered_at" json:"deliveredAt"`
+	RecipientName    string                 `db:"recipient_name" json:"recipientName"`
+	RecipientAddress string                 `db:"recipient_address" json:"recipientAddress"`
+	RecipientPhone   string                 `db:"recipient_phone" json:"recipientPhone"`
+	TrackingNumber   string                 `db:"tracking_number" json:"trackingNumber"`
+	Note             string                 `db:"note" json:"note"`
+	StockDeducted    bool                   `db:"stock_deducted" json:"stockDeducted"`
+	CreatedAt        time.Time              `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time              `db:"updated_at" json:"updatedAt"`
+	Items            []DeliveryItem         `json:"items"`
+	History          []DeliveryStatusChange `json:"history,omitempty"`
 }
 
 type DeliveryItem struct {
@@ -38,17 +61,42 @@ type DeliveryItem struct {
 	DeliveryId uuid.UUID `db:"delivery_id" json:"deliveryId"`
 	ProductId  uuid.UUID `db:"product_id" json:"productId"`
 	Quantity   int       `db:"quantity" json:"quantity"`
-	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updatedAt"`
-	Product    *Product  `json:"product,omitempty"`
+	// Unit and UnitQuantity are what the line was entered as; Quantity is in
+	// the product's base unit.
+	Unit         string    `db:"unit" json:"unit"`
+	UnitQuantity int       `db:"unit_quantity" json:"unitQuantity"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+	Product      *Product  `json:"product,omitempty"`
+	Serials      []string  `db:"-" json:"serialNumbers,omitempty"`
+	// Components is the stock a bundle line takes from its components.
+	Components []LineComponent `db:"-" json:"components,omitempty"`
+}
+
+// StockLines lists what the item takes from stock: its bundle's components, or
+// the product itself.
+func (item *DeliveryItem) StockLines() []LineComponent {
+	if len(item.Components) > 0 {
+		return item.Components
+	}
+	return []LineComponent{{ProductId: item.ProductId, Quantity: item.Quantity}}
+}
+
+type DeliveryStatusChange struct {
+	Id         uuid.UUID       `db:"id" json:"id"`
+	DeliveryId uuid.UUID       `db:"delivery_id" json:"deliveryId"`
+	FromStatus *DeliveryStatus `db:"from_status" json:"fromStatus"`
+	ToStatus   DeliveryStatus  `db:"to_status" json:"toStatus"`
+	Note       string          `db:"note" json:"note"`
+	ChangedBy  *uuid.UUID      `db:"changed_by" json:"changedBy"`
+	CreatedAt  time.Time       `db:"created_at" json:"createdAt"`
 }
 
 // DTOs
 type DeliveryRequest struct {
 	InventoryId      uuid.UUID             `json:"inventoryId" validate:"required"`
-	Status           DeliveryStatus        `json:"status" validate:"required,oneof=pending processing shipped delivered cancelled"`
+	Status           DeliveryStatus        `json:"status" validate:"omitempty,oneof=pending processing shipped delivered cancelled"`
 	OrderDate        time.Time             `json:"orderDate" validate:"required"`
-	DeliveredAt      *time.Time            `json:"deliveredAt"`
 	RecipientName    string                `json:"recipientName" validate:"required"`
 	RecipientAddress string                `json:"recipientAddress" validate:"required"`
 	RecipientPhone   string                `json:"recipientPhone" validate:"required"`
@@ -57,18 +105,29 @@ type DeliveryRequest struct {
 	Items            []DeliveryItemRequest `json:"items" validate:"required,min=1"`
 }
 
+// DeliveryItemRequest is one line of a delivery, counted in Unit or the
+// product's base unit when empty. Serialized products must name the exact
+// serial numbers being sent.
 type DeliveryItemRequest struct {
 	ProductId uuid.UUID `json:"productId" validate:"required"`
 	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	Unit      string    `json:"unit" validate:"max=50"`
+	Serials   []string  `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
 }
 
+type DeliveryTransitionRequest struct {
+	Status DeliveryStatus `json:"status" validate:"required,oneof=processing shipped delivered cancelled"`
+	Note   string         `json:"note"`
+}
+
+// ToCreateDeliveryRequest builds a new delivery. Deliveries always start out as
+// pending; later statuses are reached through transitions.
 func (req *DeliveryRequest) ToCreateDeliveryRequest() *Delivery {
 	delivery := &Delivery{
 		Id:               uuid.New(),
 		InventoryId:      req.InventoryId,
-		Status:           req.Status,
+		Status:           DeliveryStatusPending,
 		OrderDate:        req.OrderDate,
-		DeliveredAt:      req.DeliveredAt,
 		RecipientName:    req.RecipientName,
 		RecipientAddress: req.RecipientAddress,
 		RecipientPhone:   req.RecipientPhone,
@@ -85,6 +144,8 @@ func (req *DeliveryRequest) ToCreateDeliveryRequest() *Delivery {
 			DeliveryId: delivery.Id,
 			ProductId:  item.ProductId,
 			Quantity:   item.Quantity,
+			Unit:       strings.TrimSpace(item.Unit),
+			Serials:    item.Serials,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
@@ -94,9 +155,7 @@ func (req *DeliveryRequest) ToCreateDeliveryRequest() *Delivery {
 }
 
 func (req *DeliveryRequest) ToUpdateDeliveryRequest(existingDelivery *Delivery) *Delivery {
-	existingDelivery.Status = req.Status
 	existingDelivery.OrderDate = req.OrderDate
-	existingDelivery.DeliveredAt = req.DeliveredAt
 	existingDelivery.RecipientName = req.RecipientName
 	existingDelivery.RecipientAddress = req.RecipientAddress
 	existingDelivery.RecipientPhone = req.RecipientPhone
@@ -111,6 +170,8 @@ func (req *DeliveryRequest) ToUpdateDeliveryRequest(existingDelivery *Delivery)
 			DeliveryId: existingDelivery.Id,
 			ProductId:  item.ProductId,
 			Quantity:   item.Quantity,
+			Unit:       strings.TrimSpace(item.Unit),
+			Serials:    item.Serials,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
@@ -119,6 +180,18 @@ func (req *DeliveryRequest) ToUpdateDeliveryRequest(existingDelivery *Delivery)
 	return existingDelivery
 }
 
+func NewDeliveryStatusChange(deliveryId uuid.UUID, from *DeliveryStatus, to DeliveryStatus, note string, actor Actor) *DeliveryStatusChange {
+	return &DeliveryStatusChange{
+		Id:         uuid.New(),
+		DeliveryId: deliveryId,
+		FromStatus: from,
+		ToStatus:   to,
+		Note:       note,
+		ChangedBy:  actor.UserId,
+		CreatedAt:  time.Now(),
+	}
+}
+
 func (req *DeliveryRequest) Sanitize() {
 	req.RecipientName = strings.TrimSpace(req.RecipientName)
 	req.RecipientAddress = strings.TrimSpace(req.RecipientAddress)
@@ -126,3 +199,7 @@ func (req *DeliveryRequest) Sanitize() {
 	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
 	req.Note = strings.TrimSpace(req.Note)
 }
+
+func (req *DeliveryTransitionRequest) Sanitize() {
+	req.Note = strings.TrimSpace(req.Note)
+}
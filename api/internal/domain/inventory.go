@@ -8,19 +8,25 @@ import (
 )
 
 type Inventory struct {
-	Id          uuid.UUID `db:"id" json:"id"`
-	Name        string    `db:"name" json:"name"`
-	Description string    `db:"description" json:"description"`
-	UserId      uuid.UUID `db:"user_id" json:"userId"`
-	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updatedAt"`
+	Id              uuid.UUID     `db:"id" json:"id"`
+	Name            string        `db:"name" json:"name"`
+	Description     string        `db:"description" json:"description"`
+	UserId          uuid.UUID     `db:"user_id" json:"userId"`
+	AllowBackorders bool          `db:"allow_backorders" json:"allowBackorders"`
+	CostingMethod   CostingMethod `db:"costing_method" json:"costingMethod"`
+	SKUPattern      string        `db:"sku_pattern" json:"skuPattern"`
+	CreatedAt       time.Time     `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updatedAt"`
 }
 
 // DTOs
 type InventoryRequest struct {
-	Name        string    `json:"name" validate:"required,min=3,max=50"`
-	Description string    `json:"description"`
-	UserId      uuid.UUID `json:"userId" validate:"required"`
+	Name            string        `json:"name" validate:"required,min=3,max=50"`
+	Description     string        `json:"description"`
+	UserId          uuid.UUID     `json:"userId" validate:"required"`
+	AllowBackorders *bool         `json:"allowBackorders"`
+	CostingMethod   CostingMethod `json:"costingMethod" validate:"omitempty,oneof=fifo average"`
+	SKUPattern      *string       `json:"skuPattern" validate:"omitempty,max=50"`
 }
 
 type InventoryResponse struct {
@@ -31,20 +37,41 @@ type InventoryResponse struct {
 }
 
 func (req *InventoryRequest) ToCreateInventoryRequest() *Inventory {
-	return &Inventory{
-		Id:          uuid.New(),
-		Name:        req.Name,
-		Description: req.Description,
-		UserId:      req.UserId,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	inventory := &Inventory{
+		Id:            uuid.New(),
+		Name:          req.Name,
+		Description:   req.Description,
+		UserId:        req.UserId,
+		CostingMethod: CostingMethodFIFO,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if req.AllowBackorders != nil {
+		inventory.AllowBackorders = *req.AllowBackorders
+	}
+	if req.CostingMethod != "" {
+		inventory.CostingMethod = req.CostingMethod
 	}
+	if req.SKUPattern != nil {
+		inventory.SKUPattern = *req.SKUPattern
+	}
+
+	return inventory
 }
 
 func (req *InventoryRequest) ToUpdateInventoryRequest(existingInventory *Inventory) *Inventory {
 	existingInventory.Name = req.Name
 	existingInventory.Description = req.Description
 	existingInventory.UserId = req.UserId
+	if req.AllowBackorders != nil {
+		existingInventory.AllowBackorders = *req.AllowBackorders
+	}
+	if req.CostingMethod != "" {
+		existingInventory.CostingMethod = req.CostingMethod
+	}
+	if req.SKUPattern != nil {
+		existingInventory.SKUPattern = *req.SKUPattern
+	}
 	existingInventory.UpdatedAt = time.Now()
 
 	return existingInventory
@@ -53,4 +80,8 @@ func (req *InventoryRequest) ToUpdateInventoryRequest(existingInventory *Invento
 func (req *InventoryRequest) Sanitize() {
 	req.Name = strings.TrimSpace(req.Name)
 	req.Description = strings.TrimSpace(req.Description)
+	if req.SKUPattern != nil {
+		pattern := strings.TrimSpace(*req.SKUPattern)
+		req.SKUPattern = &pattern
+	}
 }
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LabelKind string
+
+const (
+	LabelKindUnit    LabelKind = "unit"
+	LabelKindProduct LabelKind = "product"
+)
+
+// Label is what one printed label shows: a QR code of its payload, the name,
+// a detail line (the unit label or product SKU) and where it is stored.
+type Label struct {
+	Kind     LabelKind `db:"kind" json:"kind"`
+	Id       uuid.UUID `db:"id" json:"id"`
+	Name     string    `db:"name" json:"name"`
+	Detail   string    `db:"detail" json:"detail"`
+	Location string    `db:"location" json:"location"`
+}
+
+// Payload is what the label's QR code encodes, e.g. "unit:<id>", so a scan
+// tells units and products apart.
+func (label *Label) Payload() string {
+	return string(label.Kind) + ":" + label.Id.String()
+}
+
+// LabelTemplate is a sheet of die-cut labels. Sizes are in points (1/72 inch)
+// and margins are measured to the first label from the page's top left.
+type LabelTemplate struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	PageWidth   float64 `json:"pageWidth"`
+	PageHeight  float64 `json:"pageHeight"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"labelWidth"`
+	LabelHeight float64 `json:"labelHeight"`
+	MarginLeft  float64 `json:"marginLeft"`
+	MarginTop   float64 `json:"marginTop"`
+	PitchX      float64 `json:"pitchX"`
+	PitchY      float64 `json:"pitchY"`
+}
+
+func (template *LabelTemplate) PerSheet() int {
+	return template.Columns * template.Rows
+}
+
+const mm = 72 / 25.4
+
+// Label output formats. PDF lays labels out on sheets for office printers, ZPL
+// drives Zebra thermal printers one label at a time.
+const (
+	LabelFormatPDF = "pdf"
+	LabelFormatZPL = "zpl"
+)
+
+// Usual thermal label sizes in millimetres: 2 x 1 in for products and units,
+// 4 x 6 in for shipping.
+const (
+	ItemLabelWidth      = 50.8
+	ItemLabelHeight     = 25.4
+	ShippingLabelWidth  = 101.6
+	ShippingLabelHeight = 152.4
+	DefaultLabelDPI     = 203
+)
+
+// ZPLOptions sizes ZPL output: the label in millimetres and the printer's
+// resolution in dots per inch.
+type ZPLOptions struct {
+	Width  float64 `json:"width" query:"width" validate:"omitempty,min=10,max=300"`
+	Height float64 `json:"height" query:"height" validate:"omitempty,min=10,max=300"`
+	DPI    int     `json:"dpi" query:"dpi" validate:"omitempty,oneof=152 203 300 600"`
+}
+
+// WithDefaults fills in the size and resolution left unset.
+func (options ZPLOptions) WithDefaults(width, height float64) ZPLOptions {
+	if options.Width == 0 {
+		options.Width = width
+	}
+	if options.Height == 0 {
+		options.Height = height
+	}
+	if options.DPI == 0 {
+		options.DPI = DefaultLabelDPI
+	}
+	return options
+}
+
+// ShippingLabel is what a delivery's shipping label shows. ItemCount is the
+// number of pieces shipped, in base units.
+type ShippingLabel struct {
+	DeliveryId       uuid.UUID `db:"id" json:"deliveryId"`
+	Sender           string    `db:"sender" json:"sender"`
+	RecipientName    string    `db:"recipient_name" json:"recipientName"`
+	RecipientAddress string    `db:"recipient_address" json:"recipientAddress"`
+	RecipientPhone   string    `db:"recipient_phone" json:"recipientPhone"`
+	TrackingNumber   string    `db:"tracking_number" json:"trackingNumber"`
+	ItemCount        int       `db:"item_count" json:"itemCount"`
+	OrderDate        time.Time `db:"order_date" json:"orderDate"`
+}
+
+// Payload is what the shipping label's QR code encodes.
+func (label *ShippingLabel) Payload() string {
+	return "delivery:" + label.DeliveryId.String()
+}
+
+// LabelTemplates are the supported label sheets, by name.
+var LabelTemplates = map[string]LabelTemplate{
+	"avery-5160": {
+		Name: "avery-5160", Description: "Avery 5160, Letter, 3 x 10, 2.625 x 1 in",
+		PageWidth: 612, PageHeight: 792, Columns: 3, Rows: 10,
+		LabelWidth: 189, LabelHeight: 72, MarginLeft: 13.5, MarginTop: 36, PitchX: 198, PitchY: 72,
+	},
+	"avery-5163": {
+		Name: "avery-5163", Description: "Avery 5163, Letter, 2 x 5, 4 x 2 in",
+		PageWidth: 612, PageHeight: 792, Columns: 2, Rows: 5,
+		LabelWidth: 288, LabelHeight: 144, MarginLeft: 11.25, MarginTop: 36, PitchX: 301.5, PitchY: 144,
+	},
+	"avery-l7160": {
+		Name: "avery-l7160", Description: "Avery L7160, A4, 3 x 7, 63.5 x 38.1 mm",
+		PageWidth: 210 * mm, PageHeight: 297 * mm, Columns: 3, Rows: 7,
+		LabelWidth: 63.5 * mm, LabelHeight: 38.1 * mm, MarginLeft: 7.25 * mm, MarginTop: 15.15 * mm, PitchX: 66 * mm, PitchY: 38.1 * mm,
+	},
+}
+
+// DTOs
+
+// LabelSheetRequest picks what to print: every unit of a storage, chosen
+// units, chosen products, or any mix of them. Skip leaves the first labels of
+// the sheet blank so a partly used sheet can be fed again. As ZPL, the
+// template and skip are ignored and each label is sized by the ZPL options.
+type LabelSheetRequest struct {
+	Format     string      `json:"format" validate:"omitempty,oneof=pdf zpl"`
+	Template   string      `json:"template"`
+	StorageId  *uuid.UUID  `json:"storageId"`
+	UnitIds    []uuid.UUID `json:"unitIds" validate:"max=1000"`
+	ProductIds []uuid.UUID `json:"productIds" validate:"max=1000"`
+	Copies     int         `json:"copies" validate:"omitempty,min=1,max=100"`
+	Skip       int         `json:"skip" validate:"min=0,max=100"`
+	ZPLOptions
+}
+
+// ShippingLabelRequest picks the shipping label's format, and its size when
+// printed as ZPL.
+type ShippingLabelRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=pdf zpl"`
+	ZPLOptions
+}
+
+func (req *LabelSheetRequest) Sanitize() {
+	req.Template = strings.ToLower(strings.TrimSpace(req.Template))
+	if req.Format == "" {
+		req.Format = LabelFormatPDF
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+}
+
+func (req *ShippingLabelRequest) Sanitize() {
+	if req.Format == "" {
+		req.Format = LabelFormatPDF
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lot is a batch of a product received together. Quantity is how much of the
+// product's on-hand stock belongs to the lot.
+type Lot struct {
+	Id             uuid.UUID  `db:"id" json:"id"`
+	ProductId      uuid.UUID  `db:"product_id" json:"productId"`
+	LotNumber      string     `db:"lot_number" json:"lotNumber"`
+	ManufacturedOn *time.Time `db:"manufactured_on" json:"manufacturedOn"`
+	ExpiresOn      *time.Time `db:"expires_on" json:"expiresOn"`
+	Quantity       int        `db:"quantity" json:"quantity"`
+	CreatedAt      time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// UnassignedLotNumber names the lot that stock of a lot-tracked product is
+// booked to when it arrives without one, such as a stocktake surplus.
+const UnassignedLotNumber = "UNASSIGNED"
+
+// ExpiringLot is a lot with stock left that expires within the report window,
+// or already has.
+type ExpiringLot struct {
+	Lot
+	ProductName string `db:"product_name" json:"productName"`
+	SKU         string `db:"sku" json:"sku"`
+	DaysLeft    int    `db:"days_left" json:"daysLeft"`
+	Expired     bool   `db:"expired" json:"expired"`
+}
+
+// LotQuantity is an amount of stock taken from a lot, or from untracked stock
+// when LotId is nil.
+type LotQuantity struct {
+	LotId    *uuid.UUID `db:"lot_id" json:"lotId"`
+	Quantity int        `db:"quantity" json:"quantity"`
+}
+
+type LotAllocation struct {
+	Id         uuid.UUID      `db:"id" json:"id"`
+	LotId      uuid.UUID      `db:"lot_id" json:"lotId"`
+	ProductId  uuid.UUID      `db:"product_id" json:"productId"`
+	SourceType MovementSource `db:"source_type" json:"sourceType"`
+	SourceId   uuid.UUID      `db:"source_id" json:"sourceId"`
+	Quantity   int            `db:"quantity" json:"quantity"`
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// DTOs
+type LotRequest struct {
+	LotNumber      string     `json:"lotNumber" validate:"required_with=ManufacturedOn ExpiresOn,max=100"`
+	ManufacturedOn *time.Time `json:"manufacturedOn"`
+	ExpiresOn      *time.Time `json:"expiresOn"`
+}
+
+func (req *LotRequest) Sanitize() {
+	req.LotNumber = strings.TrimSpace(req.LotNumber)
+}
+
+// ToLot builds a new, empty lot for the product.
+func (req *LotRequest) ToLot(productId uuid.UUID) *Lot {
+	return &Lot{
+		Id:             uuid.New(),
+		ProductId:      productId,
+		LotNumber:      req.LotNumber,
+		ManufacturedOn: req.ManufacturedOn,
+		ExpiresOn:      req.ExpiresOn,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+}
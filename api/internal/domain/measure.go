@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBaseUnit is the base unit products start out with.
+const DefaultBaseUnit = "each"
+
+// UnitOfMeasure is a pack a product is handled in, such as a case of 24.
+// Factor is the number of base units in one pack.
+type UnitOfMeasure struct {
+	Id        uuid.UUID `db:"id" json:"id"`
+	ProductId uuid.UUID `db:"product_id" json:"productId"`
+	Name      string    `db:"name" json:"name"`
+	Factor    int       `db:"factor" json:"factor"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// DTOs
+type UnitOfMeasureRequest struct {
+	Name   string `json:"name" validate:"required,max=50"`
+	Factor int    `json:"factor" validate:"required,min=1"`
+}
+
+// ProductUnitsRequest sets a product's base unit and replaces its packs.
+type ProductUnitsRequest struct {
+	BaseUnit string                 `json:"baseUnit" validate:"required,max=50"`
+	Units    []UnitOfMeasureRequest `json:"units" validate:"omitempty,max=10,dive"`
+}
+
+func (req *ProductUnitsRequest) ToUnitsOfMeasure(productId uuid.UUID) []UnitOfMeasure {
+	units := make([]UnitOfMeasure, len(req.Units))
+	for i, unit := range req.Units {
+		units[i] = UnitOfMeasure{
+			Id:        uuid.New(),
+			ProductId: productId,
+			Name:      unit.Name,
+			Factor:    unit.Factor,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
+	return units
+}
+
+func (req *ProductUnitsRequest) Sanitize() {
+	req.BaseUnit = strings.TrimSpace(req.BaseUnit)
+	for i := range req.Units {
+		req.Units[i].Name = strings.TrimSpace(req.Units[i].Name)
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MovementSource string
+
+const (
+	MovementSourceDelivery   MovementSource = "delivery"
+	MovementSourceSale       MovementSource = "sale"
+	MovementSourceAdjustment MovementSource = "adjustment"
+	MovementSourcePurchase   MovementSource = "purchase_order"
+	MovementSourceStocktake  MovementSource = "stocktake"
+	MovementSourceTransfer   MovementSource = "transfer"
+)
+
+// StockMovement is a single ledger entry describing a change to a product's on-hand quantity.
+// Value is the signed cost of the stock moved; InboundCost, when set, is what
+// incoming stock cost and otherwise the product's current cost is used.
+type StockMovement struct {
+	Id            uuid.UUID      `db:"id" json:"id"`
+	ProductId     uuid.UUID      `db:"product_id" json:"productId"`
+	StorageUnitId *uuid.UUID     `db:"storage_unit_id" json:"storageUnitId"`
+	Delta         int            `db:"delta" json:"delta"`
+	Balance       int            `db:"balance" json:"balance"`
+	UnitCost      float64        `db:"unit_cost" json:"unitCost"`
+	Value         float64        `db:"value" json:"value"`
+	Reason        string         `db:"reason" json:"reason"`
+	SourceType    MovementSource `db:"source_type" json:"sourceType"`
+	SourceId      *uuid.UUID     `db:"source_id" json:"sourceId"`
+	UserId        *uuid.UUID     `db:"user_id" json:"userId"`
+	RequestId     *string        `db:"request_id" json:"requestId"`
+	CreatedAt     time.Time      `db:"created_at" json:"createdAt"`
+	InboundCost   *float64       `db:"-" json:"-"`
+}
+
+// Actor identifies the user and request responsible for a change.
+type Actor struct {
+	UserId    *uuid.UUID
+	RequestId *string
+}
+
+type MovementFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+func NewStockMovement(productId uuid.UUID, delta int, source MovementSource, sourceId *uuid.UUID, reason string, actor Actor) *StockMovement {
+	return &StockMovement{
+		Id:         uuid.New(),
+		ProductId:  productId,
+		Delta:      delta,
+		Reason:     reason,
+		SourceType: source,
+		SourceId:   sourceId,
+		UserId:     actor.UserId,
+		RequestId:  actor.RequestId,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PriceSource string
+
+const (
+	PriceSourceCreated  PriceSource = "created"
+	PriceSourceManual   PriceSource = "manual"
+	PriceSourceSchedule PriceSource = "schedule"
+)
+
+// PriceHistoryEntry is a product's price and cost from CreatedAt until the
+// next entry.
+type PriceHistoryEntry struct {
+	Id         uuid.UUID   `db:"id" json:"id"`
+	ProductId  uuid.UUID   `db:"product_id" json:"productId"`
+	Price      float64     `db:"price" json:"price"`
+	Cost       float64     `db:"cost" json:"cost"`
+	Source     PriceSource `db:"source" json:"source"`
+	ScheduleId *uuid.UUID  `db:"schedule_id" json:"scheduleId"`
+	UserId     *uuid.UUID  `db:"user_id" json:"userId"`
+	CreatedAt  time.Time   `db:"created_at" json:"createdAt"`
+}
+
+type PriceChangeStatus string
+
+const (
+	PriceChangePending   PriceChangeStatus = "pending"
+	PriceChangeActive    PriceChangeStatus = "active"
+	PriceChangeCompleted PriceChangeStatus = "completed"
+	PriceChangeCancelled PriceChangeStatus = "cancelled"
+)
+
+// ScheduledPriceChange sets a product's price, cost or both at StartsAt. With
+// an EndsAt it is temporary: it stays active until then and the previous
+// values come back, unless they were changed again in the meantime.
+type ScheduledPriceChange struct {
+	Id            uuid.UUID         `db:"id" json:"id"`
+	ProductId     uuid.UUID         `db:"product_id" json:"productId"`
+	Price         *float64          `db:"price" json:"price"`
+	Cost          *float64          `db:"cost" json:"cost"`
+	StartsAt      time.Time         `db:"starts_at" json:"startsAt"`
+	EndsAt        *time.Time        `db:"ends_at" json:"endsAt"`
+	Status        PriceChangeStatus `db:"status" json:"status"`
+	PreviousPrice *float64          `db:"previous_price" json:"previousPrice"`
+	PreviousCost  *float64          `db:"previous_cost" json:"previousCost"`
+	Note          string            `db:"note" json:"note"`
+	UserId        *uuid.UUID        `db:"user_id" json:"userId"`
+	AppliedAt     *time.Time        `db:"applied_at" json:"appliedAt"`
+	EndedAt       *time.Time        `db:"ended_at" json:"endedAt"`
+	CreatedAt     time.Time         `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time         `db:"updated_at" json:"updatedAt"`
+}
+
+// IsTemporary reports whether the change ends and restores the previous values.
+func (change *ScheduledPriceChange) IsTemporary() bool {
+	return change.EndsAt != nil
+}
+
+// DTOs
+type ScheduledPriceChangeRequest struct {
+	Price    *float64   `json:"price" validate:"omitempty,min=0"`
+	Cost     *float64   `json:"cost" validate:"omitempty,min=0"`
+	StartsAt time.Time  `json:"startsAt" validate:"required"`
+	EndsAt   *time.Time `json:"endsAt"`
+	Note     string     `json:"note" validate:"max=500"`
+}
+
+func (req *ScheduledPriceChangeRequest) ToScheduledPriceChange(productId uuid.UUID, actor Actor) *ScheduledPriceChange {
+	return &ScheduledPriceChange{
+		Id:        uuid.New(),
+		ProductId: productId,
+		Price:     req.Price,
+		Cost:      req.Cost,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Status:    PriceChangePending,
+		Note:      req.Note,
+		UserId:    actor.UserId,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (req *ScheduledPriceChangeRequest) Sanitize() {
+	req.Note = strings.TrimSpace(req.Note)
+}
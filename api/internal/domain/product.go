@@ -8,39 +8,66 @@ import (
 )
 
 type Product struct {
-	Id           uuid.UUID  `db:"id" json:"id"`
-	Name         string     `db:"name" json:"name"`
-	Description  *string    `db:"description" json:"description"`
-	SKU          string     `db:"sku" json:"sku"`
-	Code         *string    `db:"code" json:"code"`
-	Quantity     int        `db:"quantity" json:"quantity"`
-	RestockLevel int        `db:"restock_level" json:"restockLevel"`
-	OptimalLevel int        `db:"optimal_level" json:"optimalLevel"`
-	Cost         float64    `db:"cost" json:"cost"`
-	Price        float64    `db:"price" json:"price"`
-	InventoryId  uuid.UUID  `db:"inventory_id" json:"inventoryId"`
-	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time  `db:"updated_at" json:"updatedAt"`
-	Categories   []Category `db:"categories" json:"categories"`
-	Storages     []Storage  `db:"storages" json:"storages"`
-	Images       []Image    `db:"images" json:"images"`
+	Id           uuid.UUID         `db:"id" json:"id"`
+	Name         string            `db:"name" json:"name"`
+	Description  *string           `db:"description" json:"description"`
+	SKU          string            `db:"sku" json:"sku"`
+	Code         *string           `db:"code" json:"code"`
+	Quantity     int               `db:"quantity" json:"quantity"`
+	Reserved     int               `db:"reserved" json:"reserved"`
+	Available    int               `db:"available" json:"available"`
+	RestockLevel int               `db:"restock_level" json:"restockLevel"`
+	OptimalLevel int               `db:"optimal_level" json:"optimalLevel"`
+	Cost         float64           `db:"cost" json:"cost"`
+	AverageCost  float64           `db:"average_cost" json:"averageCost"`
+	Price        float64           `db:"price" json:"price"`
+	UnitVolume   float64           `db:"unit_volume" json:"unitVolume"`
+	UnitWeight   float64           `db:"unit_weight" json:"unitWeight"`
+	IsSerialized bool              `db:"is_serialized" json:"isSerialized"`
+	BaseUnit     string            `db:"base_unit" json:"baseUnit"`
+	IsBundle     bool              `db:"is_bundle" json:"isBundle"`
+	InventoryId  uuid.UUID         `db:"inventory_id" json:"inventoryId"`
+	ParentId     *uuid.UUID        `db:"parent_id" json:"parentId"`
+	Attributes   AttributeValues   `db:"attributes" json:"attributes"`
+	CreatedAt    time.Time         `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time         `db:"updated_at" json:"updatedAt"`
+	Categories   []Category        `db:"categories" json:"categories"`
+	Storages     []Storage         `db:"storages" json:"storages"`
+	Images       []Image           `db:"images" json:"images"`
+	Suppliers    []ProductSupplier `db:"suppliers" json:"suppliers"`
+	Units        []UnitOfMeasure   `db:"-" json:"units"`
+	// A bundle's quantity and availability are derived from its components.
+	Components []BundleComponent `db:"-" json:"components,omitempty"`
+	// A parent lists its axes and variants; a variant lists its options and
+	// shares its parent's categories, storages, images and suppliers.
+	VariantAxes []VariantAxis   `db:"-" json:"variantAxes,omitempty"`
+	Variants    []Product       `db:"-" json:"variants,omitempty"`
+	Options     []VariantOption `db:"-" json:"options,omitempty"`
 }
 
 // DTOs
 type ProductRequest struct {
 	Name         string    `json:"name" validate:"required"`
 	Description  *string   `json:"description"`
-	SKU          string    `json:"sku" validate:"required"`
+	SKU          string    `json:"sku" validate:"max=50"`
 	Code         *string   `json:"code"`
 	Quantity     int       `json:"quantity"`
 	RestockLevel int       `json:"restockLevel"`
 	OptimalLevel int       `json:"optimalLevel"`
 	Cost         float64   `json:"cost"`
 	Price        float64   `json:"price"`
+	UnitVolume   float64   `json:"unitVolume" validate:"min=0"`
+	UnitWeight   float64   `json:"unitWeight" validate:"min=0"`
+	IsSerialized bool      `json:"isSerialized"`
 	InventoryId  uuid.UUID `json:"inventoryId" validate:"required"`
 	Categories   []string  `db:"categories" json:"categories"`
 	Storages     []Storage `db:"storages" json:"storages"`
 	Images       []string  `db:"images" json:"images"`
+	// Suppliers replaces the product's supplier catalog when present; leaving
+	// it out keeps the existing entries.
+	Suppliers []ProductSupplierRequest `json:"suppliers" validate:"omitempty,dive"`
+	// Attributes replaces the product's custom attribute values when present.
+	Attributes AttributeValues `json:"attributes"`
 }
 
 type ProductResponse struct {
@@ -50,6 +77,8 @@ type ProductResponse struct {
 	SKU          string    `json:"sku"`
 	Code         *string   `json:"code"`
 	Quantity     int       `json:"quantity"`
+	Reserved     int       `json:"reserved"`
+	Available    int       `json:"available"`
 	RestockLevel int       `json:"restockLevel"`
 	OptimalLevel int       `json:"optimalLevel"`
 	Cost         float64   `json:"cost"`
@@ -71,7 +100,12 @@ func (req *ProductRequest) ToCreateProductRequest() *Product {
 		OptimalLevel: req.OptimalLevel,
 		Cost:         req.Cost,
 		Price:        req.Price,
+		UnitVolume:   req.UnitVolume,
+		UnitWeight:   req.UnitWeight,
+		IsSerialized: req.IsSerialized,
+		BaseUnit:     DefaultBaseUnit,
 		InventoryId:  req.InventoryId,
+		Attributes:   req.Attributes,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -80,14 +114,22 @@ func (req *ProductRequest) ToCreateProductRequest() *Product {
 func (req *ProductRequest) ToEditProductRequest(existingProduct *Product) *Product {
 	existingProduct.Name = req.Name
 	existingProduct.Description = req.Description
-	existingProduct.SKU = req.SKU
+	if req.SKU != "" {
+		existingProduct.SKU = req.SKU
+	}
 	existingProduct.Code = req.Code
 	existingProduct.Quantity = req.Quantity
 	existingProduct.RestockLevel = req.RestockLevel
 	existingProduct.OptimalLevel = req.OptimalLevel
 	existingProduct.Cost = req.Cost
 	existingProduct.Price = req.Price
+	existingProduct.UnitVolume = req.UnitVolume
+	existingProduct.UnitWeight = req.UnitWeight
+	existingProduct.IsSerialized = req.IsSerialized
 	existingProduct.InventoryId = req.InventoryId
+	if req.Attributes != nil {
+		existingProduct.Attributes = req.Attributes
+	}
 	existingProduct.UpdatedAt = time.Now()
 
 	return existingProduct
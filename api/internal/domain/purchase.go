@@ -0,0 +1,268 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusOrdered           PurchaseOrderStatus = "ordered"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed"
+)
+
+// purchaseOrderTransitions lists the statuses a purchase order may be moved to by
+// hand. The received statuses are only reached by receiving goods.
+var purchaseOrderTransitions = map[PurchaseOrderStatus][]PurchaseOrderStatus{
+	PurchaseOrderStatusDraft:             {PurchaseOrderStatusOrdered},
+	PurchaseOrderStatusOrdered:           {PurchaseOrderStatusClosed},
+	PurchaseOrderStatusPartiallyReceived: {PurchaseOrderStatusClosed},
+	PurchaseOrderStatusReceived:          {PurchaseOrderStatusClosed},
+}
+
+func (status PurchaseOrderStatus) CanTransitionTo(next PurchaseOrderStatus) bool {
+	for _, allowed := range purchaseOrderTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReceive reports whether goods may still be booked in against the order.
+func (status PurchaseOrderStatus) CanReceive() bool {
+	return status == PurchaseOrderStatusOrdered || status == PurchaseOrderStatusPartiallyReceived
+}
+
+type PurchaseOrder struct {
+	Id           uuid.UUID              `db:"id" json:"id"`
+	InventoryId  uuid.UUID              `db:"inventory_id" json:"inventoryId"`
+	SupplierId   uuid.UUID              `db:"supplier_id" json:"supplierId"`
+	Reference    string                 `db:"reference" json:"reference"`
+	Status       PurchaseOrderStatus    `db:"status" json:"status"`
+	OrderDate    time.Time              `db:"order_date" json:"orderDate"`
+	ExpectedDate *time.Time             `db:"expected_date" json:"expectedDate"`
+	Note         string                 `db:"note" json:"note"`
+	CreatedAt    time.Time              `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time              `db:"updated_at" json:"updatedAt"`
+	Supplier     *Supplier              `json:"supplier,omitempty"`
+	Lines        []PurchaseOrderLine    `json:"lines"`
+	Receipts     []PurchaseOrderReceipt `json:"receipts,omitempty"`
+}
+
+// PurchaseOrderLine is one product on a purchase order. Variance is negative
+// while the line is under-received and positive once more arrived than ordered.
+type PurchaseOrderLine struct {
+	Id               uuid.UUID `db:"id" json:"id"`
+	PurchaseOrderId  uuid.UUID `db:"purchase_order_id" json:"purchaseOrderId"`
+	ProductId        uuid.UUID `db:"product_id" json:"productId"`
+	QuantityOrdered  int       `db:"quantity_ordered" json:"quantityOrdered"`
+	QuantityReceived int       `db:"quantity_received" json:"quantityReceived"`
+	Variance         int       `db:"variance" json:"variance"`
+	UnitCost         float64   `db:"unit_cost" json:"unitCost"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updatedAt"`
+	Product          *Product  `json:"product,omitempty"`
+}
+
+// PurchaseOrderReceipt records one booking of received goods against a line.
+type PurchaseOrderReceipt struct {
+	Id              uuid.UUID  `db:"id" json:"id"`
+	PurchaseOrderId uuid.UUID  `db:"purchase_order_id" json:"purchaseOrderId"`
+	LineId          uuid.UUID  `db:"line_id" json:"lineId"`
+	ProductId       uuid.UUID  `db:"product_id" json:"productId"`
+	StorageUnitId   *uuid.UUID `db:"storage_unit_id" json:"storageUnitId"`
+	LotId           *uuid.UUID `db:"lot_id" json:"lotId"`
+	Quantity        int        `db:"quantity" json:"quantity"`
+	Unit            string     `db:"unit" json:"unit"`
+	UnitQuantity    int        `db:"unit_quantity" json:"unitQuantity"`
+	Note            string     `db:"note" json:"note"`
+	ReceivedBy      *uuid.UUID `db:"received_by" json:"receivedBy"`
+	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// DTOs
+type PurchaseOrderRequest struct {
+	InventoryId  uuid.UUID                  `json:"inventoryId" validate:"required"`
+	SupplierId   uuid.UUID                  `json:"supplierId" validate:"required"`
+	Reference    string                     `json:"reference" validate:"max=100"`
+	OrderDate    time.Time                  `json:"orderDate" validate:"required"`
+	ExpectedDate *time.Time                 `json:"expectedDate"`
+	Note         string                     `json:"note"`
+	Lines        []PurchaseOrderLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+type PurchaseOrderLineRequest struct {
+	ProductId uuid.UUID `json:"productId" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	UnitCost  float64   `json:"unitCost" validate:"min=0"`
+}
+
+type PurchaseOrderTransitionRequest struct {
+	Status PurchaseOrderStatus `json:"status" validate:"required,oneof=ordered closed"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Note  string               `json:"note"`
+	Lines []ReceiptLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// ReceiptLineRequest books received goods, optionally as a lot. Quantity is
+// counted in Unit, or the product's base unit when empty. Receiving an existing
+// lot number again adds to that lot. Serialized products must list one serial
+// number per base unit received.
+type ReceiptLineRequest struct {
+	ProductId     uuid.UUID  `json:"productId" validate:"required"`
+	Quantity      int        `json:"quantity" validate:"required,min=1"`
+	Unit          string     `json:"unit" validate:"max=50"`
+	StorageUnitId *uuid.UUID `json:"storageUnitId"`
+	Lot           LotRequest `json:"lot"`
+	Serials       []string   `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
+}
+
+// ToCreatePurchaseOrderRequest builds a new purchase order. Orders always start
+// out as drafts so their lines can be reviewed before they are sent.
+func (req *PurchaseOrderRequest) ToCreatePurchaseOrderRequest() *PurchaseOrder {
+	order := &PurchaseOrder{
+		Id:           uuid.New(),
+		InventoryId:  req.InventoryId,
+		SupplierId:   req.SupplierId,
+		Reference:    req.Reference,
+		Status:       PurchaseOrderStatusDraft,
+		OrderDate:    req.OrderDate,
+		ExpectedDate: req.ExpectedDate,
+		Note:         req.Note,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	order.Lines = req.toLines(order.Id)
+
+	return order
+}
+
+func (req *PurchaseOrderRequest) ToUpdatePurchaseOrderRequest(existing *PurchaseOrder) *PurchaseOrder {
+	existing.SupplierId = req.SupplierId
+	existing.Reference = req.Reference
+	existing.OrderDate = req.OrderDate
+	existing.ExpectedDate = req.ExpectedDate
+	existing.Note = req.Note
+	existing.UpdatedAt = time.Now()
+	existing.Lines = req.toLines(existing.Id)
+
+	return existing
+}
+
+func (req *PurchaseOrderRequest) toLines(orderId uuid.UUID) []PurchaseOrderLine {
+	lines := make([]PurchaseOrderLine, len(req.Lines))
+	for i, line := range req.Lines {
+		lines[i] = PurchaseOrderLine{
+			Id:              uuid.New(),
+			PurchaseOrderId: orderId,
+			ProductId:       line.ProductId,
+			QuantityOrdered: line.Quantity,
+			Variance:        -line.Quantity,
+			UnitCost:        line.UnitCost,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+	}
+	return lines
+}
+
+func NewPurchaseOrderReceipt(line *PurchaseOrderLine, quantity int, storageUnitId *uuid.UUID, note string, actor Actor) *PurchaseOrderReceipt {
+	return &PurchaseOrderReceipt{
+		Id:              uuid.New(),
+		PurchaseOrderId: line.PurchaseOrderId,
+		LineId:          line.Id,
+		ProductId:       line.ProductId,
+		StorageUnitId:   storageUnitId,
+		Quantity:        quantity,
+		Note:            note,
+		ReceivedBy:      actor.UserId,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func (req *PurchaseOrderRequest) Sanitize() {
+	req.Reference = strings.TrimSpace(req.Reference)
+	req.Note = strings.TrimSpace(req.Note)
+}
+
+func (req *ReceivePurchaseOrderRequest) Sanitize() {
+	req.Note = strings.TrimSpace(req.Note)
+	for i := range req.Lines {
+		req.Lines[i].Unit = strings.TrimSpace(req.Lines[i].Unit)
+		req.Lines[i].Lot.Sanitize()
+	}
+}
+
+// ReorderSuggestion is a product whose stock position (available plus still
+// on order) has fallen to its restock level, with the supplier to buy from.
+type ReorderSuggestion struct {
+	ProductId         uuid.UUID  `db:"product_id" json:"productId"`
+	ProductName       string     `db:"product_name" json:"productName"`
+	SKU               string     `db:"sku" json:"sku"`
+	Quantity          int        `db:"quantity" json:"quantity"`
+	Reserved          int        `db:"reserved" json:"reserved"`
+	Available         int        `db:"available" json:"available"`
+	OnOrder           int        `db:"on_order" json:"onOrder"`
+	RestockLevel      int        `db:"restock_level" json:"restockLevel"`
+	OptimalLevel      int        `db:"optimal_level" json:"optimalLevel"`
+	SupplierId        *uuid.UUID `db:"supplier_id" json:"supplierId"`
+	SupplierName      string     `db:"supplier_name" json:"-"`
+	UnitCost          float64    `db:"unit_cost" json:"unitCost"`
+	MinOrderQuantity  int        `db:"min_order_quantity" json:"minOrderQuantity"`
+	PackSize          int        `db:"pack_size" json:"packSize"`
+	LeadTimeDays      int        `db:"lead_time_days" json:"leadTimeDays"`
+	SuggestedQuantity int        `db:"-" json:"suggestedQuantity"`
+}
+
+// Suggest works out how much to order to bring the stock position back up to
+// the optimal level, honouring the supplier's minimum order and pack size.
+func (s *ReorderSuggestion) Suggest() int {
+	target := s.OptimalLevel
+	if target < s.RestockLevel {
+		target = s.RestockLevel
+	}
+
+	needed := target - (s.Available + s.OnOrder)
+	if needed <= 0 {
+		return 0
+	}
+
+	if needed < s.MinOrderQuantity {
+		needed = s.MinOrderQuantity
+	}
+	if s.PackSize > 1 {
+		needed = (needed + s.PackSize - 1) / s.PackSize * s.PackSize
+	}
+
+	return needed
+}
+
+// ReorderGroup collects the suggestions for one supplier. Products without a
+// known supplier are grouped under a nil SupplierId.
+type ReorderGroup struct {
+	SupplierId    *uuid.UUID          `json:"supplierId"`
+	SupplierName  string              `json:"supplierName"`
+	EstimatedCost float64             `json:"estimatedCost"`
+	Items         []ReorderSuggestion `json:"items"`
+}
+
+type ReorderRequest struct {
+	Items []ReorderItemRequest `json:"items" validate:"omitempty,dive"`
+}
+
+// ReorderItemRequest picks a product to reorder. Quantity and SupplierId
+// default to the suggested values when left out.
+type ReorderItemRequest struct {
+	ProductId  uuid.UUID  `json:"productId" validate:"required"`
+	Quantity   int        `json:"quantity" validate:"min=0"`
+	SupplierId *uuid.UUID `json:"supplierId"`
+}
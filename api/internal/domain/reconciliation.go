@@ -0,0 +1,40 @@
+package domain
+
+import "github.com/google/uuid"
+
+type ReconciliationFixMode string
+
+const (
+	// ReconciliationFixAdjustment sets the product total to the located stock.
+	ReconciliationFixAdjustment ReconciliationFixMode = "adjustment"
+	// ReconciliationFixUnlocated keeps the product total and books the
+	// difference into the inventory's unlocated storage.
+	ReconciliationFixUnlocated ReconciliationFixMode = "unlocated"
+)
+
+// ReconciliationEntry is a product whose on-hand total differs from the stock
+// located in its inventory's storage units. Difference is positive when more
+// is on hand than located.
+type ReconciliationEntry struct {
+	ProductId   uuid.UUID      `db:"product_id" json:"productId"`
+	ProductName string         `db:"product_name" json:"productName"`
+	SKU         string         `db:"sku" json:"sku"`
+	OnHand      int            `db:"on_hand" json:"onHand"`
+	Located     int            `db:"located" json:"located"`
+	Difference  int            `db:"difference" json:"difference"`
+	Storages    []LocatedStock `db:"-" json:"storages"`
+}
+
+// LocatedStock is how much of a product sits in one storage.
+type LocatedStock struct {
+	ProductId   uuid.UUID `db:"product_id" json:"-"`
+	StorageId   uuid.UUID `db:"storage_id" json:"storageId"`
+	StorageName string    `db:"storage_name" json:"storageName"`
+	Quantity    int       `db:"quantity" json:"quantity"`
+}
+
+// DTOs
+type ReconciliationFixRequest struct {
+	Mode       ReconciliationFixMode `json:"mode" validate:"required,oneof=adjustment unlocated"`
+	ProductIds []uuid.UUID           `json:"productIds"`
+}
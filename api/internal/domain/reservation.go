@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusReleased  ReservationStatus = "released"
+	ReservationStatusFulfilled ReservationStatus = "fulfilled"
+	ReservationStatusExpired   ReservationStatus = "expired"
+)
+
+// StockReservation holds product quantity for a delivery that has not shipped yet.
+type StockReservation struct {
+	Id         uuid.UUID         `db:"id" json:"id"`
+	ProductId  uuid.UUID         `db:"product_id" json:"productId"`
+	DeliveryId uuid.UUID         `db:"delivery_id" json:"deliveryId"`
+	Quantity   int               `db:"quantity" json:"quantity"`
+	Status     ReservationStatus `db:"status" json:"status"`
+	ExpiresAt  time.Time         `db:"expires_at" json:"expiresAt"`
+	CreatedAt  time.Time         `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time         `db:"updated_at" json:"updatedAt"`
+}
+
+func NewStockReservation(productId, deliveryId uuid.UUID, quantity int, ttl time.Duration) *StockReservation {
+	now := time.Now()
+	return &StockReservation{
+		Id:         uuid.New(),
+		ProductId:  productId,
+		DeliveryId: deliveryId,
+		Quantity:   quantity,
+		Status:     ReservationStatusActive,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
@@ -23,10 +23,27 @@ type SaleItem struct {
 	SaleId    uuid.UUID `db:"sale_id" json:"saleId"`
 	ProductId uuid.UUID `db:"product_id" json:"productId"`
 	Quantity  int       `db:"quantity" json:"quantity"`
-	UnitPrice float64   `db:"unit_price" json:"unitPrice"`
-	Subtotal  float64   `db:"subtotal" json:"subtotal"`
-	CreatedAt time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+	// Unit and UnitQuantity are what the item was sold as; Quantity is in the
+	// product's base unit and UnitPrice is per Unit.
+	Unit         string    `db:"unit" json:"unit"`
+	UnitQuantity int       `db:"unit_quantity" json:"unitQuantity"`
+	UnitPrice    float64   `db:"unit_price" json:"unitPrice"`
+	Subtotal     float64   `db:"subtotal" json:"subtotal"`
+	Cogs         float64   `db:"cogs" json:"cogs"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+	Serials      []string  `db:"-" json:"serialNumbers,omitempty"`
+	// Components is the stock a bundle item took from its components.
+	Components []LineComponent `db:"-" json:"components,omitempty"`
+}
+
+// StockLines lists what the item takes from stock: its bundle's components, or
+// the product itself.
+func (item *SaleItem) StockLines() []LineComponent {
+	if len(item.Components) > 0 {
+		return item.Components
+	}
+	return []LineComponent{{ProductId: item.ProductId, Quantity: item.Quantity}}
 }
 
 // DTOs
@@ -42,7 +59,9 @@ type SaleItemRequest struct {
 	SaleId    uuid.UUID `json:"saleId" validate:"required"`
 	ProductId uuid.UUID `json:"productId" validate:"required"`
 	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	Unit      string    `json:"unit" validate:"max=50"`
 	UnitPrice float64   `json:"unitPrice" validate:"required"`
+	Serials   []string  `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
 }
 
 func (req *SaleRequest) ToSale() *Sale {
@@ -62,7 +81,9 @@ func (req *SaleRequest) ToSale() *Sale {
 		sale.Items[i] = SaleItem{
 			ProductId: item.ProductId,
 			Quantity:  item.Quantity,
+			Unit:      strings.TrimSpace(item.Unit),
 			UnitPrice: item.UnitPrice,
+			Serials:   item.Serials,
 		}
 	}
 
@@ -99,7 +120,9 @@ func (req *SaleRequest) Sanitize() {
 type SaleItemCreateRequest struct {
 	ProductId uuid.UUID `json:"productId" validate:"required"`
 	Quantity  int       `json:"quantity" validate:"required,min=1"`
+	Unit      string    `json:"unit" validate:"max=50"`
 	UnitPrice float64   `json:"unitPrice" validate:"required"`
+	Serials   []string  `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
 }
 
 type SaleCreateRequest struct {
@@ -128,7 +151,9 @@ func (req *SaleCreateRequest) ToSale() *Sale {
 		sale.Items[i] = SaleItem{
 			ProductId: item.ProductId,
 			Quantity:  item.Quantity,
+			Unit:      strings.TrimSpace(item.Unit),
 			UnitPrice: item.UnitPrice,
+			Serials:   item.Serials,
 		}
 	}
 
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SerialStatus string
+
+const (
+	SerialStatusInStock    SerialStatus = "in_stock"
+	SerialStatusReserved   SerialStatus = "reserved"
+	SerialStatusSold       SerialStatus = "sold"
+	SerialStatusShipped    SerialStatus = "shipped"
+	SerialStatusWrittenOff SerialStatus = "written_off"
+)
+
+type SerialEventType string
+
+const (
+	SerialEventReceived   SerialEventType = "received"
+	SerialEventMoved      SerialEventType = "moved"
+	SerialEventReserved   SerialEventType = "reserved"
+	SerialEventSold       SerialEventType = "sold"
+	SerialEventShipped    SerialEventType = "shipped"
+	SerialEventReturned   SerialEventType = "returned"
+	SerialEventWrittenOff SerialEventType = "written_off"
+)
+
+// SerialNumber is one physical item of a serialized product.
+type SerialNumber struct {
+	Id            uuid.UUID       `db:"id" json:"id"`
+	ProductId     uuid.UUID       `db:"product_id" json:"productId"`
+	SerialNumber  string          `db:"serial_number" json:"serialNumber"`
+	Status        SerialStatus    `db:"status" json:"status"`
+	StorageUnitId *uuid.UUID      `db:"storage_unit_id" json:"storageUnitId"`
+	LotId         *uuid.UUID      `db:"lot_id" json:"lotId"`
+	SourceType    *MovementSource `db:"source_type" json:"sourceType"`
+	SourceId      *uuid.UUID      `db:"source_id" json:"sourceId"`
+	CreatedAt     time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updatedAt"`
+	Events        []SerialEvent   `db:"-" json:"events,omitempty"`
+}
+
+// SerialEvent records one step in a serialized item's history, with the
+// status and location it was left in.
+type SerialEvent struct {
+	Id            uuid.UUID       `db:"id" json:"id"`
+	SerialId      uuid.UUID       `db:"serial_id" json:"serialId"`
+	Event         SerialEventType `db:"event" json:"event"`
+	Status        SerialStatus    `db:"status" json:"status"`
+	StorageUnitId *uuid.UUID      `db:"storage_unit_id" json:"storageUnitId"`
+	SourceType    MovementSource  `db:"source_type" json:"sourceType"`
+	SourceId      *uuid.UUID      `db:"source_id" json:"sourceId"`
+	UserId        *uuid.UUID      `db:"user_id" json:"userId"`
+	RequestId     *string         `db:"request_id" json:"requestId"`
+	CreatedAt     time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// SerialChange describes what happens to a set of serials: the event to
+// record, the status and location they end up in, and the document causing it.
+type SerialChange struct {
+	Event         SerialEventType
+	Status        SerialStatus
+	StorageUnitId *uuid.UUID
+	SourceType    MovementSource
+	SourceId      *uuid.UUID
+	Actor         Actor
+}
+
+func NewSerialEvent(serialId uuid.UUID, change SerialChange) *SerialEvent {
+	return &SerialEvent{
+		Id:            uuid.New(),
+		SerialId:      serialId,
+		Event:         change.Event,
+		Status:        change.Status,
+		StorageUnitId: change.StorageUnitId,
+		SourceType:    change.SourceType,
+		SourceId:      change.SourceId,
+		UserId:        change.Actor.UserId,
+		RequestId:     change.Actor.RequestId,
+		CreatedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSKULength is the longest SKU the products table stores.
+const MaxSKULength = 50
+
+// skuPlaceholder matches a placeholder of an SKU pattern, such as {SEQ:5}.
+var skuPlaceholder = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// SKUInput is what a generated SKU is built from.
+type SKUInput struct {
+	Name     string
+	Category string
+	Date     time.Time
+	Sequence int64
+}
+
+// CheckSKUPattern validates an inventory's SKU pattern. Patterns mix literal
+// text with placeholders: {SEQ} or {SEQ:n} for the inventory's sequence
+// zero-padded to n digits, {NAME:n} and {CATEGORY:n} for the first n letters
+// and digits of the product's name and first category (3 by default), and
+// {YYYY}, {YY}, {MM} and {DD} for the date the product is created. Every
+// pattern needs a sequence so the SKUs it generates stay unique.
+func CheckSKUPattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	hasSequence := false
+	for _, match := range skuPlaceholder.FindAllStringSubmatch(pattern, -1) {
+		switch match[1] {
+		case "SEQ":
+			hasSequence = true
+		case "NAME", "CATEGORY":
+		case "YYYY", "YY", "MM", "DD":
+			if match[2] != "" {
+				return fmt.Errorf("{%s} takes no length", match[1])
+			}
+		default:
+			return fmt.Errorf("unknown placeholder {%s}", match[1])
+		}
+
+		if match[2] != "" {
+			if length, _ := strconv.Atoi(match[2]); length < 1 || length > 20 {
+				return fmt.Errorf("the length of {%s} must be from 1 to 20", match[1])
+			}
+		}
+	}
+
+	if strings.ContainsAny(skuPlaceholder.ReplaceAllString(pattern, ""), "{}") {
+		return fmt.Errorf("unbalanced or malformed placeholder")
+	}
+	if !hasSequence {
+		return fmt.Errorf("the pattern needs a {SEQ} placeholder to keep SKUs unique")
+	}
+
+	return nil
+}
+
+// RenderSKU fills in a valid pattern's placeholders. A product without a
+// category uses GEN for {CATEGORY}.
+func RenderSKU(pattern string, input SKUInput) string {
+	return skuPlaceholder.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		match := skuPlaceholder.FindStringSubmatch(placeholder)
+		length, _ := strconv.Atoi(match[2])
+
+		switch match[1] {
+		case "SEQ":
+			return fmt.Sprintf("%0*d", length, input.Sequence)
+		case "NAME":
+			return skuSegment(input.Name, length, "X")
+		case "CATEGORY":
+			return skuSegment(input.Category, length, "GEN")
+		case "YYYY":
+			return input.Date.Format("2006")
+		case "YY":
+			return input.Date.Format("06")
+		case "MM":
+			return input.Date.Format("01")
+		case "DD":
+			return input.Date.Format("02")
+		}
+		return placeholder
+	})
+}
+
+// skuSegment takes the first length letters and digits of text in upper case,
+// or fallback when it has none.
+func skuSegment(text string, length int, fallback string) string {
+	if length == 0 {
+		length = 3
+	}
+
+	segment := skuUnsafe.ReplaceAllString(strings.ToUpper(text), "")
+	if segment == "" {
+		return fallback
+	}
+	if len(segment) > length {
+		segment = segment[:length]
+	}
+	return segment
+}
+
+// SKUPreview is the SKU the next product created in an inventory would get.
+type SKUPreview struct {
+	Pattern  string `json:"pattern"`
+	SKU      string `json:"sku"`
+	Sequence int64  `json:"sequence"`
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSKUPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr string
+	}{
+		{"empty", "", ""},
+		{"sequence only", "{SEQ}", ""},
+		{"every placeholder", "{CATEGORY}-{NAME:4}-{YYYY}{YY}{MM}{DD}-{SEQ:5}", ""},
+		{"longest lengths", "{NAME:20}{SEQ:20}", ""},
+		{"no sequence", "{CATEGORY}-{NAME}", "needs a {SEQ}"},
+		{"literal only", "SKU", "needs a {SEQ}"},
+		{"unknown placeholder", "{SEQ}-{COLOR}", "unknown placeholder {COLOR}"},
+		{"length on a date", "{YYYY:2}-{SEQ}", "{YYYY} takes no length"},
+		{"zero length", "{SEQ:0}", "from 1 to 20"},
+		{"length too long", "{NAME:21}-{SEQ}", "from 1 to 20"},
+		{"unclosed", "{SEQ}-{NAME", "malformed"},
+		{"stray brace", "SKU}{SEQ}", "malformed"},
+		{"lower case", "{seq}", "malformed"},
+		{"negative length", "{SEQ:-1}", "malformed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckSKUPattern(test.pattern)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckSKUPattern(%q): %v", test.pattern, err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("CheckSKUPattern(%q) = %v, want an error containing %q", test.pattern, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderSKU(t *testing.T) {
+	input := SKUInput{
+		Name:     "Blue widget, large",
+		Category: "Hardware",
+		Date:     time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC),
+		Sequence: 42,
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		input   SKUInput
+		want    string
+	}{
+		{"unpadded sequence", "{SEQ}", input, "42"},
+		{"padded sequence", "P-{SEQ:5}", input, "P-00042"},
+		{"sequence wider than its padding", "{SEQ:3}", SKUInput{Sequence: 123456}, "123456"},
+		{"default segment length", "{CATEGORY}-{NAME}-{SEQ}", input, "HAR-BLU-42"},
+		{"segment length", "{NAME:8}-{SEQ}", input, "BLUEWIDG-42"},
+		{"segment shorter than its length", "{CATEGORY:20}{SEQ}", input, "HARDWARE42"},
+		{"dates", "{YYYY}{MM}{DD}-{YY}-{SEQ}", input, "20260307-26-42"},
+		{"no category", "{CATEGORY}-{SEQ}", SKUInput{Name: "Widget", Sequence: 1}, "GEN-1"},
+		{"name without letters or digits", "{NAME}-{SEQ}", SKUInput{Name: "--", Sequence: 1}, "X-1"},
+		{"zero sequence", "{SEQ}", SKUInput{Sequence: 0}, "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RenderSKU(test.pattern, test.input); got != test.want {
+				t.Errorf("RenderSKU(%q) = %q, want %q", test.pattern, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderSKUOverflow(t *testing.T) {
+	// A valid pattern can render longer than a SKU may be, which callers must
+	// refuse rather than truncate
+	pattern := "{NAME:20}{CATEGORY:20}{SEQ:20}"
+	if err := CheckSKUPattern(pattern); err != nil {
+		t.Fatalf("CheckSKUPattern(%q): %v", pattern, err)
+	}
+
+	input := SKUInput{Name: strings.Repeat("n", 30), Category: strings.Repeat("c", 30), Sequence: 1}
+	if got := RenderSKU(pattern, input); len(got) <= MaxSKULength {
+		t.Errorf("RenderSKU(%q) = %q, want it longer than %d characters", pattern, got, MaxSKULength)
+	}
+}
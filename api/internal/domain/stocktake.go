@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StocktakeStatus string
+
+const (
+	StocktakeStatusOpen     StocktakeStatus = "open"
+	StocktakeStatusCounting StocktakeStatus = "counting"
+	StocktakeStatusReview   StocktakeStatus = "review"
+	StocktakeStatusPosted   StocktakeStatus = "posted"
+)
+
+type StocktakeScope string
+
+const (
+	StocktakeScopeInventory StocktakeScope = "inventory"
+	StocktakeScopeStorage   StocktakeScope = "storage"
+	StocktakeScopeUnits     StocktakeScope = "units"
+)
+
+// stocktakeTransitions lists the statuses each stocktake status may move to.
+// Review can go back to counting for a recount.
+var stocktakeTransitions = map[StocktakeStatus][]StocktakeStatus{
+	StocktakeStatusOpen:     {StocktakeStatusCounting},
+	StocktakeStatusCounting: {StocktakeStatusReview},
+	StocktakeStatusReview:   {StocktakeStatusCounting, StocktakeStatusPosted},
+}
+
+func (status StocktakeStatus) CanTransitionTo(next StocktakeStatus) bool {
+	for _, allowed := range stocktakeTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsCounts reports whether counted quantities may still be entered.
+func (status StocktakeStatus) AcceptsCounts() bool {
+	return status == StocktakeStatusOpen || status == StocktakeStatusCounting
+}
+
+type Stocktake struct {
+	Id          uuid.UUID       `db:"id" json:"id"`
+	InventoryId uuid.UUID       `db:"inventory_id" json:"inventoryId"`
+	Name        string          `db:"name" json:"name"`
+	Status      StocktakeStatus `db:"status" json:"status"`
+	Scope       StocktakeScope  `db:"scope" json:"scope"`
+	StorageId   *uuid.UUID      `db:"storage_id" json:"storageId"`
+	Blind       bool            `db:"blind" json:"blind"`
+	Note        string          `db:"note" json:"note"`
+	CreatedBy   *uuid.UUID      `db:"created_by" json:"createdBy"`
+	PostedBy    *uuid.UUID      `db:"posted_by" json:"postedBy"`
+	PostedAt    *time.Time      `db:"posted_at" json:"postedAt"`
+	CreatedAt   time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updatedAt"`
+	UnitIds     []uuid.UUID     `db:"-" json:"unitIds,omitempty"`
+	Lines       []StocktakeLine `db:"-" json:"lines"`
+}
+
+// StocktakeLine is the expected and counted quantity of one product, either in
+// one storage unit or, without a unit, across the whole inventory.
+type StocktakeLine struct {
+	Id               uuid.UUID  `db:"id" json:"id"`
+	StocktakeId      uuid.UUID  `db:"stocktake_id" json:"stocktakeId"`
+	ProductId        uuid.UUID  `db:"product_id" json:"productId"`
+	StorageUnitId    *uuid.UUID `db:"storage_unit_id" json:"storageUnitId"`
+	ExpectedQuantity *int       `db:"expected_quantity" json:"expectedQuantity"`
+	CountedQuantity  *int       `db:"counted_quantity" json:"countedQuantity"`
+	Variance         *int       `db:"variance" json:"variance"`
+	Approved         bool       `db:"approved" json:"approved"`
+	CountedBy        *uuid.UUID `db:"counted_by" json:"countedBy"`
+	CountedAt        *time.Time `db:"counted_at" json:"countedAt"`
+	CreatedAt        time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// HideExpected strips expected quantities and variances from a blind
+// stocktake until counting is finished, so counters are not influenced.
+func (stocktake *Stocktake) HideExpected() {
+	if !stocktake.Blind || !stocktake.Status.AcceptsCounts() {
+		return
+	}
+
+	for i := range stocktake.Lines {
+		stocktake.Lines[i].ExpectedQuantity = nil
+		stocktake.Lines[i].Variance = nil
+	}
+}
+
+// DTOs
+type StocktakeRequest struct {
+	InventoryId uuid.UUID   `json:"inventoryId" validate:"required"`
+	Name        string      `json:"name" validate:"required,min=2,max=100"`
+	StorageId   *uuid.UUID  `json:"storageId"`
+	UnitIds     []uuid.UUID `json:"unitIds"`
+	Blind       bool        `json:"blind"`
+	Note        string      `json:"note"`
+}
+
+type StocktakeCountRequest struct {
+	Counts []StocktakeCountItem `json:"counts" validate:"required,min=1,dive"`
+}
+
+type StocktakeCountItem struct {
+	ProductId     uuid.UUID  `json:"productId" validate:"required"`
+	StorageUnitId *uuid.UUID `json:"storageUnitId"`
+	Quantity      int        `json:"quantity" validate:"min=0"`
+}
+
+type StocktakeApprovalRequest struct {
+	Lines []StocktakeApprovalItem `json:"lines" validate:"required,min=1,dive"`
+}
+
+type StocktakeApprovalItem struct {
+	LineId   uuid.UUID `json:"lineId" validate:"required"`
+	Approved bool      `json:"approved"`
+}
+
+type StocktakeTransitionRequest struct {
+	Status StocktakeStatus `json:"status" validate:"required,oneof=counting review posted"`
+}
+
+// ToCreateStocktakeRequest builds a new open stocktake. The scope is the given
+// units, else the given storage, else the whole inventory.
+func (req *StocktakeRequest) ToCreateStocktakeRequest(actor Actor) *Stocktake {
+	stocktake := &Stocktake{
+		Id:          uuid.New(),
+		InventoryId: req.InventoryId,
+		Name:        req.Name,
+		Status:      StocktakeStatusOpen,
+		Scope:       StocktakeScopeInventory,
+		Blind:       req.Blind,
+		Note:        req.Note,
+		CreatedBy:   actor.UserId,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	switch {
+	case len(req.UnitIds) > 0:
+		stocktake.Scope = StocktakeScopeUnits
+		stocktake.UnitIds = req.UnitIds
+	case req.StorageId != nil:
+		stocktake.Scope = StocktakeScopeStorage
+		stocktake.StorageId = req.StorageId
+	}
+
+	return stocktake
+}
+
+func (req *StocktakeRequest) Sanitize() {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Note = strings.TrimSpace(req.Note)
+}
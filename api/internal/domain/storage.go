@@ -14,6 +14,7 @@ type Storage struct {
 	Location    string        `db:"location" json:"location"`
 	Capacity    int           `db:"capacity" json:"capacity"`
 	InventoryId uuid.UUID     `db:"inventory_id" json:"inventoryId"`
+	IsUnlocated bool          `db:"is_unlocated" json:"isUnlocated"`
 	CreatedAt   time.Time     `db:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time     `db:"updated_at" json:"updatedAt"`
 	Units       []StorageUnit `json:"units,omitempty"`
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Supplier struct {
+	Id          uuid.UUID `db:"id" json:"id"`
+	InventoryId uuid.UUID `db:"inventory_id" json:"inventoryId"`
+	Name        string    `db:"name" json:"name"`
+	ContactName string    `db:"contact_name" json:"contactName"`
+	Email       string    `db:"email" json:"email"`
+	Phone       string    `db:"phone" json:"phone"`
+	Address     string    `db:"address" json:"address"`
+	Note        string    `db:"note" json:"note"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// DTOs
+type SupplierRequest struct {
+	InventoryId uuid.UUID `json:"inventoryId" validate:"required"`
+	Name        string    `json:"name" validate:"required,min=2,max=100"`
+	ContactName string    `json:"contactName" validate:"max=100"`
+	Email       string    `json:"email" validate:"omitempty,email"`
+	Phone       string    `json:"phone" validate:"max=50"`
+	Address     string    `json:"address"`
+	Note        string    `json:"note"`
+}
+
+func (req *SupplierRequest) ToCreateSupplierRequest() *Supplier {
+	return &Supplier{
+		Id:          uuid.New(),
+		InventoryId: req.InventoryId,
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Address:     req.Address,
+		Note:        req.Note,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func (req *SupplierRequest) ToUpdateSupplierRequest(existing *Supplier) *Supplier {
+	existing.Name = req.Name
+	existing.ContactName = req.ContactName
+	existing.Email = req.Email
+	existing.Phone = req.Phone
+	existing.Address = req.Address
+	existing.Note = req.Note
+	existing.UpdatedAt = time.Now()
+	return existing
+}
+
+func (req *SupplierRequest) Sanitize() {
+	req.Name = strings.TrimSpace(req.Name)
+	req.ContactName = strings.TrimSpace(req.ContactName)
+	req.Email = strings.TrimSpace(req.Email)
+	req.Phone = strings.TrimSpace(req.Phone)
+	req.Address = strings.TrimSpace(req.Address)
+	req.Note = strings.TrimSpace(req.Note)
+}
+
+// ProductSupplier is a supplier's catalog entry for a product: what they call
+// it, what it costs and how it has to be ordered.
+type ProductSupplier struct {
+	Id               uuid.UUID `db:"id" json:"id"`
+	ProductId        uuid.UUID `db:"product_id" json:"productId"`
+	SupplierId       uuid.UUID `db:"supplier_id" json:"supplierId"`
+	SupplierName     string    `db:"supplier_name" json:"supplierName"`
+	SupplierSKU      string    `db:"supplier_sku" json:"supplierSku"`
+	UnitCost         float64   `db:"unit_cost" json:"unitCost"`
+	MinOrderQuantity int       `db:"min_order_quantity" json:"minOrderQuantity"`
+	PackSize         int       `db:"pack_size" json:"packSize"`
+	LeadTimeDays     int       `db:"lead_time_days" json:"leadTimeDays"`
+	IsPreferred      bool      `db:"is_preferred" json:"isPreferred"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type ProductSupplierRequest struct {
+	SupplierId       uuid.UUID `json:"supplierId" validate:"required"`
+	SupplierSKU      string    `json:"supplierSku" validate:"max=50"`
+	UnitCost         float64   `json:"unitCost" validate:"min=0"`
+	MinOrderQuantity int       `json:"minOrderQuantity" validate:"min=0"`
+	PackSize         int       `json:"packSize" validate:"min=0"`
+	LeadTimeDays     int       `json:"leadTimeDays" validate:"min=0"`
+	IsPreferred      bool      `json:"isPreferred"`
+}
+
+// ToProductSupplier builds the catalog entry, treating an unset minimum order
+// quantity or pack size as one.
+func (req *ProductSupplierRequest) ToProductSupplier(productId uuid.UUID) *ProductSupplier {
+	entry := &ProductSupplier{
+		Id:               uuid.New(),
+		ProductId:        productId,
+		SupplierId:       req.SupplierId,
+		SupplierSKU:      strings.TrimSpace(req.SupplierSKU),
+		UnitCost:         req.UnitCost,
+		MinOrderQuantity: req.MinOrderQuantity,
+		PackSize:         req.PackSize,
+		LeadTimeDays:     req.LeadTimeDays,
+		IsPreferred:      req.IsPreferred,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	if entry.MinOrderQuantity < 1 {
+		entry.MinOrderQuantity = 1
+	}
+	if entry.PackSize < 1 {
+		entry.PackSize = 1
+	}
+
+	return entry
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockTransfer documents goods moved from one storage unit to another. The
+// product's total quantity is unchanged by a transfer.
+type StockTransfer struct {
+	Id          uuid.UUID  `db:"id" json:"id"`
+	InventoryId uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	ProductId   uuid.UUID  `db:"product_id" json:"productId"`
+	FromUnitId  *uuid.UUID `db:"from_unit_id" json:"fromUnitId"`
+	ToUnitId    *uuid.UUID `db:"to_unit_id" json:"toUnitId"`
+	Quantity    int        `db:"quantity" json:"quantity"`
+	Note        string     `db:"note" json:"note"`
+	UserId      *uuid.UUID `db:"user_id" json:"userId"`
+	RequestId   *string    `db:"request_id" json:"requestId"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	Serials     []string   `db:"-" json:"serialNumbers,omitempty"`
+}
+
+// DTOs
+type StockTransferRequest struct {
+	ProductId  uuid.UUID `json:"productId" validate:"required"`
+	FromUnitId uuid.UUID `json:"fromUnitId" validate:"required"`
+	ToUnitId   uuid.UUID `json:"toUnitId" validate:"required,nefield=FromUnitId"`
+	Quantity   int       `json:"quantity" validate:"required,min=1"`
+	Note       string    `json:"note"`
+	Serials    []string  `json:"serialNumbers" validate:"omitempty,dive,required,max=100"`
+}
+
+func (req *StockTransferRequest) ToStockTransfer(actor Actor) *StockTransfer {
+	return &StockTransfer{
+		Id:         uuid.New(),
+		ProductId:  req.ProductId,
+		FromUnitId: &req.FromUnitId,
+		ToUnitId:   &req.ToUnitId,
+		Quantity:   req.Quantity,
+		Note:       req.Note,
+		UserId:     actor.UserId,
+		RequestId:  actor.RequestId,
+		CreatedAt:  time.Now(),
+		Serials:    req.Serials,
+	}
+}
+
+func (req *StockTransferRequest) Sanitize() {
+	req.Note = strings.TrimSpace(req.Note)
+}
@@ -7,54 +7,71 @@ import (
 	"github.com/google/uuid"
 )
 
+// StorageUnit is a single location inside a storage. It may hold several
+// products; IsOccupied is kept in sync with its contents by the database and
+// nil capacities mean the unit is unlimited.
 type StorageUnit struct {
-	Id         uuid.UUID  `db:"id" json:"id"`
-	Name       string     `db:"name" json:"name"`
-	Label      string     `db:"label" json:"label"`
-	StorageId  uuid.UUID  `db:"storage_id" json:"storageId"`
-	IsOccupied bool       `db:"is_occupied" json:"isOccupied"`
-	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
-	Items      []UnitItem `json:"items,omitempty"`
+	Id             uuid.UUID  `db:"id" json:"id"`
+	Name           string     `db:"name" json:"name"`
+	Label          string     `db:"label" json:"label"`
+	StorageId      uuid.UUID  `db:"storage_id" json:"storageId"`
+	IsOccupied     bool       `db:"is_occupied" json:"isOccupied"`
+	CapacityCount  *int       `db:"capacity_count" json:"capacityCount"`
+	CapacityVolume *float64   `db:"capacity_volume" json:"capacityVolume"`
+	CapacityWeight *float64   `db:"capacity_weight" json:"capacityWeight"`
+	CreatedAt      time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updatedAt"`
+	Items          []UnitItem `json:"items,omitempty"`
 }
 
 type UnitItem struct {
-	Id            uuid.UUID `db:"id" json:"id"`
-	StorageUnitId uuid.UUID `db:"storage_unit_id" json:"storageUnitId"`
-	ProductId     uuid.UUID `db:"product_id" json:"productId"`
-	Quantity      int       `db:"quantity" json:"quantity"`
-	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updatedAt"`
-	Product       *Product  `json:"product,omitempty"`
+	Id            uuid.UUID  `db:"id" json:"id"`
+	StorageUnitId uuid.UUID  `db:"storage_unit_id" json:"storageUnitId"`
+	ProductId     uuid.UUID  `db:"product_id" json:"productId"`
+	LotId         *uuid.UUID `db:"lot_id" json:"lotId"`
+	Quantity      int        `db:"quantity" json:"quantity"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	Product       *Product   `json:"product,omitempty"`
 }
 
 // DTO
 type StorageUnitRequest struct {
-	Label      string    `json:"label" validate:"required,min=2,max=50"`
-	StorageId  uuid.UUID `json:"storageId" validate:"required"`
-	IsOccupied bool      `json:"isOccupied"`
+	Label          string    `json:"label" validate:"required,min=2,max=50"`
+	StorageId      uuid.UUID `json:"storageId" validate:"required"`
+	CapacityCount  *int      `json:"capacityCount" validate:"omitempty,min=1"`
+	CapacityVolume *float64  `json:"capacityVolume" validate:"omitempty,gt=0"`
+	CapacityWeight *float64  `json:"capacityWeight" validate:"omitempty,gt=0"`
 }
 
+// UnitItemRequest puts away or takes out a quantity counted in Unit, or the
+// product's base unit when empty.
 type UnitItemRequest struct {
-	StorageUnitId uuid.UUID `json:"storageUnitId" validate:"required"`
+	StorageUnitId uuid.UUID `json:"storageUnitId"`
 	ProductId     uuid.UUID `json:"productId" validate:"required"`
 	Quantity      int       `json:"quantity" validate:"required,min=1"`
+	Unit          string    `json:"unit" validate:"max=50"`
 }
 
 func (req *StorageUnitRequest) ToCreateStorageUnitRequest() *StorageUnit {
 	return &StorageUnit{
-		Id:         uuid.New(),
-		Label:      req.Label,
-		StorageId:  req.StorageId,
-		IsOccupied: false,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		Id:             uuid.New(),
+		Label:          req.Label,
+		StorageId:      req.StorageId,
+		IsOccupied:     false,
+		CapacityCount:  req.CapacityCount,
+		CapacityVolume: req.CapacityVolume,
+		CapacityWeight: req.CapacityWeight,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 }
 
 func (req *StorageUnitRequest) ToUpdateStorageUnitRequest(existing *StorageUnit) *StorageUnit {
 	existing.Label = req.Label
-	existing.IsOccupied = req.IsOccupied
+	existing.CapacityCount = req.CapacityCount
+	existing.CapacityVolume = req.CapacityVolume
+	existing.CapacityWeight = req.CapacityWeight
 	existing.UpdatedAt = time.Now()
 	return existing
 }
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CostingMethod decides what stock leaving an inventory is valued at.
+type CostingMethod string
+
+const (
+	// CostingMethodFIFO values outgoing stock at the cost of the oldest layers.
+	CostingMethodFIFO CostingMethod = "fifo"
+	// CostingMethodAverage values outgoing stock at the product's moving average cost.
+	CostingMethodAverage CostingMethod = "average"
+)
+
+// CostLayer is stock that came in at one unit cost. Remaining is the part of
+// it still on hand. Layers are used up oldest first under either method.
+type CostLayer struct {
+	Id         uuid.UUID       `db:"id" json:"id"`
+	ProductId  uuid.UUID       `db:"product_id" json:"productId"`
+	SourceType *MovementSource `db:"source_type" json:"sourceType"`
+	SourceId   *uuid.UUID      `db:"source_id" json:"sourceId"`
+	Quantity   int             `db:"quantity" json:"quantity"`
+	Remaining  int             `db:"remaining" json:"remaining"`
+	UnitCost   float64         `db:"unit_cost" json:"unitCost"`
+	CreatedAt  time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// ProductValuation is a product's quantity and stock value at a point in time.
+type ProductValuation struct {
+	ProductId uuid.UUID `db:"product_id" json:"productId"`
+	Name      string    `db:"name" json:"name"`
+	SKU       string    `db:"sku" json:"sku"`
+	Quantity  int       `db:"quantity" json:"quantity"`
+	Value     float64   `db:"value" json:"value"`
+}
+
+// InventoryValuation is the value of an inventory's stock as of a date, built
+// from the cost recorded with every stock movement up to it.
+type InventoryValuation struct {
+	InventoryId   uuid.UUID          `json:"inventoryId"`
+	CostingMethod CostingMethod      `json:"costingMethod"`
+	AsOf          time.Time          `json:"asOf"`
+	TotalQuantity int                `json:"totalQuantity"`
+	TotalValue    float64            `json:"totalValue"`
+	Products      []ProductValuation `json:"products"`
+}
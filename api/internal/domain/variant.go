@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VariantAxis is one dimension a parent product comes in, such as size or
+// colour, with the options offered on it in display order.
+type VariantAxis struct {
+	Id        uuid.UUID `db:"id" json:"id"`
+	ProductId uuid.UUID `db:"product_id" json:"productId"`
+	Name      string    `db:"name" json:"name"`
+	Position  int       `db:"position" json:"position"`
+	Options   []string  `db:"-" json:"options"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// VariantOption is the option a variant has on one of its parent's axes.
+type VariantOption struct {
+	ProductId uuid.UUID `db:"product_id" json:"-"`
+	AxisId    uuid.UUID `db:"axis_id" json:"axisId"`
+	Axis      string    `db:"axis" json:"axis"`
+	Value     string    `db:"value" json:"value"`
+}
+
+// DTOs
+type VariantAxisRequest struct {
+	Name    string   `json:"name" validate:"required,max=50"`
+	Options []string `json:"options" validate:"required,min=1,dive,required,max=50"`
+}
+
+// GenerateVariantsRequest sets a parent's axes. A variant is generated for
+// every combination of options that does not have one yet; at most three axes
+// keep the number of combinations manageable.
+type GenerateVariantsRequest struct {
+	Axes []VariantAxisRequest `json:"axes" validate:"required,min=1,max=3,dive"`
+}
+
+// VariantRequest edits what a variant keeps for itself; everything else comes
+// from its parent.
+type VariantRequest struct {
+	SKU          string  `json:"sku" validate:"required,max=50"`
+	Code         *string `json:"code"`
+	Quantity     int     `json:"quantity"`
+	Price        float64 `json:"price" validate:"min=0"`
+	RestockLevel int     `json:"restockLevel" validate:"min=0"`
+	OptimalLevel int     `json:"optimalLevel" validate:"min=0"`
+}
+
+// Combinations lists every combination of the axes' options, one option per
+// axis in axis order.
+func (req *GenerateVariantsRequest) Combinations() [][]string {
+	combinations := [][]string{{}}
+	for _, axis := range req.Axes {
+		next := make([][]string, 0, len(combinations)*len(axis.Options))
+		for _, combination := range combinations {
+			for _, option := range axis.Options {
+				values := append(append([]string{}, combination...), option)
+				next = append(next, values)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+func (req *GenerateVariantsRequest) Sanitize() {
+	for i := range req.Axes {
+		req.Axes[i].Name = strings.TrimSpace(req.Axes[i].Name)
+		for j := range req.Axes[i].Options {
+			req.Axes[i].Options[j] = strings.TrimSpace(req.Axes[i].Options[j])
+		}
+	}
+}
+
+// NewVariant builds the variant of parent with the given options. It starts
+// with the parent's price and restock levels and no stock; its name is set
+// when the parent's variants are synced.
+func (parent *Product) NewVariant(options []string) *Product {
+	return &Product{
+		Id:           uuid.New(),
+		Name:         parent.Name,
+		Description:  parent.Description,
+		SKU:          VariantSKU(parent.SKU, options),
+		RestockLevel: parent.RestockLevel,
+		OptimalLevel: parent.OptimalLevel,
+		Cost:         parent.Cost,
+		Price:        parent.Price,
+		UnitVolume:   parent.UnitVolume,
+		UnitWeight:   parent.UnitWeight,
+		IsSerialized: parent.IsSerialized,
+		BaseUnit:     parent.BaseUnit,
+		InventoryId:  parent.InventoryId,
+		ParentId:     &parent.Id,
+		Attributes:   parent.Attributes,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+var skuUnsafe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// VariantSKU derives a variant's SKU from its parent's and its options, for
+// example TSHIRT-M-RED.
+func VariantSKU(parentSKU string, options []string) string {
+	parts := []string{parentSKU}
+	for _, option := range options {
+		parts = append(parts, strings.Trim(skuUnsafe.ReplaceAllString(strings.ToUpper(option), "-"), "-"))
+	}
+
+	return strings.Join(parts, "-")
+}
+
+func (req *VariantRequest) ToEditVariantRequest(existing *Product) *Product {
+	existing.SKU = req.SKU
+	existing.Code = req.Code
+	existing.Quantity = req.Quantity
+	existing.Price = req.Price
+	existing.RestockLevel = req.RestockLevel
+	existing.OptimalLevel = req.OptimalLevel
+	existing.UpdatedAt = time.Now()
+
+	return existing
+}
+
+func (req *VariantRequest) Sanitize() {
+	req.SKU = strings.TrimSpace(req.SKU)
+	if req.Code != nil {
+		trimmedCode := strings.TrimSpace(*req.Code)
+		req.Code = &trimmedCode
+	}
+}
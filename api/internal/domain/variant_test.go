@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombinations(t *testing.T) {
+	tests := []struct {
+		name string
+		axes []VariantAxisRequest
+		want [][]string
+	}{
+		{
+			"one axis",
+			[]VariantAxisRequest{{Name: "Size", Options: []string{"S", "M", "L"}}},
+			[][]string{{"S"}, {"M"}, {"L"}},
+		},
+		{
+			"two axes in axis order",
+			[]VariantAxisRequest{
+				{Name: "Size", Options: []string{"S", "M"}},
+				{Name: "Colour", Options: []string{"Red", "Blue"}},
+			},
+			[][]string{{"S", "Red"}, {"S", "Blue"}, {"M", "Red"}, {"M", "Blue"}},
+		},
+		{
+			"three axes",
+			[]VariantAxisRequest{
+				{Name: "Size", Options: []string{"S", "M"}},
+				{Name: "Colour", Options: []string{"Red"}},
+				{Name: "Fit", Options: []string{"Slim", "Regular"}},
+			},
+			[][]string{
+				{"S", "Red", "Slim"}, {"S", "Red", "Regular"},
+				{"M", "Red", "Slim"}, {"M", "Red", "Regular"},
+			},
+		},
+		{
+			"an axis without options",
+			[]VariantAxisRequest{
+				{Name: "Size", Options: []string{"S", "M"}},
+				{Name: "Colour", Options: []string{}},
+			},
+			[][]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := GenerateVariantsRequest{Axes: test.axes}
+			if got := req.Combinations(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Combinations() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVariantSKU(t *testing.T) {
+	tests := []struct {
+		name    string
+		parent  string
+		options []string
+		want    string
+	}{
+		{"plain options", "TSHIRT", []string{"M", "Red"}, "TSHIRT-M-RED"},
+		{"spaces and punctuation", "TSHIRT", []string{"Extra large", "Navy/White"}, "TSHIRT-EXTRA-LARGE-NAVY-WHITE"},
+		{"trimmed separators", "MUG", []string{" (350 ml) "}, "MUG-350-ML"},
+		{"non-ASCII letters", "CAP", []string{"Grün"}, "CAP-GR-N"},
+		{"different options, same SKU", "CAP", []string{"x/l"}, "CAP-X-L"},
+		{"no options", "CAP", nil, "CAP"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := VariantSKU(test.parent, test.options); got != test.want {
+				t.Errorf("VariantSKU(%q, %q) = %q, want %q", test.parent, test.options, got, test.want)
+			}
+		})
+	}
+}
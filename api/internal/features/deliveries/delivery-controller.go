@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
 	"github.com/ventry/internal/utils"
 )
 
@@ -59,13 +61,16 @@ func (ctrl *DeliveryController) CreateDelivery(ctx echo.Context) error {
 		return err
 	}
 
+	if input.Status != "" && input.Status != domain.DeliveryStatusPending {
+		return errors.Send(ctx, errors.ValidationError("New deliveries start as pending; use the transition endpoint to change status"))
+	}
+
 	newDelivery := input.ToCreateDeliveryRequest()
 
-	if err := ctrl.repo.CreateDelivery(newDelivery); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to create delivery",
-			"details": err.Error(),
-		})
+	if err := ctrl.repo.CreateDelivery(newDelivery, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create delivery",
+			logger.Field{Key: "inventory_id", Value: newDelivery.InventoryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Delivery"))
 	}
 
 	delivery, err := ctrl.repo.GetDelivery(newDelivery.Id)
@@ -97,13 +102,16 @@ func (ctrl *DeliveryController) UpdateDelivery(ctx echo.Context) error {
 		return ctx.JSON(http.StatusNotFound, "Delivery not found")
 	}
 
+	if input.Status != "" && input.Status != existingDelivery.Status {
+		return errors.Send(ctx, errors.ValidationError("Use the transition endpoint to change delivery status"))
+	}
+
 	updatedDelivery := input.ToUpdateDeliveryRequest(existingDelivery)
 
-	if err := ctrl.repo.UpdateDelivery(updatedDelivery); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to update delivery",
-			"details": err.Error(),
-		})
+	if err := ctrl.repo.UpdateDelivery(updatedDelivery, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to update delivery",
+			logger.Field{Key: "delivery_id", Value: deliveryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Update Delivery"))
 	}
 
 	delivery, err := ctrl.repo.GetDelivery(updatedDelivery.Id)
@@ -123,12 +131,42 @@ func (ctrl *DeliveryController) DeleteDelivery(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, "Invalid delivery ID")
 	}
 
-	if err := ctrl.repo.DeleteDelivery(deliveryId); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to delete delivery",
-			"details": err.Error(),
-		})
+	if err := ctrl.repo.DeleteDelivery(deliveryId, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete delivery",
+			logger.Field{Key: "delivery_id", Value: deliveryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Delete Delivery"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+func (ctrl *DeliveryController) TransitionDelivery(ctx echo.Context) error {
+	deliveryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid delivery ID"))
+	}
+
+	var input domain.DeliveryTransitionRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	if err := ctrl.repo.TransitionDelivery(deliveryId, input.Status, input.Note, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to change delivery status",
+			logger.Field{Key: "delivery_id", Value: deliveryId},
+			logger.Field{Key: "status", Value: input.Status})
+		return errors.Send(ctx, errors.DatabaseError(err, "Transition Delivery"))
+	}
+
+	delivery, err := ctrl.repo.GetDelivery(deliveryId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Delivery"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Delivery status changed",
+		logger.Field{Key: "delivery_id", Value: deliveryId},
+		logger.Field{Key: "status", Value: delivery.Status})
+
+	return ctx.JSON(http.StatusOK, delivery)
+}
@@ -1,17 +1,24 @@
 package deliveries
 
 import (
+	"database/sql"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
 )
 
 type DeliveryRepository struct {
-	db *sqlx.DB
+	db             *sqlx.DB
+	reservationTTL time.Duration
 }
 
-func NewDeliveryRepository(data *sqlx.DB) *DeliveryRepository {
-	return &DeliveryRepository{db: data}
+func NewDeliveryRepository(data *sqlx.DB, reservationTTL time.Duration) *DeliveryRepository {
+	return &DeliveryRepository{db: data, reservationTTL: reservationTTL}
 }
 
 func (repo *DeliveryRepository) ListDeliveries(inventoryId uuid.UUID) ([]domain.Delivery, error) {
@@ -24,7 +31,7 @@ func (repo *DeliveryRepository) ListDeliveries(inventoryId uuid.UUID) ([]domain.
 
 	// Fetch items for each delivery
 	for i := range deliveries {
-		items, err := repo.getDeliveryItems(deliveries[i].Id)
+		items, err := repo.getDeliveryItems(repo.db, deliveries[i].Id)
 		if err != nil {
 			return nil, err
 		}
@@ -42,16 +49,23 @@ func (repo *DeliveryRepository) GetDelivery(deliveryId uuid.UUID) (*domain.Deliv
 		return nil, err
 	}
 
-	items, err := repo.getDeliveryItems(deliveryId)
+	items, err := repo.getDeliveryItems(repo.db, deliveryId)
 	if err != nil {
 		return nil, err
 	}
 	delivery.Items = items
 
+	history := []domain.DeliveryStatusChange{}
+	historyQuery := `SELECT * FROM delivery_status_history WHERE delivery_id = $1 ORDER BY created_at`
+	if err := repo.db.Select(&history, historyQuery, deliveryId); err != nil {
+		return nil, err
+	}
+	delivery.History = history
+
 	return &delivery, nil
 }
 
-func (repo *DeliveryRepository) CreateDelivery(delivery *domain.Delivery) error {
+func (repo *DeliveryRepository) CreateDelivery(delivery *domain.Delivery, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -63,6 +77,17 @@ func (repo *DeliveryRepository) CreateDelivery(delivery *domain.Delivery) error
 		}
 	}()
 
+	if err := prepareItems(tx, delivery.Items); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Lock affected products before reserving stock
+	if err := stock.LockProducts(tx, deliveryProductIds(delivery.Items)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	// Insert delivery
 	deliveryQuery := `
 		INSERT INTO deliveries (
@@ -81,29 +106,43 @@ func (repo *DeliveryRepository) CreateDelivery(delivery *domain.Delivery) error
 		return err
 	}
 
+	change := domain.NewDeliveryStatusChange(delivery.Id, nil, delivery.Status, "delivery created", actor)
+	if err := insertStatusChange(tx, change); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	// Insert delivery items
 	itemQuery := `
 		INSERT INTO delivery_items (
-			id, delivery_id, product_id, quantity, created_at, updated_at
+			id, delivery_id, product_id, quantity, unit, unit_quantity, created_at, updated_at
 		) VALUES (
-			:id, :delivery_id, :product_id, :quantity, :created_at, :updated_at
+			:id, :delivery_id, :product_id, :quantity, :unit, :unit_quantity, :created_at, :updated_at
 		)
 	`
 
-	for _, item := range delivery.Items {
+	for i := range delivery.Items {
+		item := &delivery.Items[i]
 		if _, err := tx.NamedExec(itemQuery, item); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
 
-		// Update product quantity
-		updateQuery := `
-			UPDATE products 
-			SET quantity = quantity - $1, 
-				updated_at = CURRENT_TIMESTAMP 
-			WHERE id = $2
-		`
-		if _, err := tx.Exec(updateQuery, item.Quantity, item.ProductId); err != nil {
+		if err := insertItemComponents(tx, item); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		// Hold the quantity until the delivery ships
+		for _, line := range item.StockLines() {
+			reservation := domain.NewStockReservation(line.ProductId, delivery.Id, line.Quantity, repo.reservationTTL)
+			if err := stock.Reserve(tx, reservation); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+
+		if err := reserveSerials(tx, *item, actor); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
@@ -112,7 +151,7 @@ func (repo *DeliveryRepository) CreateDelivery(delivery *domain.Delivery) error
 	return tx.Commit()
 }
 
-func (repo *DeliveryRepository) UpdateDelivery(delivery *domain.Delivery) error {
+func (repo *DeliveryRepository) UpdateDelivery(delivery *domain.Delivery, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -124,33 +163,45 @@ func (repo *DeliveryRepository) UpdateDelivery(delivery *domain.Delivery) error
 		}
 	}()
 
-	// Get existing delivery items to restore quantities
-	oldItems, err := repo.getDeliveryItems(delivery.Id)
+	current, err := lockDelivery(tx, delivery.Id)
 	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	// Restore product quantities
-	for _, item := range oldItems {
-		restoreQuery := `
-			UPDATE products 
-			SET quantity = quantity + $1, 
-				updated_at = CURRENT_TIMESTAMP 
-			WHERE id = $2
-		`
-		if _, err := tx.Exec(restoreQuery, item.Quantity, item.ProductId); err != nil {
-			_ = tx.Rollback()
-			return err
-		}
+	if !current.Status.IsEditable() {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot edit a %s delivery", current.Status))
+	}
+
+	// Get existing delivery items to release their stock
+	oldItems, err := repo.getDeliveryItems(tx, delivery.Id)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := prepareItems(tx, delivery.Items); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Lock both old and new products before touching stock
+	productIds := append(deliveryProductIds(oldItems), deliveryProductIds(delivery.Items)...)
+	if err := stock.LockProducts(tx, productIds); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := releaseDeliveryStock(tx, current, oldItems, "delivery updated: previous items released", actor); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	// Update delivery
 	deliveryQuery := `
 		UPDATE deliveries SET
-			status = :status,
 			order_date = :order_date,
-			delivered_at = :delivered_at,
 			recipient_name = :recipient_name,
 			recipient_address = :recipient_address,
 			recipient_phone = :recipient_phone,
@@ -174,26 +225,34 @@ func (repo *DeliveryRepository) UpdateDelivery(delivery *domain.Delivery) error
 	// Insert new items
 	itemQuery := `
 		INSERT INTO delivery_items (
-			id, delivery_id, product_id, quantity, created_at, updated_at
+			id, delivery_id, product_id, quantity, unit, unit_quantity, created_at, updated_at
 		) VALUES (
-			:id, :delivery_id, :product_id, :quantity, :created_at, :updated_at
+			:id, :delivery_id, :product_id, :quantity, :unit, :unit_quantity, :created_at, :updated_at
 		)
 	`
 
-	for _, item := range delivery.Items {
+	for i := range delivery.Items {
+		item := &delivery.Items[i]
 		if _, err := tx.NamedExec(itemQuery, item); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
 
-		// Update product quantity
-		updateQuery := `
-			UPDATE products 
-			SET quantity = quantity - $1, 
-				updated_at = CURRENT_TIMESTAMP 
-			WHERE id = $2
-		`
-		if _, err := tx.Exec(updateQuery, item.Quantity, item.ProductId); err != nil {
+		if err := insertItemComponents(tx, item); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		// Hold the quantity until the delivery ships
+		for _, line := range item.StockLines() {
+			reservation := domain.NewStockReservation(line.ProductId, delivery.Id, line.Quantity, repo.reservationTTL)
+			if err := stock.Reserve(tx, reservation); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+
+		if err := reserveSerials(tx, *item, actor); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
@@ -202,7 +261,7 @@ func (repo *DeliveryRepository) UpdateDelivery(delivery *domain.Delivery) error
 	return tx.Commit()
 }
 
-func (repo *DeliveryRepository) DeleteDelivery(deliveryId uuid.UUID) error {
+func (repo *DeliveryRepository) DeleteDelivery(deliveryId uuid.UUID, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -214,29 +273,100 @@ func (repo *DeliveryRepository) DeleteDelivery(deliveryId uuid.UUID) error {
 		}
 	}()
 
-	// Get delivery items to restore quantities
-	items, err := repo.getDeliveryItems(deliveryId)
+	current, err := lockDelivery(tx, deliveryId)
 	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	// Restore product quantities
-	for _, item := range items {
-		updateQuery := `
-			UPDATE products 
-			SET quantity = quantity + $1, 
-				updated_at = CURRENT_TIMESTAMP 
-			WHERE id = $2
-		`
-		if _, err := tx.Exec(updateQuery, item.Quantity, item.ProductId); err != nil {
+	// Get delivery items to release their stock
+	items, err := repo.getDeliveryItems(tx, deliveryId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := stock.LockProducts(tx, deliveryProductIds(items)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := releaseDeliveryStock(tx, current, items, "delivery deleted", actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Delete delivery (cascade will handle items)
+	if _, err := tx.Exec(`DELETE FROM deliveries WHERE id = $1`, deliveryId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransitionDelivery moves a delivery to the next status, enforcing the allowed
+// transitions and recording the change in the status history.
+func (repo *DeliveryRepository) TransitionDelivery(deliveryId uuid.UUID, next domain.DeliveryStatus, note string, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockDelivery(tx, deliveryId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if !current.Status.CanTransitionTo(next) {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot change delivery status from %s to %s", current.Status, next))
+	}
+
+	if next == domain.DeliveryStatusShipped || next == domain.DeliveryStatusCancelled {
+		items, err := repo.getDeliveryItems(tx, deliveryId)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err := stock.LockProducts(tx, deliveryProductIds(items)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if next == domain.DeliveryStatusShipped {
+			err = shipDeliveryStock(tx, current, items, actor)
+		} else {
+			err = releaseDeliveryStock(tx, current, items, "delivery cancelled", actor)
+		}
+		if err != nil {
 			_ = tx.Rollback()
 			return err
 		}
 	}
 
-	// Delete delivery (cascade will handle items)
-	if _, err := tx.Exec(`DELETE FROM deliveries WHERE id = $1`, deliveryId); err != nil {
+	updateQuery := `
+		UPDATE deliveries SET
+			status = $1,
+			delivered_at = CASE WHEN $1 = 'delivered' THEN CURRENT_TIMESTAMP ELSE delivered_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(updateQuery, next, deliveryId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	change := domain.NewDeliveryStatusChange(deliveryId, &current.Status, next, note, actor)
+	if err := insertStatusChange(tx, change); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
@@ -244,16 +374,20 @@ func (repo *DeliveryRepository) DeleteDelivery(deliveryId uuid.UUID) error {
 	return tx.Commit()
 }
 
-func (repo *DeliveryRepository) getDeliveryItems(deliveryId uuid.UUID) ([]domain.DeliveryItem, error) {
+func (repo *DeliveryRepository) getDeliveryItems(q sqlx.Queryer, deliveryId uuid.UUID) ([]domain.DeliveryItem, error) {
 	items := []domain.DeliveryItem{}
 	query := `
-		SELECT di.*, p.* 
+		SELECT di.id, di.delivery_id, di.product_id, di.quantity, di.unit, di.unit_quantity,
+			di.created_at, di.updated_at,
+			p.id, p.name, p.description, p.sku, p.code, p.quantity, p.reserved, p.available,
+			p.restock_level, p.optimal_level, p.cost, p.price, p.inventory_id,
+			p.created_at, p.updated_at
 		FROM delivery_items di
 		JOIN products p ON di.product_id = p.id
 		WHERE di.delivery_id = $1
 	`
 
-	rows, err := repo.db.Queryx(query, deliveryId)
+	rows, err := q.Queryx(query, deliveryId)
 	if err != nil {
 		return nil, err
 	}
@@ -266,10 +400,10 @@ func (repo *DeliveryRepository) getDeliveryItems(deliveryId uuid.UUID) ([]domain
 		// Scan into both structs
 		err := rows.Scan(
 			&item.Id, &item.DeliveryId, &item.ProductId, &item.Quantity,
-			&item.CreatedAt, &item.UpdatedAt,
+			&item.Unit, &item.UnitQuantity, &item.CreatedAt, &item.UpdatedAt,
 			&product.Id, &product.Name, &product.Description, &product.SKU,
-			&product.Code, &product.Quantity, &product.RestockLevel,
-			&product.OptimalLevel, &product.Cost, &product.Price,
+			&product.Code, &product.Quantity, &product.Reserved, &product.Available,
+			&product.RestockLevel, &product.OptimalLevel, &product.Cost, &product.Price,
 			&product.InventoryId, &product.CreatedAt, &product.UpdatedAt,
 		)
 		if err != nil {
@@ -280,5 +414,202 @@ func (repo *DeliveryRepository) getDeliveryItems(deliveryId uuid.UUID) ([]domain
 		items = append(items, item)
 	}
 
+	serialQuery := `
+		SELECT sn.serial_number
+		FROM delivery_item_serials dis
+		JOIN serial_numbers sn ON sn.id = dis.serial_id
+		WHERE dis.delivery_item_id = $1
+		ORDER BY sn.serial_number
+	`
+	for i := range items {
+		if err := sqlx.Select(q, &items[i].Serials, serialQuery, items[i].Id); err != nil {
+			return nil, err
+		}
+		if err := sqlx.Select(q, &items[i].Components, itemComponentsQuery, items[i].Id); err != nil {
+			return nil, err
+		}
+	}
+
 	return items, nil
 }
+
+// lockDelivery serialises concurrent changes to the same delivery and returns
+// its current state.
+func lockDelivery(tx *sqlx.Tx, deliveryId uuid.UUID) (*domain.Delivery, error) {
+	var delivery domain.Delivery
+	err := tx.Get(&delivery, `SELECT * FROM deliveries WHERE id = $1 FOR UPDATE`, deliveryId)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("Delivery not found")
+	}
+	return &delivery, err
+}
+
+// shipDeliveryStock turns the delivery's reservations into actual stock
+// deductions. The products must already be locked.
+func shipDeliveryStock(tx *sqlx.Tx, delivery *domain.Delivery, items []domain.DeliveryItem, actor domain.Actor) error {
+	if err := stock.ReleaseReservations(tx, delivery.Id, domain.ReservationStatusFulfilled); err != nil {
+		return err
+	}
+
+	if delivery.StockDeducted {
+		return nil
+	}
+
+	for _, item := range items {
+		for _, line := range item.StockLines() {
+			movement := domain.NewStockMovement(line.ProductId, -line.Quantity,
+				domain.MovementSourceDelivery, &delivery.Id, "delivery shipped", actor)
+			if err := stock.DeductStock(tx, movement); err != nil {
+				return err
+			}
+		}
+
+		ids, err := itemSerialIds(tx, item.Id)
+		if err != nil {
+			return err
+		}
+
+		err = stock.UpdateSerials(tx, ids, domain.SerialChange{
+			Event:      domain.SerialEventShipped,
+			Status:     domain.SerialStatusShipped,
+			SourceType: domain.MovementSourceDelivery,
+			SourceId:   &delivery.Id,
+			Actor:      actor,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`UPDATE deliveries SET stock_deducted = true WHERE id = $1`, delivery.Id)
+	return err
+}
+
+// releaseDeliveryStock drops the delivery's reservations and returns any stock
+// it had already taken. The products must already be locked.
+func releaseDeliveryStock(tx *sqlx.Tx, delivery *domain.Delivery, items []domain.DeliveryItem, reason string, actor domain.Actor) error {
+	if err := stock.ReleaseReservations(tx, delivery.Id, domain.ReservationStatusReleased); err != nil {
+		return err
+	}
+
+	// Serials go back into stock whether they were only reserved or shipped
+	for _, item := range items {
+		ids, err := itemSerialIds(tx, item.Id)
+		if err != nil {
+			return err
+		}
+
+		err = stock.ReturnSerials(tx, ids, domain.SerialChange{
+			SourceType: domain.MovementSourceDelivery,
+			SourceId:   &delivery.Id,
+			Actor:      actor,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !delivery.StockDeducted {
+		return nil
+	}
+
+	for _, item := range items {
+		for _, line := range item.StockLines() {
+			movement := domain.NewStockMovement(line.ProductId, line.Quantity,
+				domain.MovementSourceDelivery, &delivery.Id, reason, actor)
+			if err := stock.ReturnStock(tx, movement); err != nil {
+				return err
+			}
+		}
+	}
+
+	delivery.StockDeducted = false
+	_, err := tx.Exec(`UPDATE deliveries SET stock_deducted = false WHERE id = $1`, delivery.Id)
+	return err
+}
+
+// reserveSerials holds a delivery line's serials until it ships and links them
+// to the line. The product must already be locked.
+func reserveSerials(tx *sqlx.Tx, item domain.DeliveryItem, actor domain.Actor) error {
+	ids, err := stock.AssignSerials(tx, item.ProductId, item.Quantity, item.Serials, domain.SerialChange{
+		Event:      domain.SerialEventReserved,
+		Status:     domain.SerialStatusReserved,
+		SourceType: domain.MovementSourceDelivery,
+		SourceId:   &item.DeliveryId,
+		Actor:      actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`INSERT INTO delivery_item_serials (delivery_item_id, serial_id) VALUES ($1, $2)`, item.Id, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func itemSerialIds(tx *sqlx.Tx, itemId uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := tx.Select(&ids, `SELECT serial_id FROM delivery_item_serials WHERE delivery_item_id = $1`, itemId)
+	return ids, err
+}
+
+// prepareItems converts each item's quantity from the unit it was entered in
+// to the product's base unit, keeping the original on the item, and works out
+// the component stock bundles take.
+func prepareItems(tx *sqlx.Tx, items []domain.DeliveryItem) error {
+	for i := range items {
+		item := &items[i]
+		unit, quantity, err := stock.ToBaseUnit(tx, item.ProductId, item.Unit, item.Quantity)
+		if err != nil {
+			return err
+		}
+		item.Unit, item.UnitQuantity, item.Quantity = unit, item.Quantity, quantity
+
+		if item.Components, err = stock.BundleComponents(tx, item.ProductId, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const itemComponentsQuery = `SELECT product_id, quantity FROM delivery_item_components WHERE delivery_item_id = $1 ORDER BY product_id`
+
+func insertItemComponents(tx *sqlx.Tx, item *domain.DeliveryItem) error {
+	query := `INSERT INTO delivery_item_components (delivery_item_id, product_id, quantity) VALUES ($1, $2, $3)`
+	for _, component := range item.Components {
+		if _, err := tx.Exec(query, item.Id, component.ProductId, component.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertStatusChange(tx *sqlx.Tx, change *domain.DeliveryStatusChange) error {
+	query := `
+		INSERT INTO delivery_status_history (
+			id, delivery_id, from_status, to_status, note, changed_by, created_at
+		) VALUES (
+			:id, :delivery_id, :from_status, :to_status, :note, :changed_by, :created_at
+		)
+	`
+
+	_, err := tx.NamedExec(query, change)
+	return err
+}
+
+// deliveryProductIds lists the delivered products along with bundle
+// components, whose stock is what actually changes.
+func deliveryProductIds(items []domain.DeliveryItem) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductId)
+		for _, component := range item.Components {
+			ids = append(ids, component.ProductId)
+		}
+	}
+	return ids
+}
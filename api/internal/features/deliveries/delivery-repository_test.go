@@ -0,0 +1,162 @@
+package deliveries
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// testDB connects to the migrated database named by TEST_DATABASE_URL, skipping
+// the test when it is not set.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// seedProduct creates a user, an inventory without backorders and a product
+// with the given stock, all removed again when the test ends.
+func seedProduct(t *testing.T, db *sqlx.DB, quantity int) (inventoryId, productId uuid.UUID) {
+	t.Helper()
+
+	userId, inventoryId, productId := uuid.New(), uuid.New(), uuid.New()
+	suffix := userId.String()[:8]
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`INSERT INTO users (id, username, email, password) VALUES ($1, $2, $3, 'x')`,
+			[]interface{}{userId, "delivery-test-" + suffix, "delivery-test-" + suffix + "@example.com"}},
+		{`INSERT INTO inventories (id, name, user_id) VALUES ($1, 'Delivery test', $2)`,
+			[]interface{}{inventoryId, userId}},
+		{`INSERT INTO products (id, name, sku, quantity, inventory_id) VALUES ($1, 'Contended product', $2, $3, $4)`,
+			[]interface{}{productId, "DT-" + suffix, quantity, inventoryId}},
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement.query, statement.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	// Delivery items restrict deleting their product, so the deliveries go first
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM deliveries WHERE inventory_id = $1`, inventoryId); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+		if _, err := db.Exec(`DELETE FROM users WHERE id = $1`, userId); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+
+	return inventoryId, productId
+}
+
+// shipOne creates a delivery of one unit of the product and ships it.
+func shipOne(repo *DeliveryRepository, inventoryId, productId uuid.UUID, n int) error {
+	delivery := &domain.Delivery{
+		Id:               uuid.New(),
+		InventoryId:      inventoryId,
+		Status:           domain.DeliveryStatusPending,
+		OrderDate:        time.Now(),
+		RecipientName:    fmt.Sprintf("Recipient %d", n),
+		RecipientAddress: "1 Test Street",
+		RecipientPhone:   "555-0100",
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	delivery.Items = []domain.DeliveryItem{{
+		Id:           uuid.New(),
+		DeliveryId:   delivery.Id,
+		ProductId:    productId,
+		Quantity:     1,
+		UnitQuantity: 1,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}}
+
+	if err := repo.CreateDelivery(delivery, domain.Actor{}); err != nil {
+		return err
+	}
+
+	for _, status := range []domain.DeliveryStatus{domain.DeliveryStatusProcessing, domain.DeliveryStatusShipped} {
+		if err := repo.TransitionDelivery(delivery.Id, status, "concurrency test", domain.Actor{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TestConcurrentDeliveriesCannotOversell fires more parallel deliveries at one
+// product than it has stock for. Exactly the stock on hand must ship, the rest
+// must be refused as conflicts, and nothing may stay reserved.
+func TestConcurrentDeliveriesCannotOversell(t *testing.T) {
+	db := testDB(t)
+
+	const stock, attempts = 5, 20
+	inventoryId, productId := seedProduct(t, db, stock)
+	repo := NewDeliveryRepository(db, time.Hour)
+
+	results := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = shipOne(repo, inventoryId, productId, i)
+		}(i)
+	}
+	wg.Wait()
+
+	shipped := 0
+	for i, err := range results {
+		if err == nil {
+			shipped++
+			continue
+		}
+		appErr, ok := err.(*errors.AppError)
+		if !ok || appErr.Code != 409 {
+			t.Errorf("delivery %d: want a 409 conflict, got %v", i, err)
+		}
+	}
+
+	if shipped != stock {
+		t.Errorf("shipped %d deliveries, want %d", shipped, stock)
+	}
+
+	var product struct {
+		Quantity int `db:"quantity"`
+		Reserved int `db:"reserved"`
+	}
+	if err := db.Get(&product, `SELECT quantity, reserved FROM products WHERE id = $1`, productId); err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+
+	if product.Quantity < 0 {
+		t.Errorf("quantity is %d, want it never below 0", product.Quantity)
+	}
+
+	if product.Reserved != 0 {
+		t.Errorf("reserved is %d, want 0", product.Reserved)
+	}
+}
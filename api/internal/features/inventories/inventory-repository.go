@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
 	"github.com/ventry/internal/pkg/errors"
 )
 
@@ -41,23 +42,49 @@ func (repo *InventoryRepository) GetInventory(inventoryId uuid.UUID) (domain.Inv
 
 func (repo *InventoryRepository) CreateInventory(newInventory *domain.Inventory) error {
 	query := `INSERT 
-				INTO inventories(id, name, description, user_id, created_at, updated_at)
-				VALUES(:id, :name, :description, :user_id, :created_at, :updated_at)`
+				INTO inventories(id, name, description, user_id, allow_backorders, costing_method, sku_pattern, created_at, updated_at)
+				VALUES(:id, :name, :description, :user_id, :allow_backorders, :costing_method, :sku_pattern, :created_at, :updated_at)`
 
-	_, err := repo.db.NamedExec(query, newInventory)
+	if err := domain.CheckSKUPattern(newInventory.SKUPattern); err != nil {
+		return errors.ValidationError("Invalid SKU pattern: " + err.Error())
+	}
+
+	tx, err := repo.db.Beginx()
 	if err != nil {
 		return errors.DatabaseError(err, "Create Inventory")
 	}
 
+	if _, err := tx.NamedExec(query, newInventory); err != nil {
+		_ = tx.Rollback()
+		return errors.DatabaseError(err, "Create Inventory")
+	}
+
+	// Every inventory starts out with the default adjustment reason codes
+	for _, reason := range domain.DefaultAdjustmentReasons(newInventory.Id) {
+		if err := stock.InsertAdjustmentReason(tx, &reason); err != nil {
+			_ = tx.Rollback()
+			return errors.DatabaseError(err, "Create Inventory")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Create Inventory")
+	}
+
 	return nil
 }
 
 func (repo *InventoryRepository) EditInventory(updatedInventory *domain.Inventory) error {
 	query := `UPDATE inventories
 				SET name = :name, description = :description, user_id = :user_id,
-					created_at = :created_at, updated_at = :updated_at
+					allow_backorders = :allow_backorders, costing_method = :costing_method,
+					sku_pattern = :sku_pattern, created_at = :created_at, updated_at = :updated_at
 				WHERE id = :id`
 
+	if err := domain.CheckSKUPattern(updatedInventory.SKUPattern); err != nil {
+		return errors.ValidationError("Invalid SKU pattern: " + err.Error())
+	}
+
 	_, err := repo.db.NamedExec(query, updatedInventory)
 	if err != nil {
 		return errors.DatabaseError(err, "Edit Inventory")
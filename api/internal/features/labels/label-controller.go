@@ -0,0 +1,142 @@
+package labels
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/pkg/pdf"
+	"github.com/ventry/internal/utils"
+)
+
+// maxLabels caps one print job so a stray request cannot build a huge PDF.
+const maxLabels = 5000
+
+// zplContentType serves ZPL as plain text, which printer utilities and browser
+// print bridges pass straight to the printer.
+const zplContentType = "text/plain; charset=utf-8"
+
+type LabelController struct {
+	repo *LabelRepository
+}
+
+func NewLabelController(labelRepo *LabelRepository) *LabelController {
+	return &LabelController{repo: labelRepo}
+}
+
+func (ctrl *LabelController) ListLabelTemplates(ctx echo.Context) error {
+	templates := make([]domain.LabelTemplate, 0, len(domain.LabelTemplates))
+	for _, template := range domain.LabelTemplates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
+	return ctx.JSON(http.StatusOK, templates)
+}
+
+// PrintLabelSheets renders the requested unit and product labels onto label
+// sheets as a PDF, or as one ZPL label each for a thermal printer.
+func (ctrl *LabelController) PrintLabelSheets(ctx echo.Context) error {
+	var input domain.LabelSheetRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	if input.StorageId == nil && len(input.UnitIds) == 0 && len(input.ProductIds) == 0 {
+		return errors.Send(ctx, errors.ValidationError("Choose a storage, units or products to print labels for"))
+	}
+
+	labels := []domain.Label{}
+	if input.StorageId != nil || len(input.UnitIds) > 0 {
+		units, err := ctrl.repo.UnitLabels(input.StorageId, input.UnitIds)
+		if err != nil {
+			return errors.Send(ctx, errors.DatabaseError(err, "List Unit Labels"))
+		}
+		labels = append(labels, units...)
+	}
+
+	products, err := ctrl.repo.ProductLabels(input.ProductIds)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "List Product Labels"))
+	}
+	labels = append(labels, products...)
+
+	if len(labels) == 0 {
+		return errors.Send(ctx, errors.ValidationError("There are no labels to print"))
+	}
+	if len(labels)*input.Copies > maxLabels {
+		return errors.Send(ctx, errors.ValidationError("Too many labels in one print job"))
+	}
+
+	if input.Format == domain.LabelFormatZPL {
+		var out bytes.Buffer
+		options := input.ZPLOptions.WithDefaults(domain.ItemLabelWidth, domain.ItemLabelHeight)
+		if err := writeZPLLabels(&out, labels, input.Copies, options); err != nil {
+			logger.Error(ctx.Request().Context(), err, "Failed to render ZPL labels")
+			return errors.Send(ctx, errors.InternalError(err, "Failed to render ZPL labels"))
+		}
+
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="labels.zpl"`)
+		return ctx.Blob(http.StatusOK, zplContentType, out.Bytes())
+	}
+
+	template, ok := domain.LabelTemplates[input.Template]
+	if !ok {
+		return errors.Send(ctx, errors.ValidationError("Unknown label template "+input.Template))
+	}
+
+	var sheet bytes.Buffer
+	if err := renderSheets(&sheet, template, labels, input.Copies, input.Skip); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to render label sheets",
+			logger.Field{Key: "template", Value: template.Name})
+		return errors.Send(ctx, errors.InternalError(err, "Failed to render label sheets"))
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="labels.pdf"`)
+	return ctx.Blob(http.StatusOK, "application/pdf", sheet.Bytes())
+}
+
+// PrintShippingLabel renders a delivery's shipping label as a PDF the size of
+// the label, or as ZPL for a thermal printer.
+func (ctrl *LabelController) PrintShippingLabel(ctx echo.Context) error {
+	deliveryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid delivery ID"))
+	}
+
+	var input domain.ShippingLabelRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	label, err := ctrl.repo.ShippingLabel(deliveryId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Shipping Label"))
+	}
+
+	options := input.ZPLOptions.WithDefaults(domain.ShippingLabelWidth, domain.ShippingLabelHeight)
+
+	var out bytes.Buffer
+	if input.Format == domain.LabelFormatZPL {
+		if err := writeZPLShippingLabel(&out, label, options); err != nil {
+			return errors.Send(ctx, errors.InternalError(err, "Failed to render shipping label"))
+		}
+
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="shipping-label.zpl"`)
+		return ctx.Blob(http.StatusOK, zplContentType, out.Bytes())
+	}
+
+	if err := renderShippingLabel(&out, label, options.Width*pdf.MM, options.Height*pdf.MM); err != nil {
+		return errors.Send(ctx, errors.InternalError(err, "Failed to render shipping label"))
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="shipping-label.pdf"`)
+	return ctx.Blob(http.StatusOK, "application/pdf", out.Bytes())
+}
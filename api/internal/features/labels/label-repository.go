@@ -0,0 +1,127 @@
+package labels
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+type LabelRepository struct {
+	db *sqlx.DB
+}
+
+func NewLabelRepository(data *sqlx.DB) *LabelRepository {
+	return &LabelRepository{db: data}
+}
+
+// UnitLabels lists the labels of every unit in the storage, when one is given,
+// and of the given units, located by their storage's name and location.
+func (repo *LabelRepository) UnitLabels(storageId *uuid.UUID, unitIds []uuid.UUID) ([]domain.Label, error) {
+	if storageId != nil {
+		var exists bool
+		if err := repo.db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM storages WHERE id = $1)`, *storageId); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.NotFoundError("Storage not found")
+		}
+	}
+
+	labels := []domain.Label{}
+	query := `
+		SELECT 'unit' AS kind, u.id, u.name, COALESCE(u.label, '') AS detail,
+			s.name || COALESCE(' - ' || NULLIF(s.location, ''), '') AS location
+		FROM storage_units u
+		JOIN storages s ON s.id = u.storage_id
+		WHERE u.storage_id = $1 OR u.id = ANY($2::uuid[])
+		ORDER BY s.name, u.name
+	`
+	if err := repo.db.Select(&labels, query, storageId, pq.Array(unitIds)); err != nil {
+		return nil, err
+	}
+
+	if err := checkFound(labels, unitIds, "Storage unit not found"); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// ProductLabels lists the labels of the given products, located by the storage
+// units holding their stock or, failing that, the storages they are assigned to.
+func (repo *LabelRepository) ProductLabels(productIds []uuid.UUID) ([]domain.Label, error) {
+	labels := []domain.Label{}
+	if len(productIds) == 0 {
+		return labels, nil
+	}
+
+	query := `
+		SELECT 'product' AS kind, p.id, p.name, p.sku AS detail,
+			COALESCE(
+				(SELECT string_agg(DISTINCT s.name || ' / ' || u.name, ', ')
+				 FROM unit_items ui
+				 JOIN storage_units u ON u.id = ui.storage_unit_id
+				 JOIN storages s ON s.id = u.storage_id
+				 WHERE ui.product_id = p.id AND ui.quantity > 0),
+				(SELECT string_agg(s.name, ', ' ORDER BY s.name)
+				 FROM product_storages ps
+				 JOIN storages s ON s.id = ps.storage_id
+				 WHERE ps.product_id = p.id),
+				''
+			) AS location
+		FROM products p
+		WHERE p.id = ANY($1::uuid[])
+		ORDER BY p.name
+	`
+	if err := repo.db.Select(&labels, query, pq.Array(productIds)); err != nil {
+		return nil, err
+	}
+
+	if err := checkFound(labels, productIds, "Product not found"); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// ShippingLabel gathers what the delivery's shipping label shows; the sender
+// is the delivery's inventory.
+func (repo *LabelRepository) ShippingLabel(deliveryId uuid.UUID) (*domain.ShippingLabel, error) {
+	var label domain.ShippingLabel
+	query := `
+		SELECT d.id, i.name AS sender, d.recipient_name,
+			COALESCE(d.recipient_address, '') AS recipient_address, COALESCE(d.recipient_phone, '') AS recipient_phone,
+			COALESCE(d.tracking_number, '') AS tracking_number, d.order_date,
+			COALESCE((SELECT SUM(di.quantity) FROM delivery_items di WHERE di.delivery_id = d.id), 0) AS item_count
+		FROM deliveries d
+		JOIN inventories i ON i.id = d.inventory_id
+		WHERE d.id = $1
+	`
+	if err := repo.db.Get(&label, query, deliveryId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Delivery not found")
+		}
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+// checkFound makes sure every requested id came back as a label.
+func checkFound(labels []domain.Label, ids []uuid.UUID, message string) error {
+	found := make(map[uuid.UUID]bool, len(labels))
+	for _, label := range labels {
+		found[label.Id] = true
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return errors.NotFoundError(message + ": " + id.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package labels
+
+import (
+	"io"
+
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/pdf"
+	"github.com/ventry/internal/pkg/qrcode"
+)
+
+// labelPadding is the blank border, in points, kept inside each label so
+// printing offsets do not clip the QR code or text.
+const labelPadding = 4.0
+
+// renderSheets lays the labels out on as many sheets of the template as they
+// need, each label copies times in a row, leaving the first skip positions of
+// the first sheet blank.
+func renderSheets(w io.Writer, template domain.LabelTemplate, labels []domain.Label, copies, skip int) error {
+	doc := pdf.New(template.PageWidth, template.PageHeight)
+
+	var page *pdf.Page
+	position := skip
+	for _, label := range labels {
+		qr, err := qrcode.Encode(label.Payload())
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < copies; i++ {
+			slot := position % template.PerSheet()
+			if page == nil || slot == 0 {
+				page = doc.AddPage()
+			}
+
+			row, column := slot/template.Columns, slot%template.Columns
+			x := template.MarginLeft + float64(column)*template.PitchX
+			y := template.PageHeight - template.MarginTop - float64(row)*template.PitchY - template.LabelHeight
+			drawLabel(page, x, y, template.LabelWidth, template.LabelHeight, label, qr)
+
+			position++
+		}
+	}
+
+	return doc.Write(w)
+}
+
+// drawLabel draws one label with its bottom left corner at x, y: the QR code
+// filling the label's height on the left, and the name, detail and location
+// beside it.
+func drawLabel(page *pdf.Page, x, y, width, height float64, label domain.Label, qr *qrcode.QRCode) {
+	side := height - 2*labelPadding
+	drawQRCode(page, x+labelPadding, y+labelPadding, side, qr)
+
+	textX := x + labelPadding + side + labelPadding
+	textWidth := x + width - labelPadding - textX
+	nameSize := min(max(height/7, 7), 14)
+	smallSize := nameSize * 0.8
+
+	baseline := y + height - labelPadding - nameSize
+	page.Text(textX, baseline, nameSize, pdf.Fit(label.Name, nameSize, textWidth))
+
+	for _, line := range []string{label.Detail, label.Location} {
+		if line == "" {
+			continue
+		}
+		baseline -= smallSize * 1.4
+		page.Text(textX, baseline, smallSize, pdf.Fit(line, smallSize, textWidth))
+	}
+}
+
+// drawQRCode draws the QR code, with its quiet zone, in a square of side points
+// whose bottom left corner is at x, y.
+func drawQRCode(page *pdf.Page, x, y, side float64, qr *qrcode.QRCode) {
+	module := side / float64(qr.Size+2*qrcode.QuietZone)
+	left := x + qrcode.QuietZone*module
+	top := y + side - qrcode.QuietZone*module
+
+	// Draw each row's runs of dark modules as one rectangle
+	for row := 0; row < qr.Size; row++ {
+		for column := 0; column < qr.Size; column++ {
+			if !qr.Dark(column, row) {
+				continue
+			}
+			start := column
+			for column < qr.Size && qr.Dark(column, row) {
+				column++
+			}
+			page.Rect(left+float64(start)*module, top-float64(row+1)*module, float64(column-start)*module, module)
+		}
+	}
+}
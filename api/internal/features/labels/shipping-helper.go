@@ -0,0 +1,72 @@
+package labels
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/barcode"
+	"github.com/ventry/internal/pkg/pdf"
+	"github.com/ventry/internal/pkg/qrcode"
+)
+
+// renderShippingLabel renders a delivery's shipping label as a one page PDF
+// the size of the label, laid out like its ZPL counterpart.
+func renderShippingLabel(w io.Writer, label *domain.ShippingLabel, width, height float64) error {
+	doc := pdf.New(width, height)
+	page := doc.AddPage()
+
+	padding := 4 * pdf.MM
+	textWidth := width - 2*padding
+	size := max(height/40, 7)
+	large := size * 1.6
+
+	// Lay the label out from the top down
+	y := height - padding - size
+	line := func(text string, textSize float64) {
+		page.Text(padding, y, textSize, pdf.Fit(text, textSize, textWidth))
+		y -= textSize * 1.4
+	}
+	rule := func() {
+		page.Rect(padding, y+size*0.6, textWidth, 0.5*pdf.MM)
+		y -= size
+	}
+
+	line("FROM: "+label.Sender, size)
+	rule()
+	line("SHIP TO:", size)
+	line(label.RecipientName, large)
+	for i, address := range pdf.Wrap(label.RecipientAddress, size, textWidth) {
+		if i == 4 {
+			break
+		}
+		line(address, size)
+	}
+	if label.RecipientPhone != "" {
+		line("Phone: "+label.RecipientPhone, size)
+	}
+	rule()
+	line(fmt.Sprintf("Items: %d", label.ItemCount), size)
+	line("Ordered: "+label.OrderDate.Format("2006-01-02"), size)
+
+	if label.TrackingNumber != "" {
+		line("Tracking: "+label.TrackingNumber, size)
+		if code, err := barcode.Encode(barcode.Code128, label.TrackingNumber); err == nil {
+			module := min(textWidth/float64(code.Width()), 0.5*pdf.MM)
+			barHeight := height / 8
+			for _, bar := range code.Bars() {
+				x := padding + float64(barcode.QuietZone+bar[0])*module
+				page.Rect(x, y-barHeight+size, float64(bar[1])*module, barHeight)
+			}
+		}
+	}
+
+	qr, err := qrcode.Encode(label.Payload())
+	if err != nil {
+		return err
+	}
+	side := width / 3
+	drawQRCode(page, width-padding-side, padding, side, qr)
+
+	return doc.Write(w)
+}
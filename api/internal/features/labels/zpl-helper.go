@@ -0,0 +1,108 @@
+package labels
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/barcode"
+	"github.com/ventry/internal/pkg/qrcode"
+	"github.com/ventry/internal/pkg/zpl"
+)
+
+// writeZPLLabels writes each label as its own ZPL label, printed copies times.
+// The QR code fills the label's height on the left, as far as the printer can
+// magnify it, with the name, detail and location beside it, as on the PDF
+// sheets.
+func writeZPLLabels(w io.Writer, labels []domain.Label, copies int, options domain.ZPLOptions) error {
+	for _, label := range labels {
+		qr, err := qrcode.Encode(label.Payload())
+		if err != nil {
+			return err
+		}
+
+		out := zpl.New(options.Width, options.Height, options.DPI)
+		padding := out.Dots(2)
+
+		magnification := min(max((out.Height-2*padding)/qr.Size, 1), zpl.MaxQRMagnification)
+		out.QRCode(padding, padding, magnification, label.Payload())
+
+		textX := 2*padding + magnification*qr.Size
+		textWidth := max(out.Width-textX-padding, 1)
+		nameSize := max(out.Height/6, 12)
+		smallSize := nameSize * 3 / 4
+
+		y := padding
+		out.Text(textX, y, nameSize, textWidth, label.Name)
+		y += nameSize * 5 / 4
+		for _, line := range []string{label.Detail, label.Location} {
+			if line == "" {
+				continue
+			}
+			out.Text(textX, y, smallSize, textWidth, line)
+			y += smallSize * 5 / 4
+		}
+
+		if err := out.Write(w, copies); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeZPLShippingLabel writes a delivery's shipping label: sender, recipient,
+// item count and order date, the tracking number as a Code 128 barcode and a
+// QR code of the delivery.
+func writeZPLShippingLabel(w io.Writer, label *domain.ShippingLabel, options domain.ZPLOptions) error {
+	out := zpl.New(options.Width, options.Height, options.DPI)
+	padding := out.Dots(4)
+	width := out.Width - 2*padding
+
+	size := max(out.Height/40, 14)
+	large := size * 8 / 5
+	rule := max(out.Dots(0.5), 2)
+
+	y := padding
+	out.Text(padding, y, size, width, "FROM: "+label.Sender)
+	y += size * 3 / 2
+	out.Box(padding, y, width, rule)
+	y += rule + size
+
+	out.Text(padding, y, size, width, "SHIP TO:")
+	y += size * 3 / 2
+	out.Text(padding, y, large, width, label.RecipientName)
+	y += large * 5 / 4
+	out.TextBlock(padding, y, size, width, 4, label.RecipientAddress)
+	y += size * 5
+	if label.RecipientPhone != "" {
+		out.Text(padding, y, size, width, "Phone: "+label.RecipientPhone)
+	}
+	y += size * 3 / 2
+	out.Box(padding, y, width, rule)
+	y += rule + size
+
+	out.Text(padding, y, size, width, fmt.Sprintf("Items: %d", label.ItemCount))
+	y += size * 3 / 2
+	out.Text(padding, y, size, width, "Ordered: "+label.OrderDate.Format("2006-01-02"))
+	y += size * 2
+
+	if label.TrackingNumber != "" {
+		out.Text(padding, y, size, width, "Tracking: "+label.TrackingNumber)
+		y += size * 3 / 2
+		if code, err := barcode.Encode(barcode.Code128, label.TrackingNumber); err == nil {
+			module := max(min(width/code.Width(), out.Dots(0.5)), 1)
+			out.Barcode(padding, y, out.Height/8, module, code)
+		}
+	}
+
+	qr, err := qrcode.Encode(label.Payload())
+	if err != nil {
+		return err
+	}
+	magnification := min(max(out.Width/4/qr.Size, 1), zpl.MaxQRMagnification)
+	side := magnification * qr.Size
+	out.QRCode(out.Width-padding-side, out.Height-padding-side, magnification, label.Payload())
+
+	return out.Write(w, 1)
+}
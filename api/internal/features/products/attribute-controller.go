@@ -0,0 +1,91 @@
+package products
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *ProductController) ListAttributeDefinitions(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	definitions, err := ctrl.repo.ListAttributeDefinitions(inventoryId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch attribute definitions",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, definitions)
+}
+
+func (ctrl *ProductController) CreateAttributeDefinition(ctx echo.Context) error {
+	var input domain.AttributeDefinitionRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	definition := input.ToCreateAttributeDefinitionRequest()
+
+	if err := ctrl.repo.CreateAttributeDefinition(definition); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create attribute definition",
+			logger.Field{Key: "inventory_id", Value: definition.InventoryId},
+			logger.Field{Key: "key", Value: definition.Key})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, definition)
+}
+
+func (ctrl *ProductController) EditAttributeDefinition(ctx echo.Context) error {
+	definitionId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid attribute ID"))
+	}
+
+	var input domain.AttributeDefinitionRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	existingDefinition, err := ctrl.repo.GetAttributeDefinition(definitionId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	previous := *existingDefinition
+	updatedDefinition := input.ToUpdateAttributeDefinitionRequest(existingDefinition)
+
+	if err := ctrl.repo.EditAttributeDefinition(updatedDefinition, previous); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to update attribute definition",
+			logger.Field{Key: "attribute_id", Value: definitionId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, updatedDefinition)
+}
+
+func (ctrl *ProductController) DeleteAttributeDefinition(ctx echo.Context) error {
+	definitionId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid attribute ID"))
+	}
+
+	if err := ctrl.repo.DeleteAttributeDefinition(definitionId); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete attribute definition",
+			logger.Field{Key: "attribute_id", Value: definitionId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,342 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func (repo *ProductRepository) ListAttributeDefinitions(inventoryId uuid.UUID) ([]domain.AttributeDefinition, error) {
+	definitions := []domain.AttributeDefinition{}
+	query := `SELECT * FROM attribute_definitions WHERE inventory_id = $1 ORDER BY name`
+
+	if err := repo.db.Select(&definitions, query, inventoryId); err != nil {
+		return nil, errors.DatabaseError(err, "List Attribute Definitions")
+	}
+
+	return definitions, nil
+}
+
+func (repo *ProductRepository) GetAttributeDefinition(definitionId uuid.UUID) (*domain.AttributeDefinition, error) {
+	var definition domain.AttributeDefinition
+	if err := repo.db.Get(&definition, `SELECT * FROM attribute_definitions WHERE id = $1`, definitionId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Attribute not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Attribute Definition")
+	}
+
+	return &definition, nil
+}
+
+func (repo *ProductRepository) CreateAttributeDefinition(definition *domain.AttributeDefinition) error {
+	if !attributeKeyPattern.MatchString(definition.Key) {
+		return errors.ValidationError("Attribute keys must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+
+	if definition.IsRequired {
+		if err := checkRequiredAttribute(repo.db, definition); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO attribute_definitions (
+			id, inventory_id, key, name, type, options, is_required, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :key, :name, :type, :options, :is_required, :created_at, :updated_at
+		)
+	`
+	if _, err := repo.db.NamedExec(query, definition); err != nil {
+		return errors.DatabaseError(err, "Create Attribute Definition")
+	}
+
+	return nil
+}
+
+// EditAttributeDefinition updates a definition. While products hold values for
+// it, its key and type are fixed and enum options in use cannot be dropped. It
+// only becomes required once every product of the inventory has a value.
+func (repo *ProductRepository) EditAttributeDefinition(definition *domain.AttributeDefinition, previous domain.AttributeDefinition) error {
+	if !attributeKeyPattern.MatchString(definition.Key) {
+		return errors.ValidationError("Attribute keys must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+
+	var inUse bool
+	usedQuery := `SELECT EXISTS (SELECT 1 FROM products WHERE inventory_id = $1 AND attributes ? $2::text)`
+	if err := repo.db.Get(&inUse, usedQuery, definition.InventoryId, previous.Key); err != nil {
+		return errors.DatabaseError(err, "Edit Attribute Definition")
+	}
+
+	if inUse && (definition.Key != previous.Key || definition.Type != previous.Type) {
+		return errors.ConflictError("The key and type of an attribute cannot change while products have values for it")
+	}
+
+	if inUse && definition.Type == domain.AttributeTypeEnum {
+		var dropped []string
+		droppedQuery := `
+			SELECT DISTINCT attributes->>$2::text FROM products
+			WHERE inventory_id = $1 AND attributes ? $2::text
+				AND NOT jsonb_build_array(attributes->$2::text) <@ $3::jsonb
+		`
+		if err := repo.db.Select(&dropped, droppedQuery, definition.InventoryId, definition.Key, definition.Options); err != nil {
+			return errors.DatabaseError(err, "Edit Attribute Definition")
+		}
+
+		if len(dropped) > 0 {
+			return errors.ConflictError(fmt.Sprintf("Options still in use cannot be removed: %s", strings.Join(dropped, ", ")))
+		}
+	}
+
+	if definition.IsRequired && !previous.IsRequired {
+		if err := checkRequiredAttribute(repo.db, definition); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE attribute_definitions SET
+			key = :key,
+			name = :name,
+			type = :type,
+			options = :options,
+			is_required = :is_required,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+	if _, err := repo.db.NamedExec(query, definition); err != nil {
+		return errors.DatabaseError(err, "Edit Attribute Definition")
+	}
+
+	return nil
+}
+
+// checkRequiredAttribute refuses to make an attribute required while products
+// of the inventory have no value for it, as they could no longer be saved.
+func checkRequiredAttribute(q sqlx.Queryer, definition *domain.AttributeDefinition) error {
+	var missing int
+	query := `
+		SELECT COUNT(*) FROM products
+		WHERE inventory_id = $1 AND NOT COALESCE(attributes, '{}'::jsonb) ? $2::text
+	`
+	if err := sqlx.Get(q, &missing, query, definition.InventoryId, definition.Key); err != nil {
+		return errors.DatabaseError(err, "Check Required Attribute")
+	}
+
+	if missing > 0 {
+		return errors.ConflictError(fmt.Sprintf(
+			"%d products have no value for %s; fill them in before making it required", missing, definition.Name,
+		))
+	}
+
+	return nil
+}
+
+// DeleteAttributeDefinition removes a definition along with every product's
+// value for it.
+func (repo *ProductRepository) DeleteAttributeDefinition(definitionId uuid.UUID) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Delete Attribute Definition")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var definition domain.AttributeDefinition
+	query := `DELETE FROM attribute_definitions WHERE id = $1 RETURNING *`
+	if err := tx.Get(&definition, query, definitionId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Attribute not found")
+		}
+		return errors.DatabaseError(err, "Delete Attribute Definition")
+	}
+
+	valuesQuery := `UPDATE products SET attributes = attributes - $2::text WHERE inventory_id = $1 AND attributes ? $2::text`
+	if _, err := tx.Exec(valuesQuery, definition.InventoryId, definition.Key); err != nil {
+		_ = tx.Rollback()
+		return errors.DatabaseError(err, "Delete Attribute Definition")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Delete Attribute Definition")
+	}
+
+	return nil
+}
+
+// checkAttributes validates a product's values against its inventory's
+// definitions, normalizing them in place. Null values are dropped.
+func checkAttributes(tx *sqlx.Tx, inventoryId uuid.UUID, values domain.AttributeValues) error {
+	definitions, err := getAttributeDefinitions(tx, inventoryId)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		definition, ok := definitions[key]
+		if !ok {
+			return errors.ValidationError(fmt.Sprintf("Unknown attribute '%s'", key))
+		}
+
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+
+		normalized, ok := definition.Normalize(value)
+		if !ok {
+			return errors.ValidationError(fmt.Sprintf("Invalid value for attribute '%s': expected %s", definition.Name, describeAttributeType(definition)))
+		}
+		values[key] = normalized
+	}
+
+	for key, definition := range definitions {
+		if _, ok := values[key]; definition.IsRequired && !ok {
+			return errors.ValidationError(fmt.Sprintf("Attribute '%s' is required", definition.Name))
+		}
+	}
+
+	return nil
+}
+
+func describeAttributeType(definition domain.AttributeDefinition) string {
+	switch definition.Type {
+	case domain.AttributeTypeEnum:
+		return "one of " + strings.Join(definition.Options, ", ")
+	case domain.AttributeTypeDate:
+		return "a date (YYYY-MM-DD)"
+	case domain.AttributeTypeText:
+		return "text"
+	default:
+		return "a " + string(definition.Type)
+	}
+}
+
+func getAttributeDefinitions(q sqlx.Queryer, inventoryId uuid.UUID) (map[string]domain.AttributeDefinition, error) {
+	list := []domain.AttributeDefinition{}
+	if err := sqlx.Select(q, &list, `SELECT * FROM attribute_definitions WHERE inventory_id = $1`, inventoryId); err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]domain.AttributeDefinition, len(list))
+	for _, definition := range list {
+		definitions[definition.Key] = definition
+	}
+
+	return definitions, nil
+}
+
+// productFilterClause turns attribute filters and the sort key into SQL
+// conditions and an ORDER BY, continuing the numbering of args. Keys and values
+// are always passed as parameters.
+func productFilterClause(q sqlx.Queryer, inventoryId uuid.UUID, filter domain.ProductFilter, args []any) (string, string, []any, error) {
+	if len(filter.Attributes) == 0 && filter.SortKey == "" {
+		return "", "name", args, nil
+	}
+
+	definitions, err := getAttributeDefinitions(q, inventoryId)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	param := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{}
+	for _, f := range filter.Attributes {
+		definition, ok := definitions[f.Key]
+		if !ok {
+			return "", "", nil, errors.ValidationError(fmt.Sprintf("Unknown attribute '%s'", f.Key))
+		}
+
+		invalid := errors.ValidationError(fmt.Sprintf("Invalid filter value for attribute '%s'", f.Key))
+		if f.Op != domain.AttributeFilterEq && definition.Type != domain.AttributeTypeNumber && definition.Type != domain.AttributeTypeDate {
+			return "", "", nil, errors.ValidationError(fmt.Sprintf("Attribute '%s' can only be filtered by value", f.Key))
+		}
+
+		key := param(f.Key) + "::text"
+		switch definition.Type {
+		case domain.AttributeTypeText:
+			conditions = append(conditions, fmt.Sprintf("lower(attributes->>%s) = lower(%s)", key, param(f.Value)))
+		case domain.AttributeTypeEnum:
+			conditions = append(conditions, fmt.Sprintf("attributes @> jsonb_build_object(%s, %s::text)", key, param(f.Value)))
+		case domain.AttributeTypeBoolean:
+			flag, err := strconv.ParseBool(f.Value)
+			if err != nil {
+				return "", "", nil, invalid
+			}
+			conditions = append(conditions, fmt.Sprintf("attributes @> jsonb_build_object(%s, %s::boolean)", key, param(flag)))
+		case domain.AttributeTypeNumber:
+			number, err := strconv.ParseFloat(f.Value, 64)
+			if err != nil {
+				return "", "", nil, invalid
+			}
+			conditions = append(conditions, fmt.Sprintf("(attributes->>%s)::numeric %s %s", key, filterOperator(f.Op), param(number)))
+		case domain.AttributeTypeDate:
+			date, err := time.Parse(time.DateOnly, f.Value)
+			if err != nil {
+				return "", "", nil, invalid
+			}
+			conditions = append(conditions, fmt.Sprintf("(attributes->>%s)::date %s %s::date", key, filterOperator(f.Op), param(date.Format(time.DateOnly))))
+		}
+	}
+
+	order := "name"
+	if filter.SortKey != "" {
+		definition, ok := definitions[filter.SortKey]
+		if !ok {
+			return "", "", nil, errors.ValidationError(fmt.Sprintf("Unknown attribute '%s'", filter.SortKey))
+		}
+
+		expression := fmt.Sprintf("attributes->>%s::text", param(filter.SortKey))
+		switch definition.Type {
+		case domain.AttributeTypeNumber:
+			expression = fmt.Sprintf("(%s)::numeric", expression)
+		case domain.AttributeTypeDate:
+			expression = fmt.Sprintf("(%s)::date", expression)
+		case domain.AttributeTypeBoolean:
+			expression = fmt.Sprintf("(%s)::boolean", expression)
+		}
+
+		direction := "ASC"
+		if filter.SortDesc {
+			direction = "DESC"
+		}
+		order = fmt.Sprintf("%s %s NULLS LAST, name", expression, direction)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " AND " + strings.Join(conditions, " AND ")
+	}
+
+	return where, order, args, nil
+}
+
+func filterOperator(op domain.AttributeFilterOp) string {
+	switch op {
+	case domain.AttributeFilterMin:
+		return ">="
+	case domain.AttributeFilterMax:
+		return "<="
+	default:
+		return "="
+	}
+}
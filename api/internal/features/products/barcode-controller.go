@@ -0,0 +1,104 @@
+package products
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/pkg/barcode"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+func (ctrl *ProductController) LookupProduct(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	code := strings.TrimSpace(ctx.QueryParam("code"))
+	if code == "" {
+		return errors.Send(ctx, errors.ValidationError("A code is required"))
+	}
+
+	product, err := ctrl.repo.LookupProduct(inventoryId, code)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Lookup Product"))
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
+
+// RenderBarcode draws the product's code, or its SKU when it has none, as a
+// barcode. The symbology query parameter picks code128, ean13 or upca and
+// defaults to what the code looks like; format is png (default) or svg, scale
+// the width of one module and height the height of the bars, both in pixels.
+func (ctrl *ProductController) RenderBarcode(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	product, err := ctrl.repo.GetProduct(productId)
+	if err != nil {
+		return errors.Send(ctx, errors.NotFoundError("Product not found"))
+	}
+
+	data := product.SKU
+	if product.Code != nil && *product.Code != "" {
+		data = *product.Code
+	}
+
+	symbology := barcode.Symbology(ctx.QueryParam("symbology"))
+	if symbology == "" {
+		symbology = barcode.Detect(data)
+	}
+
+	scale, err := queryInt(ctx, "scale", 2, 1, 10)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+	height, err := queryInt(ctx, "height", 80, 10, 1000)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	code, err := barcode.Encode(symbology, data)
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Cannot encode "+data+" as "+string(symbology)+": "+err.Error()))
+	}
+
+	var image bytes.Buffer
+	switch ctx.QueryParam("format") {
+	case "", "png":
+		if err := code.WritePNG(&image, scale, height); err != nil {
+			return errors.Send(ctx, errors.InternalError(err, "Failed to render barcode"))
+		}
+		return ctx.Blob(http.StatusOK, "image/png", image.Bytes())
+	case "svg":
+		if err := code.WriteSVG(&image, scale, height); err != nil {
+			return errors.Send(ctx, errors.InternalError(err, "Failed to render barcode"))
+		}
+		return ctx.Blob(http.StatusOK, "image/svg+xml", image.Bytes())
+	default:
+		return errors.Send(ctx, errors.ValidationError("Format must be png or svg"))
+	}
+}
+
+// queryInt reads an optional integer query parameter, falling back to def and
+// rejecting values outside [low, high].
+func queryInt(ctx echo.Context, name string, def, low, high int) (int, error) {
+	raw := ctx.QueryParam(name)
+	if raw == "" {
+		return def, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < low || value > high {
+		return 0, errors.ValidationError(name + " must be a number from " + strconv.Itoa(low) + " to " + strconv.Itoa(high))
+	}
+
+	return value, nil
+}
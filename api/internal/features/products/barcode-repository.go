@@ -0,0 +1,61 @@
+package products
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/barcode"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// LookupProduct resolves a scanned or typed code to a product of the
+// inventory, matching its code first and its SKU otherwise.
+func (repo *ProductRepository) LookupProduct(inventoryId uuid.UUID, code string) (*domain.Product, error) {
+	var productId uuid.UUID
+	query := `
+		SELECT id FROM products
+		WHERE inventory_id = $1 AND (code = $2 OR sku = $2)
+		ORDER BY code IS NOT DISTINCT FROM $2 DESC
+		LIMIT 1
+	`
+	if err := repo.db.Get(&productId, query, inventoryId, code); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("No product matches code " + code)
+		}
+		return nil, err
+	}
+
+	product, err := repo.GetProductWithRelations(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// checkProductCode stores a blank code as no code and makes sure a code that
+// reads as an EAN-13 or UPC-A, 13 or 12 digits, ends in a correct check digit.
+func checkProductCode(product *domain.Product) error {
+	if product.Code == nil {
+		return nil
+	}
+
+	code := *product.Code
+	if code == "" {
+		product.Code = nil
+		return nil
+	}
+
+	if symbology := barcode.Detect(code); symbology != barcode.Code128 && !barcode.ValidGTIN(code) {
+		return errors.ValidationError("Code " + code + " has an invalid " + symbologyNames[symbology] + " check digit")
+	}
+
+	return nil
+}
+
+var symbologyNames = map[barcode.Symbology]string{
+	barcode.Code128: "Code 128",
+	barcode.EAN13:   "EAN-13",
+	barcode.UPCA:    "UPC-A",
+}
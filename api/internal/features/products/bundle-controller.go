@@ -0,0 +1,37 @@
+package products
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *ProductController) SetBundleComponents(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	var input domain.BundleRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	if err := ctrl.repo.SetBundleComponents(productId, input.Components); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to set bundle components",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Set Bundle Components"))
+	}
+
+	product, err := ctrl.repo.GetProductWithRelations(productId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Product"))
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
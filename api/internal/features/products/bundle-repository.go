@@ -0,0 +1,210 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// SetBundleComponents replaces a product's components, making it a bundle, or
+// an ordinary product again when the list is empty. Bundles hold no stock, so
+// only products without stock on hand can become one, and bundles do not nest.
+func (repo *ProductRepository) SetBundleComponents(bundleId uuid.UUID, components []domain.BundleComponentRequest) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var bundle domain.Product
+	if err := tx.Get(&bundle, `SELECT * FROM products WHERE id = $1 FOR UPDATE`, bundleId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if len(components) > 0 {
+		if err := checkBundle(tx, &bundle, components); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM bundle_components WHERE bundle_id = $1`, bundleId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	query := `INSERT INTO bundle_components (bundle_id, product_id, quantity) VALUES ($1, $2, $3)`
+	for _, component := range components {
+		if _, err := tx.Exec(query, bundleId, component.ProductId, component.Quantity); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	bundleQuery := `UPDATE products SET is_bundle = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if _, err := tx.Exec(bundleQuery, bundleId, len(components) > 0); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkBundle makes sure the product can be a bundle of the given components.
+func checkBundle(tx *sqlx.Tx, bundle *domain.Product, components []domain.BundleComponentRequest) error {
+	if bundle.Quantity != 0 || bundle.Reserved != 0 {
+		return errors.ConflictError("Only products without stock on hand can become bundles")
+	}
+
+	if bundle.IsSerialized {
+		return errors.ValidationError("A serialized product cannot be a bundle")
+	}
+
+	var usage struct {
+		HasVariants bool `db:"has_variants"`
+		IsComponent bool `db:"is_component"`
+	}
+	usageQuery := `
+		SELECT EXISTS (SELECT 1 FROM product_variant_axes WHERE product_id = $1) AS has_variants,
+			EXISTS (SELECT 1 FROM bundle_components WHERE product_id = $1) AS is_component
+	`
+	if err := tx.Get(&usage, usageQuery, bundle.Id); err != nil {
+		return err
+	}
+
+	if usage.HasVariants {
+		return errors.ValidationError("A product with variants cannot be a bundle; bundle one of its variants instead")
+	}
+
+	if usage.IsComponent {
+		return errors.ValidationError("A component of another bundle cannot be a bundle itself")
+	}
+
+	ids := make([]string, len(components))
+	listed := make(map[uuid.UUID]bool, len(components))
+	for i, component := range components {
+		if component.ProductId == bundle.Id {
+			return errors.ValidationError("A bundle cannot contain itself")
+		}
+		if listed[component.ProductId] {
+			return errors.ValidationError(fmt.Sprintf("Component %s is listed twice", component.ProductId))
+		}
+		listed[component.ProductId] = true
+		ids[i] = component.ProductId.String()
+	}
+
+	candidates := []struct {
+		Id           uuid.UUID `db:"id"`
+		Name         string    `db:"name"`
+		InventoryId  uuid.UUID `db:"inventory_id"`
+		IsBundle     bool      `db:"is_bundle"`
+		IsSerialized bool      `db:"is_serialized"`
+		HasVariants  bool      `db:"has_variants"`
+	}{}
+	query := `
+		SELECT p.id, p.name, p.inventory_id, p.is_bundle, p.is_serialized,
+			EXISTS (SELECT 1 FROM product_variant_axes a WHERE a.product_id = p.id) AS has_variants
+		FROM products p
+		WHERE p.id = ANY($1::uuid[])
+	`
+	if err := tx.Select(&candidates, query, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	if len(candidates) != len(components) {
+		return errors.ValidationError("Every component must be an existing product")
+	}
+
+	for _, candidate := range candidates {
+		switch {
+		case candidate.InventoryId != bundle.InventoryId:
+			return errors.ValidationError(fmt.Sprintf("Component %s belongs to another inventory", candidate.Name))
+		case candidate.IsBundle:
+			return errors.ValidationError(fmt.Sprintf("Component %s is a bundle itself", candidate.Name))
+		case candidate.HasVariants:
+			return errors.ValidationError(fmt.Sprintf("Component %s has variants; use one of its variants instead", candidate.Name))
+		case candidate.IsSerialized:
+			return errors.ValidationError(fmt.Sprintf("Component %s is serialized and cannot be bundled", candidate.Name))
+		}
+	}
+
+	return nil
+}
+
+// getBundleComponents lists a bundle's components with their current stock.
+func getBundleComponents(q sqlx.Queryer, bundleId uuid.UUID) ([]domain.BundleComponent, error) {
+	components := []domain.BundleComponent{}
+	query := `
+		SELECT bc.bundle_id, bc.product_id, bc.quantity, p.name, p.sku, p.available
+		FROM bundle_components bc
+		JOIN products p ON p.id = bc.product_id
+		WHERE bc.bundle_id = $1
+		ORDER BY p.name
+	`
+	if err := sqlx.Select(q, &components, query, bundleId); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+// deriveBundleStock sets each bundle's quantity and availability to the
+// number of complete bundles its components make up.
+func deriveBundleStock(q sqlx.Queryer, products []domain.Product) error {
+	ids := []string{}
+	for _, product := range products {
+		if product.IsBundle {
+			ids = append(ids, product.Id.String())
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	derived := []struct {
+		BundleId  uuid.UUID `db:"bundle_id"`
+		Quantity  int       `db:"quantity"`
+		Available int       `db:"available"`
+	}{}
+	query := `
+		SELECT bc.bundle_id,
+			MIN(FLOOR(p.quantity::numeric / bc.quantity))::int AS quantity,
+			MIN(FLOOR(p.available::numeric / bc.quantity))::int AS available
+		FROM bundle_components bc
+		JOIN products p ON p.id = bc.product_id
+		WHERE bc.bundle_id = ANY($1::uuid[])
+		GROUP BY bc.bundle_id
+	`
+	if err := sqlx.Select(q, &derived, query, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	byBundle := make(map[uuid.UUID]int, len(derived))
+	for i, row := range derived {
+		byBundle[row.BundleId] = i
+	}
+
+	for i := range products {
+		if row, ok := byBundle[products[i].Id]; ok {
+			products[i].Quantity = derived[row].Quantity
+			products[i].Available = derived[row].Available
+		}
+	}
+
+	return nil
+}
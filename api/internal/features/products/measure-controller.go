@@ -0,0 +1,38 @@
+package products
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *ProductController) SetProductUnits(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	var input domain.ProductUnitsRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	if err := ctrl.repo.SetProductUnits(productId, input.BaseUnit, input.ToUnitsOfMeasure(productId)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to set units of measure",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Set Product Units"))
+	}
+
+	product, err := ctrl.repo.GetProductWithRelations(productId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Product"))
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,85 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// SetProductUnits sets a product's base unit and replaces its units of
+// measure. Variants follow their parent's base unit. Lines already booked keep
+// the unit and quantity they were entered in.
+func (repo *ProductRepository) SetProductUnits(productId uuid.UUID, baseUnit string, units []domain.UnitOfMeasure) error {
+	names := map[string]bool{strings.ToLower(baseUnit): true}
+	for _, unit := range units {
+		key := strings.ToLower(unit.Name)
+		if names[key] {
+			return errors.ValidationError(fmt.Sprintf("Unit '%s' is listed twice or repeats the base unit", unit.Name))
+		}
+		names[key] = true
+	}
+
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var parentId *uuid.UUID
+	if err := tx.Get(&parentId, `SELECT parent_id FROM products WHERE id = $1 FOR UPDATE`, productId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if parentId != nil {
+		_ = tx.Rollback()
+		return errors.ValidationError("Variants use their parent's units of measure")
+	}
+
+	baseQuery := `UPDATE products SET base_unit = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1 OR parent_id = $1`
+	if _, err := tx.Exec(baseQuery, productId, baseUnit); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_units WHERE product_id = $1`, productId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO product_units (id, product_id, name, factor, created_at, updated_at)
+		VALUES (:id, :product_id, :name, :factor, :created_at, :updated_at)
+	`
+	for _, unit := range units {
+		if _, err := tx.NamedExec(query, unit); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func getProductUnits(q sqlx.Queryer, productId uuid.UUID) ([]domain.UnitOfMeasure, error) {
+	units := []domain.UnitOfMeasure{}
+	query := `SELECT * FROM product_units WHERE product_id = $1 ORDER BY factor, name`
+	if err := sqlx.Select(q, &units, query, productId); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
@@ -0,0 +1,97 @@
+package products
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+// ListPriceHistory shows the product's price and cost over time, optionally
+// between the 'from' and 'to' dates.
+func (ctrl *ProductController) ListPriceHistory(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	var filter domain.MovementFilter
+	if filter.From, err = utils.ParseDateParam(ctx.QueryParam("from"), false); err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid 'from' date"))
+	}
+	if filter.To, err = utils.ParseDateParam(ctx.QueryParam("to"), true); err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid 'to' date"))
+	}
+
+	entries, err := ctrl.repo.ListPriceHistory(productId, filter)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch price history",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, entries)
+}
+
+func (ctrl *ProductController) ListScheduledPriceChanges(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	changes, err := ctrl.repo.ListScheduledPriceChanges(productId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch scheduled price changes",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, changes)
+}
+
+func (ctrl *ProductController) SchedulePriceChange(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	var input domain.ScheduledPriceChangeRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	change := input.ToScheduledPriceChange(productId, utils.GetActor(ctx))
+
+	if err := ctrl.repo.SchedulePriceChange(change); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to schedule price change",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Schedule Price Change"))
+	}
+
+	return ctx.JSON(http.StatusCreated, change)
+}
+
+func (ctrl *ProductController) CancelPriceChange(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	changeId, err := uuid.Parse(ctx.Param("changeId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid price change ID"))
+	}
+
+	if err := ctrl.repo.CancelPriceChange(productId, changeId, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to cancel price change",
+			logger.Field{Key: "change_id", Value: changeId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Cancel Price Change"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,429 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+)
+
+// ListPriceHistory lists the product's prices and costs over time, oldest
+// first.
+func (repo *ProductRepository) ListPriceHistory(productId uuid.UUID, filter domain.MovementFilter) ([]domain.PriceHistoryEntry, error) {
+	if err := repo.checkProductExists(productId); err != nil {
+		return nil, err
+	}
+
+	entries := []domain.PriceHistoryEntry{}
+	query := `
+		SELECT * FROM price_history
+		WHERE product_id = $1
+			AND ($2::timestamptz IS NULL OR created_at >= $2)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY created_at ASC
+	`
+	if err := repo.db.Select(&entries, query, productId, filter.From, filter.To); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (repo *ProductRepository) ListScheduledPriceChanges(productId uuid.UUID) ([]domain.ScheduledPriceChange, error) {
+	if err := repo.checkProductExists(productId); err != nil {
+		return nil, err
+	}
+
+	changes := []domain.ScheduledPriceChange{}
+	query := `SELECT * FROM scheduled_price_changes WHERE product_id = $1 ORDER BY starts_at DESC`
+	if err := repo.db.Select(&changes, query, productId); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// SchedulePriceChange plans a price or cost change. A variant's cost follows
+// its parent's, and temporary changes of one product may not overlap, so each
+// has a well-defined price to go back to.
+func (repo *ProductRepository) SchedulePriceChange(change *domain.ScheduledPriceChange) error {
+	if change.Price == nil && change.Cost == nil {
+		return errors.ValidationError("A scheduled change needs a price, a cost or both")
+	}
+	if change.EndsAt != nil && !change.EndsAt.After(change.StartsAt) {
+		return errors.ValidationError("A scheduled change must end after it starts")
+	}
+
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var parentId *uuid.UUID
+	if err := tx.Get(&parentId, `SELECT parent_id FROM products WHERE id = $1 FOR UPDATE`, change.ProductId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if parentId != nil && change.Cost != nil {
+		_ = tx.Rollback()
+		return errors.ValidationError("A variant's cost follows its parent's; schedule the cost change on the parent")
+	}
+
+	if change.IsTemporary() {
+		var overlaps bool
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM scheduled_price_changes
+				WHERE product_id = $1 AND status IN ('pending', 'active') AND ends_at IS NOT NULL
+					AND starts_at < $3 AND ends_at > $2
+			)
+		`
+		if err := tx.Get(&overlaps, query, change.ProductId, change.StartsAt, change.EndsAt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if overlaps {
+			_ = tx.Rollback()
+			return errors.ConflictError("The change overlaps another temporary price change of the product")
+		}
+	}
+
+	query := `
+		INSERT INTO scheduled_price_changes (
+			id, product_id, price, cost, starts_at, ends_at, status, note, user_id, created_at, updated_at
+		) VALUES (
+			:id, :product_id, :price, :cost, :starts_at, :ends_at, :status, :note, :user_id, :created_at, :updated_at
+		)
+	`
+	if _, err := tx.NamedExec(query, change); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CancelPriceChange drops a pending change, or ends an active temporary one
+// early, putting the previous values back.
+func (repo *ProductRepository) CancelPriceChange(productId, changeId uuid.UUID, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var change domain.ScheduledPriceChange
+	query := `SELECT * FROM scheduled_price_changes WHERE id = $1 AND product_id = $2 FOR UPDATE`
+	if err := tx.Get(&change, query, changeId, productId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Scheduled price change not found")
+		}
+		return err
+	}
+
+	switch change.Status {
+	case domain.PriceChangePending:
+		update := `UPDATE scheduled_price_changes SET status = $2, updated_at = $3 WHERE id = $1`
+		if _, err := tx.Exec(update, change.Id, domain.PriceChangeCancelled, time.Now()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	case domain.PriceChangeActive:
+		if err := endPriceChange(tx, &change, domain.PriceChangeCancelled, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	default:
+		_ = tx.Rollback()
+		return errors.ConflictError("Only pending or active price changes can be cancelled")
+	}
+
+	return tx.Commit()
+}
+
+// ApplyDuePriceChanges starts the pending changes whose time has come, then
+// ends the temporary ones that are over, each in its own transaction. It
+// returns how many changes it started or ended.
+func (repo *ProductRepository) ApplyDuePriceChanges(now time.Time) (int, error) {
+	count := 0
+
+	starting := []uuid.UUID{}
+	query := `SELECT id FROM scheduled_price_changes WHERE status = 'pending' AND starts_at <= $1 ORDER BY starts_at`
+	if err := repo.db.Select(&starting, query, now); err != nil {
+		return count, err
+	}
+
+	for _, changeId := range starting {
+		applied, err := repo.startPriceChange(changeId)
+		if err != nil {
+			return count, err
+		}
+		if applied {
+			count++
+		}
+	}
+
+	ending := []uuid.UUID{}
+	query = `SELECT id FROM scheduled_price_changes WHERE status = 'active' AND ends_at <= $1 ORDER BY ends_at`
+	if err := repo.db.Select(&ending, query, now); err != nil {
+		return count, err
+	}
+
+	for _, changeId := range ending {
+		ended, err := repo.finishPriceChange(changeId)
+		if err != nil {
+			return count, err
+		}
+		if ended {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// RunPriceSchedule applies due price changes every interval until ctx is done.
+func (repo *ProductRepository) RunPriceSchedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := repo.ApplyDuePriceChanges(time.Now())
+			if err != nil {
+				logger.Error(ctx, err, "Failed to apply scheduled price changes")
+				continue
+			}
+			if count > 0 {
+				logger.Info(ctx, "Applied scheduled price changes",
+					logger.Field{Key: "count", Value: count})
+			}
+		}
+	}
+}
+
+// startPriceChange applies a pending change, remembering the values it
+// replaces. A permanent change is completed at once; a temporary one stays
+// active until it ends. It reports false when another run got there first.
+func (repo *ProductRepository) startPriceChange(changeId uuid.UUID) (bool, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var change domain.ScheduledPriceChange
+	query := `SELECT * FROM scheduled_price_changes WHERE id = $1 AND status = 'pending' FOR UPDATE`
+	if err := tx.Get(&change, query, changeId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var current struct {
+		Price float64 `db:"price"`
+		Cost  float64 `db:"cost"`
+	}
+	if err := tx.Get(&current, `SELECT price, cost FROM products WHERE id = $1 FOR UPDATE`, change.ProductId); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	now := time.Now()
+	change.PreviousPrice = &current.Price
+	change.PreviousCost = &current.Cost
+	change.AppliedAt = &now
+	change.Status = domain.PriceChangeCompleted
+	if change.IsTemporary() {
+		change.Status = domain.PriceChangeActive
+	}
+
+	update := `UPDATE products SET price = COALESCE($2, price), cost = COALESCE($3, cost), updated_at = $4 WHERE id = $1`
+	if _, err := tx.Exec(update, change.ProductId, change.Price, change.Cost, now); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := syncVariantCost(tx, &change); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	query = `
+		UPDATE scheduled_price_changes SET
+			status = :status,
+			previous_price = :previous_price,
+			previous_cost = :previous_cost,
+			applied_at = :applied_at,
+			updated_at = :applied_at
+		WHERE id = :id
+	`
+	if _, err := tx.NamedExec(query, &change); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := recordPrices(tx, change.ProductId, domain.PriceSourceSchedule, &change.Id, domain.Actor{UserId: change.UserId}); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// finishPriceChange ends an active temporary change that is over. It reports
+// false when another run got there first.
+func (repo *ProductRepository) finishPriceChange(changeId uuid.UUID) (bool, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var change domain.ScheduledPriceChange
+	query := `SELECT * FROM scheduled_price_changes WHERE id = $1 AND status = 'active' FOR UPDATE`
+	if err := tx.Get(&change, query, changeId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := endPriceChange(tx, &change, domain.PriceChangeCompleted, domain.Actor{UserId: change.UserId}); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// endPriceChange puts back the values an active change replaced and closes it
+// with the given status. A price or cost changed again since the change
+// started is left alone rather than overwritten.
+func endPriceChange(tx *sqlx.Tx, change *domain.ScheduledPriceChange, status domain.PriceChangeStatus, actor domain.Actor) error {
+	if _, err := tx.Exec(`SELECT id FROM products WHERE id = $1 FOR UPDATE`, change.ProductId); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	restore := `
+		UPDATE products SET
+			price = CASE WHEN $2::numeric IS NOT NULL AND price = $2 THEN $3 ELSE price END,
+			cost = CASE WHEN $4::numeric IS NOT NULL AND cost = $4 THEN $5 ELSE cost END,
+			updated_at = $6
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(restore, change.ProductId, change.Price, change.PreviousPrice, change.Cost, change.PreviousCost, now); err != nil {
+		return err
+	}
+
+	if err := syncVariantCost(tx, change); err != nil {
+		return err
+	}
+
+	update := `UPDATE scheduled_price_changes SET status = $2, ended_at = $3, updated_at = $3 WHERE id = $1`
+	if _, err := tx.Exec(update, change.Id, status, now); err != nil {
+		return err
+	}
+
+	return recordPrices(tx, change.ProductId, domain.PriceSourceSchedule, &change.Id, actor)
+}
+
+// syncVariantCost copies a parent's cost onto its variants after a scheduled
+// change touched it, as editing the parent does.
+func syncVariantCost(tx *sqlx.Tx, change *domain.ScheduledPriceChange) error {
+	if change.Cost == nil {
+		return nil
+	}
+
+	query := `UPDATE products v SET cost = p.cost, updated_at = p.updated_at FROM products p WHERE p.id = $1 AND v.parent_id = p.id`
+	_, err := tx.Exec(query, change.ProductId)
+	return err
+}
+
+func (repo *ProductRepository) checkProductExists(productId uuid.UUID) error {
+	var exists bool
+	if err := repo.db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productId); err != nil {
+		return err
+	}
+	if !exists {
+		return errors.NotFoundError("Product not found")
+	}
+	return nil
+}
+
+// recordPrices writes a history entry for the product, and its variants, whose
+// price or cost differs from its latest entry.
+func recordPrices(tx *sqlx.Tx, productId uuid.UUID, source domain.PriceSource, scheduleId *uuid.UUID, actor domain.Actor) error {
+	changed := []struct {
+		Id    uuid.UUID `db:"id"`
+		Price float64   `db:"price"`
+		Cost  float64   `db:"cost"`
+	}{}
+	query := `
+		SELECT p.id, p.price, p.cost FROM products p
+		WHERE (p.id = $1 OR p.parent_id = $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM (
+					SELECT h.price, h.cost FROM price_history h
+					WHERE h.product_id = p.id
+					ORDER BY h.created_at DESC
+					LIMIT 1
+				) latest
+				WHERE latest.price = p.price AND latest.cost = p.cost
+			)
+	`
+	if err := tx.Select(&changed, query, productId); err != nil {
+		return err
+	}
+
+	insert := `
+		INSERT INTO price_history (id, product_id, price, cost, source, schedule_id, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for _, product := range changed {
+		if _, err := tx.Exec(insert, uuid.New(), product.Id, product.Price, product.Cost,
+			source, scheduleId, actor.UserId, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
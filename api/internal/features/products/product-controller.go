@@ -2,10 +2,12 @@ package products
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
 	"github.com/ventry/internal/utils"
 )
 
@@ -23,12 +25,9 @@ func (ctrl *ProductController) ListProducts(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, "Invalid inventory ID")
 	}
 
-	products, err := ctrl.repo.ListProducts(inventoryId)
+	products, err := ctrl.repo.ListProducts(inventoryId, parseProductFilter(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to fetch products",
-			"details": err.Error(),
-		})
+		return errors.Send(ctx, errors.DatabaseError(err, "List Products"))
 	}
 
 	return ctx.JSON(http.StatusOK, products)
@@ -61,12 +60,9 @@ func (ctrl *ProductController) CreateProduct(ctx echo.Context) error {
 
 	newProduct := input.ToCreateProductRequest()
 
-	err := ctrl.repo.CreateProduct(newProduct, input.Categories, input.Storages, input.Images)
+	err := ctrl.repo.CreateProduct(newProduct, input.Categories, input.Storages, input.Images, input.Suppliers, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to create product",
-			"details": err.Error(),
-		})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Product"))
 	}
 
 	product, err := ctrl.repo.GetProductWithRelations(newProduct.Id)
@@ -98,14 +94,15 @@ func (ctrl *ProductController) EditProduct(ctx echo.Context) error {
 		return ctx.JSON(http.StatusNotFound, "Product not found")
 	}
 
+	if existingProduct.ParentId != nil {
+		return errors.Send(ctx, errors.ValidationError("Variants are edited through their parent's variant endpoints"))
+	}
+
 	updatedProduct := input.ToEditProductRequest(existingProduct)
 
-	err = ctrl.repo.EditProduct(updatedProduct, input.Categories, input.Storages, input.Images)
+	err = ctrl.repo.EditProduct(updatedProduct, input.Categories, input.Storages, input.Images, input.Suppliers, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to update product",
-			"details": err.Error(),
-		})
+		return errors.Send(ctx, errors.DatabaseError(err, "Edit Product"))
 	}
 
 	product, err := ctrl.repo.GetProductWithRelations(updatedProduct.Id)
@@ -135,3 +132,39 @@ func (ctrl *ProductController) DeleteProduct(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// parseProductFilter reads the listing filter from the query string:
+// attr.<key>=value matches a value, attr.<key>.min and attr.<key>.max bound a
+// range, and sort=attr.<key> or sort=-attr.<key> orders by an attribute.
+func parseProductFilter(ctx echo.Context) domain.ProductFilter {
+	filter := domain.ProductFilter{Search: strings.TrimSpace(ctx.QueryParam("search"))}
+
+	for param, values := range ctx.QueryParams() {
+		key, ok := strings.CutPrefix(param, "attr.")
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		op := domain.AttributeFilterEq
+		if name, ok := strings.CutSuffix(key, ".min"); ok {
+			key, op = name, domain.AttributeFilterMin
+		} else if name, ok := strings.CutSuffix(key, ".max"); ok {
+			key, op = name, domain.AttributeFilterMax
+		}
+
+		filter.Attributes = append(filter.Attributes, domain.AttributeFilter{
+			Key:   key,
+			Op:    op,
+			Value: strings.TrimSpace(values[0]),
+		})
+	}
+
+	sort := ctx.QueryParam("sort")
+	sort, filter.SortDesc = strings.CutPrefix(sort, "-")
+	filter.SortKey, _ = strings.CutPrefix(sort, "attr.")
+	if filter.SortKey == sort {
+		filter.SortKey, filter.SortDesc = "", false
+	}
+
+	return filter
+}
@@ -2,18 +2,44 @@ package products
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
 )
 
-func (repo *ProductRepository) UpdateProductQuantity(productId uuid.UUID, quantity int) error {
-	query := `UPDATE products SET quantity = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+func (repo *ProductRepository) UpdateProductQuantity(productId uuid.UUID, quantity int, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var currentQuantity int
+	if err := tx.Get(&currentQuantity, `SELECT quantity FROM products WHERE id = $1 FOR UPDATE`, productId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
-	_, err := repo.db.Exec(query, quantity, productId)
-	return err
+	if delta := quantity - currentQuantity; delta != 0 {
+		movement := domain.NewStockMovement(productId, delta,
+			domain.MovementSourceAdjustment, nil, "quantity set", actor)
+		if err := stock.ApplyCorrection(tx, movement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (repo *ProductRepository) GetProductWithRelations(productId uuid.UUID) (domain.Product, error) {
@@ -26,12 +52,22 @@ func (repo *ProductRepository) GetProductWithRelations(productId uuid.UUID) (dom
 		return product, err
 	}
 
+	if err := repo.loadVariants(&product); err != nil {
+		return product, err
+	}
+
+	// Variants share their parent's categories, storages, images and suppliers
+	relationId := productId
+	if product.ParentId != nil {
+		relationId = *product.ParentId
+	}
+
 	categoriesQuery := `
         SELECT c.* FROM categories c
         INNER JOIN product_categories pc ON c.id = pc.category_id
         WHERE pc.product_id = $1
     `
-	if err := repo.db.Select(&product.Categories, categoriesQuery, productId); err != nil {
+	if err := repo.db.Select(&product.Categories, categoriesQuery, relationId); err != nil {
 		return product, err
 	}
 
@@ -40,7 +76,7 @@ func (repo *ProductRepository) GetProductWithRelations(productId uuid.UUID) (dom
         INNER JOIN product_storages ps ON s.id = ps.storage_id
         WHERE ps.product_id = $1
     `
-	if err := repo.db.Select(&product.Storages, storagesQuery, productId); err != nil {
+	if err := repo.db.Select(&product.Storages, storagesQuery, relationId); err != nil {
 		return product, err
 	}
 
@@ -49,9 +85,33 @@ func (repo *ProductRepository) GetProductWithRelations(productId uuid.UUID) (dom
         WHERE product_id = $1 
         ORDER BY is_primary DESC, created_at ASC
     `
-	if err := repo.db.Select(&product.Images, imagesQuery, productId); err != nil {
+	if err := repo.db.Select(&product.Images, imagesQuery, relationId); err != nil {
+		return product, err
+	}
+
+	suppliers, err := repo.getProductSuppliers(relationId)
+	if err != nil {
+		return product, err
+	}
+	product.Suppliers = suppliers
+
+	units, err := getProductUnits(repo.db, relationId)
+	if err != nil {
 		return product, err
 	}
+	product.Units = units
+
+	if product.IsBundle {
+		if product.Components, err = getBundleComponents(repo.db, productId); err != nil {
+			return product, err
+		}
+
+		bundle := []domain.Product{product}
+		if err := deriveBundleStock(repo.db, bundle); err != nil {
+			return product, err
+		}
+		product = bundle[0]
+	}
 
 	return product, nil
 }
@@ -149,6 +209,67 @@ func (repo *ProductRepository) handleProductImages(tx *sqlx.Tx, productId uuid.U
 	return nil
 }
 
+func (repo *ProductRepository) getProductSuppliers(productId uuid.UUID) ([]domain.ProductSupplier, error) {
+	suppliers := []domain.ProductSupplier{}
+	query := `
+		SELECT ps.*, s.name AS supplier_name FROM product_suppliers ps
+		INNER JOIN suppliers s ON s.id = ps.supplier_id
+		WHERE ps.product_id = $1
+		ORDER BY ps.is_preferred DESC, s.name
+	`
+	if err := repo.db.Select(&suppliers, query, productId); err != nil {
+		return nil, err
+	}
+
+	return suppliers, nil
+}
+
+// handleProductSuppliers replaces the product's supplier catalog. Every supplier
+// must belong to the product's inventory and at most one may be preferred.
+func (repo *ProductRepository) handleProductSuppliers(tx *sqlx.Tx, productId, inventoryId uuid.UUID, suppliers []domain.ProductSupplierRequest) error {
+	preferred := 0
+	for _, supplier := range suppliers {
+		if supplier.IsPreferred {
+			preferred++
+		}
+	}
+	if preferred > 1 {
+		return errors.ValidationError("Only one supplier can be preferred for a product")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_suppliers WHERE product_id = $1`, productId); err != nil {
+		return err
+	}
+
+	for _, supplier := range suppliers {
+		// Validate that the supplier exists and belongs to the inventory
+		var exists bool
+		validationQuery := `SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1 AND inventory_id = $2)`
+		if err := tx.Get(&exists, validationQuery, supplier.SupplierId, inventoryId); err != nil {
+			return err
+		}
+		if !exists {
+			return errors.ValidationError(fmt.Sprintf("Supplier %s not found in this inventory", supplier.SupplierId))
+		}
+
+		entry := supplier.ToProductSupplier(productId)
+		query := `
+			INSERT INTO product_suppliers (
+				id, product_id, supplier_id, supplier_sku, unit_cost, min_order_quantity,
+				pack_size, lead_time_days, is_preferred, created_at, updated_at
+			) VALUES (
+				:id, :product_id, :supplier_id, :supplier_sku, :unit_cost, :min_order_quantity,
+				:pack_size, :lead_time_days, :is_preferred, :created_at, :updated_at
+			)
+		`
+		if _, err := tx.NamedExec(query, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (repo *ProductRepository) clearProductRelationships(tx *sqlx.Tx, productId uuid.UUID) error {
 	queries := []string{
 		`DELETE FROM product_categories WHERE product_id = $1`,
@@ -164,3 +285,52 @@ func (repo *ProductRepository) clearProductRelationships(tx *sqlx.Tx, productId
 
 	return nil
 }
+
+// checkSerializedChange refuses to switch serial tracking on or off while the
+// product or its variants hold stock or reservations, which would be left
+// without serials or with orphaned ones. Bundles and bundle components cannot
+// become serialized at all.
+func checkSerializedChange(tx *sqlx.Tx, product *domain.Product) error {
+	var current struct {
+		IsSerialized bool `db:"is_serialized"`
+		IsBundle     bool `db:"is_bundle"`
+		HasStock     bool `db:"has_stock"`
+		IsComponent  bool `db:"is_component"`
+	}
+	query := `
+		SELECT p.is_serialized, p.is_bundle,
+			EXISTS (
+				SELECT 1 FROM products s
+				WHERE (s.id = p.id OR s.parent_id = p.id) AND (s.quantity <> 0 OR s.reserved <> 0)
+			) AS has_stock,
+			EXISTS (
+				SELECT 1 FROM bundle_components bc
+				JOIN products s ON s.id = bc.product_id
+				WHERE s.id = p.id OR s.parent_id = p.id
+			) AS is_component
+		FROM products p
+		WHERE p.id = $1
+		FOR UPDATE OF p
+	`
+	if err := tx.Get(&current, query, product.Id); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if current.IsSerialized == product.IsSerialized {
+		return nil
+	}
+
+	switch {
+	case product.IsSerialized && current.IsBundle:
+		return errors.ValidationError("A bundle cannot be serialized")
+	case product.IsSerialized && current.IsComponent:
+		return errors.ValidationError("A component of a bundle cannot be serialized")
+	case current.HasStock || product.Quantity != 0:
+		return errors.ConflictError("Serial tracking can only be switched while the product and its variants have no stock or reservations")
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package products
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
 )
 
 type ProductRepository struct {
@@ -14,17 +17,75 @@ func NewProductRepository(data *sqlx.DB) *ProductRepository {
 	return &ProductRepository{db: data}
 }
 
-func (repo *ProductRepository) ListProducts(inventoryId uuid.UUID) (*[]domain.Product, error) {
-	// First, get all products for the inventory
+// ListProducts lists the inventory's products with variants grouped under their
+// parent, filtered and sorted on custom attributes. A search matches name, SKU
+// or code; a parent that matches keeps all its variants, otherwise only the
+// matching variants are listed under it.
+func (repo *ProductRepository) ListProducts(inventoryId uuid.UUID, filter domain.ProductFilter) (*[]domain.Product, error) {
+	where, order, args, err := productFilterClause(repo.db, inventoryId, filter, []any{inventoryId})
+	if err != nil {
+		return nil, err
+	}
+
+	// First, get all matching products for the inventory
+	all := []domain.Product{}
+	productsQuery := `SELECT * FROM products WHERE inventory_id = $1` + where + ` ORDER BY ` + order
+
+	if err := repo.db.Select(&all, productsQuery, args...); err != nil {
+		return nil, err
+	}
+
+	if err := deriveBundleStock(repo.db, all); err != nil {
+		return nil, err
+	}
+
+	options, err := getOptionsByVariant(repo.db, inventoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[uuid.UUID][]domain.Product)
+	for _, product := range all {
+		if product.ParentId != nil {
+			product.Options = options[product.Id]
+			variants[*product.ParentId] = append(variants[*product.ParentId], product)
+		}
+	}
+
 	products := []domain.Product{}
-	productsQuery := `SELECT * FROM products WHERE inventory_id = $1`
+	parentIds := []uuid.UUID{}
+	for _, product := range all {
+		if product.ParentId != nil {
+			continue
+		}
+
+		product.Variants = variants[product.Id]
+		if !matchesSearch(product, filter.Search) {
+			matched := []domain.Product{}
+			for _, variant := range product.Variants {
+				if matchesSearch(variant, filter.Search) {
+					matched = append(matched, variant)
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+			product.Variants = matched
+		}
+
+		products = append(products, product)
+		parentIds = append(parentIds, product.Id)
+	}
 
-	if err := repo.db.Select(&products, productsQuery, inventoryId); err != nil {
+	axes, err := getVariantAxes(repo.db, parentIds)
+	if err != nil {
 		return nil, err
 	}
 
 	// For each product, fetch related data
 	for i := range products {
+		products[i].VariantAxes = axes[products[i].Id]
+
 		// Fetch categories
 		categoriesQuery := `
 			SELECT c.* FROM categories c
@@ -54,11 +115,29 @@ func (repo *ProductRepository) ListProducts(inventoryId uuid.UUID) (*[]domain.Pr
 		if err := repo.db.Select(&products[i].Images, imagesQuery, products[i].Id); err != nil {
 			return nil, err
 		}
+
+		// Fetch supplier catalog
+		suppliers, err := repo.getProductSuppliers(products[i].Id)
+		if err != nil {
+			return nil, err
+		}
+		products[i].Suppliers = suppliers
 	}
 
 	return &products, nil
 }
 
+func matchesSearch(product domain.Product, search string) bool {
+	if search == "" {
+		return true
+	}
+
+	search = strings.ToLower(search)
+	return strings.Contains(strings.ToLower(product.Name), search) ||
+		strings.Contains(strings.ToLower(product.SKU), search) ||
+		(product.Code != nil && strings.Contains(strings.ToLower(*product.Code), search))
+}
+
 func (repo *ProductRepository) GetProduct(productId uuid.UUID) (*domain.Product, error) {
 	var product domain.Product
 	query := `SELECT * FROM products WHERE id = $1`
@@ -67,7 +146,7 @@ func (repo *ProductRepository) GetProduct(productId uuid.UUID) (*domain.Product,
 	return &product, err
 }
 
-func (repo *ProductRepository) CreateProduct(product *domain.Product, categoryNames []string, storages []domain.Storage, imageUrls []string) error {
+func (repo *ProductRepository) CreateProduct(product *domain.Product, categoryNames []string, storages []domain.Storage, imageUrls []string, suppliers []domain.ProductSupplierRequest, actor domain.Actor) error {
 	// Start transaction
 	tx, err := repo.db.Beginx()
 	if err != nil {
@@ -80,20 +159,60 @@ func (repo *ProductRepository) CreateProduct(product *domain.Product, categoryNa
 		}
 	}()
 
-	// Insert the product into the database
+	// Insert the product into the database; the opening quantity is booked
+	// through the stock ledger below
 	query := `INSERT INTO products (
 				id, name, description, sku, code, quantity, restock_level, optimal_level, 
-				cost, price, inventory_id, created_at, updated_at
+				cost, price, unit_volume, unit_weight, is_serialized, base_unit, inventory_id, attributes, created_at, updated_at
 			  ) VALUES (
-			  	:id, :name, :description, :sku, :code, :quantity, :restock_level, :optimal_level, 
-				:cost, :price, :inventory_id, :created_at, :updated_at
+			  	:id, :name, :description, :sku, :code, 0, :restock_level, :optimal_level, 
+				:cost, :price, :unit_volume, :unit_weight, :is_serialized, :base_unit, :inventory_id, :attributes, :created_at, :updated_at
 			  )`
 
+	if err := checkProductCode(product); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := checkAttributes(tx, product.InventoryId, product.Attributes); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Generate the SKU from the inventory's pattern when none was given
+	if product.SKU == "" {
+		category := ""
+		if len(categoryNames) > 0 {
+			category = categoryNames[0]
+		}
+
+		sku, err := generateSKU(tx, product, category)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		product.SKU = sku
+	}
+
 	if _, err := tx.NamedExec(query, product); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
+	if err := recordPrices(tx, product.Id, domain.PriceSourceCreated, nil, actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if product.Quantity != 0 {
+		movement := domain.NewStockMovement(product.Id, product.Quantity,
+			domain.MovementSourceAdjustment, nil, "opening balance", actor)
+		if err := stock.ApplyCorrection(tx, movement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	// Handle category relationships
 	if err := repo.handleProductCategories(tx, product.Id, product.InventoryId, categoryNames); err != nil {
 		_ = tx.Rollback()
@@ -114,11 +233,19 @@ func (repo *ProductRepository) CreateProduct(product *domain.Product, categoryNa
 		}
 	}
 
+	// Handle supplier catalog
+	if suppliers != nil {
+		if err := repo.handleProductSuppliers(tx, product.Id, product.InventoryId, suppliers); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	// Commit the transaction
 	return tx.Commit()
 }
 
-func (repo *ProductRepository) EditProduct(product *domain.Product, categoryNames []string, storages []domain.Storage, imageUrls []string) error {
+func (repo *ProductRepository) EditProduct(product *domain.Product, categoryNames []string, storages []domain.Storage, imageUrls []string, suppliers []domain.ProductSupplierRequest, actor domain.Actor) error {
 	// Start transaction
 	tx, err := repo.db.Beginx()
 	if err != nil {
@@ -131,26 +258,71 @@ func (repo *ProductRepository) EditProduct(product *domain.Product, categoryName
 		}
 	}()
 
+	if err := checkSerializedChange(tx, product); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Book any quantity change through the stock ledger. A bundle's quantity is
+	// derived from its components and cannot be edited.
+	var currentQuantity int
+	if err := tx.Get(&currentQuantity, `SELECT quantity FROM products WHERE id = $1 FOR UPDATE`, product.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if delta := product.Quantity - currentQuantity; delta != 0 && !product.IsBundle {
+		movement := domain.NewStockMovement(product.Id, delta,
+			domain.MovementSourceAdjustment, nil, "product edited", actor)
+		if err := stock.ApplyCorrection(tx, movement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	// Update the product
 	query := `UPDATE products SET 
 				name = :name,
 				description = :description,
 				sku = :sku,
 				code = :code,
-				quantity = :quantity,
 				restock_level = :restock_level,
 				optimal_level = :optimal_level,
 				cost = :cost,
 				price = :price,
+				unit_volume = :unit_volume,
+				unit_weight = :unit_weight,
+				is_serialized = :is_serialized,
 				inventory_id = :inventory_id,
+				attributes = :attributes,
 				updated_at = :updated_at
 			 WHERE id = :id`
 
+	if err := checkProductCode(product); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := checkAttributes(tx, product.InventoryId, product.Attributes); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	if _, err := tx.NamedExec(query, product); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
+	if err := syncVariants(tx, product); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := recordPrices(tx, product.Id, domain.PriceSourceManual, nil, actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	// Clear existing relationships
 	if err := repo.clearProductRelationships(tx, product.Id); err != nil {
 		_ = tx.Rollback()
@@ -177,6 +349,14 @@ func (repo *ProductRepository) EditProduct(product *domain.Product, categoryName
 		}
 	}
 
+	// Handle supplier catalog
+	if suppliers != nil {
+		if err := repo.handleProductSuppliers(tx, product.Id, product.InventoryId, suppliers); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	return tx.Commit()
 }
 
@@ -0,0 +1,30 @@
+package products
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// PreviewSKU shows the next SKU the inventory's pattern generates. The name and
+// category query parameters stand in for the product's, and pattern tries out
+// a pattern before it is saved on the inventory.
+func (ctrl *ProductController) PreviewSKU(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	preview, err := ctrl.repo.PreviewSKU(inventoryId,
+		strings.TrimSpace(ctx.QueryParam("pattern")),
+		strings.TrimSpace(ctx.QueryParam("name")),
+		strings.TrimSpace(ctx.QueryParam("category")))
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Preview SKU"))
+	}
+
+	return ctx.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,117 @@
+package products
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// maxSKUAttempts bounds how many sequence values are tried when generated SKUs
+// collide with ones entered by hand.
+const maxSKUAttempts = 20
+
+// PreviewSKU shows the SKU the next product of the inventory would get from
+// its pattern, or from pattern when one is given, without using up the
+// sequence value.
+func (repo *ProductRepository) PreviewSKU(inventoryId uuid.UUID, pattern, name, category string) (*domain.SKUPreview, error) {
+	if pattern == "" {
+		var err error
+		if pattern, err = inventorySKUPattern(repo.db, inventoryId); err != nil {
+			return nil, err
+		}
+	}
+	if pattern == "" {
+		return nil, errors.ValidationError("A SKU is required; the inventory has no SKU pattern to generate one")
+	}
+	if err := domain.CheckSKUPattern(pattern); err != nil {
+		return nil, errors.ValidationError("Invalid SKU pattern: " + err.Error())
+	}
+
+	var last int64
+	query := `SELECT COALESCE((SELECT last_value FROM sku_sequences WHERE inventory_id = $1), 0)`
+	if err := repo.db.Get(&last, query, inventoryId); err != nil {
+		return nil, err
+	}
+
+	input := domain.SKUInput{Name: name, Category: category, Date: time.Now()}
+	for sequence := last + 1; sequence <= last+maxSKUAttempts; sequence++ {
+		input.Sequence = sequence
+		sku, taken, err := renderSKU(repo.db, inventoryId, pattern, input)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			return &domain.SKUPreview{Pattern: pattern, SKU: sku, Sequence: sequence}, nil
+		}
+	}
+
+	return nil, errors.ConflictError("No free SKU found; the pattern's SKUs are already taken")
+}
+
+// generateSKU gives a product created without a SKU one from its inventory's
+// pattern. Each attempt claims the next sequence value, which locks the
+// inventory's sequence row until the transaction ends, so concurrent creations
+// never share a value and a rolled back one gives its value back.
+func generateSKU(tx *sqlx.Tx, product *domain.Product, category string) (string, error) {
+	pattern, err := inventorySKUPattern(tx, product.InventoryId)
+	if err != nil {
+		return "", err
+	}
+	if pattern == "" {
+		return "", errors.ValidationError("A SKU is required; the inventory has no SKU pattern to generate one")
+	}
+
+	claim := `
+		INSERT INTO sku_sequences (inventory_id, last_value) VALUES ($1, 1)
+		ON CONFLICT (inventory_id) DO UPDATE SET last_value = sku_sequences.last_value + 1
+		RETURNING last_value
+	`
+	input := domain.SKUInput{Name: product.Name, Category: category, Date: product.CreatedAt}
+	for attempt := 0; attempt < maxSKUAttempts; attempt++ {
+		if err := tx.Get(&input.Sequence, claim, product.InventoryId); err != nil {
+			return "", err
+		}
+
+		sku, taken, err := renderSKU(tx, product.InventoryId, pattern, input)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return sku, nil
+		}
+	}
+
+	return "", errors.ConflictError("No free SKU found; the pattern's SKUs are already taken")
+}
+
+// renderSKU fills in the pattern and reports whether a product of the
+// inventory already has the result.
+func renderSKU(q sqlx.Queryer, inventoryId uuid.UUID, pattern string, input domain.SKUInput) (string, bool, error) {
+	sku := domain.RenderSKU(pattern, input)
+	if len(sku) > domain.MaxSKULength {
+		return "", false, errors.ValidationError("The SKU pattern generates " + sku + ", longer than 50 characters")
+	}
+
+	var taken bool
+	query := `SELECT EXISTS (SELECT 1 FROM products WHERE inventory_id = $1 AND sku = $2)`
+	if err := sqlx.Get(q, &taken, query, inventoryId, sku); err != nil {
+		return "", false, err
+	}
+
+	return sku, taken, nil
+}
+
+func inventorySKUPattern(q sqlx.Queryer, inventoryId uuid.UUID) (string, error) {
+	var pattern string
+	if err := sqlx.Get(q, &pattern, `SELECT sku_pattern FROM inventories WHERE id = $1`, inventoryId); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.NotFoundError("Inventory not found")
+		}
+		return "", err
+	}
+	return pattern, nil
+}
@@ -0,0 +1,75 @@
+package products
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *ProductController) GenerateVariants(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	var input domain.GenerateVariantsRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	if err := ctrl.repo.GenerateVariants(productId, &input, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to generate variants",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Generate Variants"))
+	}
+
+	product, err := ctrl.repo.GetProductWithRelations(productId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Product"))
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
+
+func (ctrl *ProductController) EditVariant(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	variantId, err := uuid.Parse(ctx.Param("variantId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid variant ID"))
+	}
+
+	var input domain.VariantRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	existing, err := ctrl.repo.GetProduct(variantId)
+	if err != nil || existing.ParentId == nil || *existing.ParentId != productId {
+		return errors.Send(ctx, errors.NotFoundError("Variant not found"))
+	}
+
+	variant := input.ToEditVariantRequest(existing)
+	if err := ctrl.repo.EditVariant(variant, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to edit variant",
+			logger.Field{Key: "variant_id", Value: variantId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Edit Variant"))
+	}
+
+	product, err := ctrl.repo.GetProductWithRelations(variantId)
+	if err != nil {
+		return errors.Send(ctx, errors.DatabaseError(err, "Get Product"))
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
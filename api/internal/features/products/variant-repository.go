@@ -0,0 +1,455 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// GenerateVariants sets the parent's variant axes and creates a variant for
+// every combination of options that does not have one. Existing variants are
+// kept, so an axis or option can only be dropped once no variant uses it.
+func (repo *ProductRepository) GenerateVariants(parentId uuid.UUID, req *domain.GenerateVariantsRequest, actor domain.Actor) error {
+	if err := checkVariantAxes(req.Axes); err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var parent domain.Product
+	if err := tx.Get(&parent, `SELECT * FROM products WHERE id = $1 FOR UPDATE`, parentId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if parent.ParentId != nil {
+		_ = tx.Rollback()
+		return errors.ValidationError("A variant cannot have variants of its own")
+	}
+
+	if parent.IsBundle {
+		_ = tx.Rollback()
+		return errors.ValidationError("A bundle cannot have variants; bundle one of the variants instead")
+	}
+
+	if parent.Quantity != 0 || parent.Reserved != 0 {
+		_ = tx.Rollback()
+		return errors.ConflictError("Only products without stock on hand or reservations can get variants")
+	}
+
+	var isComponent bool
+	if err := tx.Get(&isComponent, `SELECT EXISTS (SELECT 1 FROM bundle_components WHERE product_id = $1)`, parentId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if isComponent {
+		_ = tx.Rollback()
+		return errors.ValidationError("A component of a bundle cannot have variants")
+	}
+
+	// Every existing variant must still be one of the combinations
+	existing, err := getVariantOptions(tx, parentId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	combinations := req.Combinations()
+	offered := make(map[string]bool, len(combinations))
+	for _, combination := range combinations {
+		offered[strings.Join(combination, "\x00")] = true
+	}
+
+	axisNames := make([]string, len(req.Axes))
+	for i, axis := range req.Axes {
+		axisNames[i] = axis.Name
+	}
+
+	taken := make(map[string]bool, len(existing))
+	for variantId, options := range existing {
+		values := make([]string, len(axisNames))
+		for i, name := range axisNames {
+			values[i] = options[name]
+		}
+
+		key := strings.Join(values, "\x00")
+		if len(options) != len(axisNames) || !offered[key] {
+			_ = tx.Rollback()
+			return errors.ConflictError(fmt.Sprintf(
+				"Variant %s uses an axis or option that is no longer offered; delete it first", variantId,
+			))
+		}
+		taken[key] = true
+	}
+
+	axisIds, err := saveVariantAxes(tx, parentId, req.Axes)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	missing := [][]string{}
+	variants := []*domain.Product{}
+	for _, combination := range combinations {
+		if !taken[strings.Join(combination, "\x00")] {
+			missing = append(missing, combination)
+			variants = append(variants, parent.NewVariant(combination))
+		}
+	}
+
+	if err := checkVariantSKUs(tx, &parent, variants); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for n, variant := range variants {
+		query := `
+			INSERT INTO products (
+				id, name, description, sku, quantity, restock_level, optimal_level,
+				cost, price, unit_volume, unit_weight, is_serialized, base_unit, inventory_id, parent_id, attributes, created_at, updated_at
+			) VALUES (
+				:id, :name, :description, :sku, 0, :restock_level, :optimal_level,
+				:cost, :price, :unit_volume, :unit_weight, :is_serialized, :base_unit, :inventory_id, :parent_id, :attributes, :created_at, :updated_at
+			)
+		`
+		if _, err := tx.NamedExec(query, variant); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		for i, value := range missing[n] {
+			optionQuery := `INSERT INTO product_variant_options (product_id, axis_id, value) VALUES ($1, $2, $3)`
+			if _, err := tx.Exec(optionQuery, variant.Id, axisIds[i], value); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if err := syncVariants(tx, &parent); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := recordPrices(tx, parent.Id, domain.PriceSourceCreated, nil, actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EditVariant updates the fields a variant keeps for itself, booking any
+// quantity change through the stock ledger.
+func (repo *ProductRepository) EditVariant(variant *domain.Product, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := checkProductCode(variant); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	var currentQuantity int
+	if err := tx.Get(&currentQuantity, `SELECT quantity FROM products WHERE id = $1 FOR UPDATE`, variant.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if delta := variant.Quantity - currentQuantity; delta != 0 {
+		movement := domain.NewStockMovement(variant.Id, delta,
+			domain.MovementSourceAdjustment, nil, "variant edited", actor)
+		if err := stock.ApplyCorrection(tx, movement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	query := `
+		UPDATE products SET
+			sku = :sku,
+			code = :code,
+			price = :price,
+			restock_level = :restock_level,
+			optimal_level = :optimal_level,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+	if _, err := tx.NamedExec(query, variant); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := recordPrices(tx, variant.Id, domain.PriceSourceManual, nil, actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkVariantAxes makes sure axis names, and the options on each axis, are
+// unique ignoring case.
+func checkVariantAxes(axes []domain.VariantAxisRequest) error {
+	names := make(map[string]bool, len(axes))
+	for _, axis := range axes {
+		key := strings.ToLower(axis.Name)
+		if names[key] {
+			return errors.ValidationError(fmt.Sprintf("Axis '%s' is listed twice", axis.Name))
+		}
+		names[key] = true
+
+		options := make(map[string]bool, len(axis.Options))
+		for _, option := range axis.Options {
+			key := strings.ToLower(option)
+			if options[key] {
+				return errors.ValidationError(fmt.Sprintf("Option '%s' is listed twice on axis '%s'", option, axis.Name))
+			}
+			options[key] = true
+		}
+	}
+
+	return nil
+}
+
+// checkVariantSKUs makes sure the SKUs derived for new variants fit, differ
+// from each other, and are not already used in the parent's inventory.
+func checkVariantSKUs(tx *sqlx.Tx, parent *domain.Product, variants []*domain.Product) error {
+	skus := make([]string, len(variants))
+	seen := make(map[string]bool, len(variants))
+	for i, variant := range variants {
+		if len(variant.SKU) > domain.MaxSKULength {
+			return errors.ValidationError(fmt.Sprintf(
+				"Variant SKU '%s' is longer than %d characters; shorten the parent's SKU or the options", variant.SKU, domain.MaxSKULength,
+			))
+		}
+
+		if seen[variant.SKU] {
+			return errors.ValidationError(fmt.Sprintf(
+				"More than one variant would get the SKU '%s'; make the options differ in their letters or digits", variant.SKU,
+			))
+		}
+		seen[variant.SKU] = true
+		skus[i] = variant.SKU
+	}
+
+	used := []string{}
+	query := `SELECT sku FROM products WHERE inventory_id = $1 AND sku = ANY($2) ORDER BY sku`
+	if err := tx.Select(&used, query, parent.InventoryId, pq.Array(skus)); err != nil {
+		return err
+	}
+
+	if len(used) > 0 {
+		return errors.ConflictError(fmt.Sprintf("Variant SKUs already in use: %s", strings.Join(used, ", ")))
+	}
+
+	return nil
+}
+
+// saveVariantAxes replaces the parent's axes, keeping the IDs of axes that
+// stay so existing variant options still point at them. It returns the axis
+// IDs in request order.
+func saveVariantAxes(tx *sqlx.Tx, parentId uuid.UUID, axes []domain.VariantAxisRequest) ([]uuid.UUID, error) {
+	names := make([]string, len(axes))
+	for i, axis := range axes {
+		names[i] = axis.Name
+	}
+
+	deleteQuery := `DELETE FROM product_variant_axes WHERE product_id = $1 AND NOT (name = ANY($2))`
+	if _, err := tx.Exec(deleteQuery, parentId, pq.Array(names)); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(axes))
+	for i, axis := range axes {
+		query := `
+			INSERT INTO product_variant_axes (id, product_id, name, position, options, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)
+			ON CONFLICT (product_id, name) DO UPDATE SET
+				position = EXCLUDED.position,
+				options = EXCLUDED.options,
+				updated_at = EXCLUDED.updated_at
+			RETURNING id
+		`
+		if err := tx.Get(&ids[i], query, uuid.New(), parentId, axis.Name, i, pq.Array(axis.Options), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// syncVariants copies what variants inherit from their parent onto them and
+// names each after the parent and its options, e.g. "T-Shirt - M / Red".
+func syncVariants(tx *sqlx.Tx, parent *domain.Product) error {
+	query := `
+		UPDATE products v SET
+			name = $2 || COALESCE(' - ' || (
+				SELECT string_agg(o.value, ' / ' ORDER BY a.position)
+				FROM product_variant_options o
+				JOIN product_variant_axes a ON a.id = o.axis_id
+				WHERE o.product_id = v.id
+			), ''),
+			description = $3,
+			cost = $4,
+			unit_volume = $5,
+			unit_weight = $6,
+			is_serialized = $7,
+			inventory_id = $8,
+			attributes = $9,
+			base_unit = $10,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE v.parent_id = $1
+	`
+	_, err := tx.Exec(query, parent.Id, parent.Name, parent.Description, parent.Cost,
+		parent.UnitVolume, parent.UnitWeight, parent.IsSerialized, parent.InventoryId, parent.Attributes, parent.BaseUnit)
+	return err
+}
+
+// getVariantOptions maps each of the parent's variants to its options by axis name.
+func getVariantOptions(q sqlx.Queryer, parentId uuid.UUID) (map[uuid.UUID]map[string]string, error) {
+	options := []domain.VariantOption{}
+	query := `
+		SELECT o.product_id, o.axis_id, a.name AS axis, o.value
+		FROM product_variant_options o
+		JOIN product_variant_axes a ON a.id = o.axis_id
+		WHERE a.product_id = $1
+	`
+	if err := sqlx.Select(q, &options, query, parentId); err != nil {
+		return nil, err
+	}
+
+	variants := make(map[uuid.UUID]map[string]string)
+	for _, option := range options {
+		if variants[option.ProductId] == nil {
+			variants[option.ProductId] = make(map[string]string)
+		}
+		variants[option.ProductId][option.Axis] = option.Value
+	}
+
+	return variants, nil
+}
+
+// getVariantAxes returns the axes of the given parents, grouped by parent.
+func getVariantAxes(q sqlx.Queryer, parentIds []uuid.UUID) (map[uuid.UUID][]domain.VariantAxis, error) {
+	ids := make([]string, len(parentIds))
+	for i, id := range parentIds {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT id, product_id, name, position, options, created_at, updated_at
+		FROM product_variant_axes
+		WHERE product_id = ANY($1::uuid[])
+		ORDER BY product_id, position
+	`
+	rows, err := q.Queryx(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	axes := make(map[uuid.UUID][]domain.VariantAxis)
+	for rows.Next() {
+		var axis domain.VariantAxis
+		err := rows.Scan(&axis.Id, &axis.ProductId, &axis.Name, &axis.Position,
+			pq.Array(&axis.Options), &axis.CreatedAt, &axis.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		axes[axis.ProductId] = append(axes[axis.ProductId], axis)
+	}
+
+	return axes, rows.Err()
+}
+
+// getOptionsByVariant returns the options of all variants in the inventory,
+// in axis order, grouped by variant.
+func getOptionsByVariant(q sqlx.Queryer, inventoryId uuid.UUID) (map[uuid.UUID][]domain.VariantOption, error) {
+	options := []domain.VariantOption{}
+	query := `
+		SELECT o.product_id, o.axis_id, a.name AS axis, o.value
+		FROM product_variant_options o
+		JOIN product_variant_axes a ON a.id = o.axis_id
+		JOIN products v ON v.id = o.product_id
+		WHERE v.inventory_id = $1
+		ORDER BY o.product_id, a.position
+	`
+	if err := sqlx.Select(q, &options, query, inventoryId); err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[uuid.UUID][]domain.VariantOption)
+	for _, option := range options {
+		grouped[option.ProductId] = append(grouped[option.ProductId], option)
+	}
+
+	return grouped, nil
+}
+
+// loadVariants fills in a parent's axes and variants, or a variant's options.
+func (repo *ProductRepository) loadVariants(product *domain.Product) error {
+	if product.ParentId != nil {
+		query := `
+			SELECT o.product_id, o.axis_id, a.name AS axis, o.value
+			FROM product_variant_options o
+			JOIN product_variant_axes a ON a.id = o.axis_id
+			WHERE o.product_id = $1
+			ORDER BY a.position
+		`
+		return repo.db.Select(&product.Options, query, product.Id)
+	}
+
+	axes, err := getVariantAxes(repo.db, []uuid.UUID{product.Id})
+	if err != nil {
+		return err
+	}
+	product.VariantAxes = axes[product.Id]
+
+	if len(product.VariantAxes) == 0 {
+		return nil
+	}
+
+	if err := repo.db.Select(&product.Variants, `SELECT * FROM products WHERE parent_id = $1 ORDER BY name`, product.Id); err != nil {
+		return err
+	}
+
+	options, err := getOptionsByVariant(repo.db, product.InventoryId)
+	if err != nil {
+		return err
+	}
+	for i := range product.Variants {
+		product.Variants[i].Options = options[product.Variants[i].Id]
+	}
+
+	return nil
+}
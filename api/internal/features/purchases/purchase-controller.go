@@ -0,0 +1,188 @@
+package purchases
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+type PurchaseController struct {
+	repo *PurchaseRepository
+}
+
+func NewPurchaseController(purchaseRepo *PurchaseRepository) *PurchaseController {
+	return &PurchaseController{repo: purchaseRepo}
+}
+
+func (ctrl *PurchaseController) ListPurchaseOrders(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	status := domain.PurchaseOrderStatus(ctx.QueryParam("status"))
+
+	orders, err := ctrl.repo.ListPurchaseOrders(inventoryId, status)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch purchase orders",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, orders)
+}
+
+func (ctrl *PurchaseController) GetPurchaseOrder(ctx echo.Context) error {
+	orderId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid purchase order ID"))
+	}
+
+	order, err := ctrl.repo.GetPurchaseOrder(orderId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to retrieve purchase order",
+			logger.Field{Key: "purchase_order_id", Value: orderId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, order)
+}
+
+func (ctrl *PurchaseController) CreatePurchaseOrder(ctx echo.Context) error {
+	var input domain.PurchaseOrderRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	newOrder := input.ToCreatePurchaseOrderRequest()
+
+	if err := ctrl.repo.CreatePurchaseOrder(newOrder); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create purchase order",
+			logger.Field{Key: "inventory_id", Value: newOrder.InventoryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Purchase Order"))
+	}
+
+	order, err := ctrl.repo.GetPurchaseOrder(newOrder.Id)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	logger.Info(ctx.Request().Context(), "Successfully created purchase order",
+		logger.Field{Key: "purchase_order_id", Value: order.Id})
+
+	return ctx.JSON(http.StatusCreated, order)
+}
+
+func (ctrl *PurchaseController) UpdatePurchaseOrder(ctx echo.Context) error {
+	orderId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid purchase order ID"))
+	}
+
+	var input domain.PurchaseOrderRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	existingOrder, err := ctrl.repo.GetPurchaseOrder(orderId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	updatedOrder := input.ToUpdatePurchaseOrderRequest(existingOrder)
+
+	if err := ctrl.repo.UpdatePurchaseOrder(updatedOrder); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to update purchase order",
+			logger.Field{Key: "purchase_order_id", Value: orderId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Update Purchase Order"))
+	}
+
+	order, err := ctrl.repo.GetPurchaseOrder(orderId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, order)
+}
+
+func (ctrl *PurchaseController) DeletePurchaseOrder(ctx echo.Context) error {
+	orderId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid purchase order ID"))
+	}
+
+	if err := ctrl.repo.DeletePurchaseOrder(orderId); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete purchase order",
+			logger.Field{Key: "purchase_order_id", Value: orderId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Delete Purchase Order"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (ctrl *PurchaseController) TransitionPurchaseOrder(ctx echo.Context) error {
+	orderId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid purchase order ID"))
+	}
+
+	var input domain.PurchaseOrderTransitionRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	if err := ctrl.repo.TransitionPurchaseOrder(orderId, input.Status); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to change purchase order status",
+			logger.Field{Key: "purchase_order_id", Value: orderId},
+			logger.Field{Key: "status", Value: input.Status})
+		return errors.Send(ctx, errors.DatabaseError(err, "Transition Purchase Order"))
+	}
+
+	order, err := ctrl.repo.GetPurchaseOrder(orderId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	logger.Info(ctx.Request().Context(), "Purchase order status changed",
+		logger.Field{Key: "purchase_order_id", Value: orderId},
+		logger.Field{Key: "status", Value: order.Status})
+
+	return ctx.JSON(http.StatusOK, order)
+}
+
+func (ctrl *PurchaseController) ReceivePurchaseOrder(ctx echo.Context) error {
+	orderId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid purchase order ID"))
+	}
+
+	var input domain.ReceivePurchaseOrderRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	if err := ctrl.repo.ReceivePurchaseOrder(orderId, input.Lines, input.Note, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to receive purchase order",
+			logger.Field{Key: "purchase_order_id", Value: orderId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Receive Purchase Order"))
+	}
+
+	order, err := ctrl.repo.GetPurchaseOrder(orderId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	logger.Info(ctx.Request().Context(), "Received goods against purchase order",
+		logger.Field{Key: "purchase_order_id", Value: orderId},
+		logger.Field{Key: "status", Value: order.Status})
+
+	return ctx.JSON(http.StatusOK, order)
+}
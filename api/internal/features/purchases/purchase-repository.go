@@ -0,0 +1,512 @@
+package purchases
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+type PurchaseRepository struct {
+	db *sqlx.DB
+}
+
+func NewPurchaseRepository(data *sqlx.DB) *PurchaseRepository {
+	return &PurchaseRepository{db: data}
+}
+
+// ListPurchaseOrders returns the inventory's purchase orders, optionally limited
+// to a single status.
+func (repo *PurchaseRepository) ListPurchaseOrders(inventoryId uuid.UUID, status domain.PurchaseOrderStatus) ([]domain.PurchaseOrder, error) {
+	orders := []domain.PurchaseOrder{}
+	query := `
+		SELECT * FROM purchase_orders
+		WHERE inventory_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY order_date DESC
+	`
+
+	if err := repo.db.Select(&orders, query, inventoryId, status); err != nil {
+		return nil, errors.DatabaseError(err, "List Purchase Orders")
+	}
+
+	for i := range orders {
+		lines, err := getOrderLines(repo.db, orders[i].Id)
+		if err != nil {
+			return nil, errors.DatabaseError(err, "List Purchase Orders")
+		}
+		orders[i].Lines = lines
+	}
+
+	return orders, nil
+}
+
+func (repo *PurchaseRepository) GetPurchaseOrder(orderId uuid.UUID) (*domain.PurchaseOrder, error) {
+	var order domain.PurchaseOrder
+	if err := repo.db.Get(&order, `SELECT * FROM purchase_orders WHERE id = $1`, orderId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Purchase order not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Purchase Order")
+	}
+
+	var supplier domain.Supplier
+	if err := repo.db.Get(&supplier, `SELECT * FROM suppliers WHERE id = $1`, order.SupplierId); err != nil {
+		return nil, errors.DatabaseError(err, "Get Purchase Order")
+	}
+	order.Supplier = &supplier
+
+	lines, err := getOrderLines(repo.db, orderId)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Get Purchase Order")
+	}
+	order.Lines = lines
+
+	receipts := []domain.PurchaseOrderReceipt{}
+	receiptQuery := `SELECT * FROM purchase_order_receipts WHERE purchase_order_id = $1 ORDER BY created_at`
+	if err := repo.db.Select(&receipts, receiptQuery, orderId); err != nil {
+		return nil, errors.DatabaseError(err, "Get Purchase Order")
+	}
+	order.Receipts = receipts
+
+	return &order, nil
+}
+
+func (repo *PurchaseRepository) CreatePurchaseOrder(order *domain.PurchaseOrder) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := checkOrderReferences(tx, order); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	orderQuery := `
+		INSERT INTO purchase_orders (
+			id, inventory_id, supplier_id, reference, status, order_date,
+			expected_date, note, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :supplier_id, :reference, :status, :order_date,
+			:expected_date, :note, :created_at, :updated_at
+		)
+	`
+
+	if _, err := tx.NamedExec(orderQuery, order); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := insertOrderLines(tx, order.Lines); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdatePurchaseOrder replaces the order's details and lines. Only drafts can be
+// edited; once an order has been sent its lines are fixed.
+func (repo *PurchaseRepository) UpdatePurchaseOrder(order *domain.PurchaseOrder) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockOrder(tx, order.Id)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if current.Status != domain.PurchaseOrderStatusDraft {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot edit a %s purchase order", current.Status))
+	}
+
+	if err := checkOrderReferences(tx, order); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	orderQuery := `
+		UPDATE purchase_orders SET
+			supplier_id = :supplier_id,
+			reference = :reference,
+			order_date = :order_date,
+			expected_date = :expected_date,
+			note = :note,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	if _, err := tx.NamedExec(orderQuery, order); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM purchase_order_lines WHERE purchase_order_id = $1`, order.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := insertOrderLines(tx, order.Lines); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeletePurchaseOrder removes a draft purchase order. Orders that have been sent
+// are closed instead so their receipts stay on record.
+func (repo *PurchaseRepository) DeletePurchaseOrder(orderId uuid.UUID) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockOrder(tx, orderId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if current.Status != domain.PurchaseOrderStatusDraft {
+		_ = tx.Rollback()
+		return errors.ConflictError("Only draft purchase orders can be deleted; close the order instead")
+	}
+
+	// Delete purchase order (cascade will handle lines)
+	if _, err := tx.Exec(`DELETE FROM purchase_orders WHERE id = $1`, orderId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransitionPurchaseOrder sends a draft order or closes an order, accepting any
+// outstanding under-receipts as final.
+func (repo *PurchaseRepository) TransitionPurchaseOrder(orderId uuid.UUID, next domain.PurchaseOrderStatus) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockOrder(tx, orderId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if !current.Status.CanTransitionTo(next) {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot change purchase order status from %s to %s", current.Status, next))
+	}
+
+	updateQuery := `UPDATE purchase_orders SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	if _, err := tx.Exec(updateQuery, next, orderId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReceivePurchaseOrder books received goods against the order's lines. Each
+// received quantity is added to the product's stock and, when a storage unit is
+// given, put away into that unit. Receiving more or less than ordered is
+// allowed; the difference is kept on the line as its variance.
+func (repo *PurchaseRepository) ReceivePurchaseOrder(orderId uuid.UUID, received []domain.ReceiptLineRequest, note string, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockOrder(tx, orderId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if !current.Status.CanReceive() {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot receive goods against a %s purchase order", current.Status))
+	}
+
+	lines := []domain.PurchaseOrderLine{}
+	lineQuery := `SELECT * FROM purchase_order_lines WHERE purchase_order_id = $1 FOR UPDATE`
+	if err := tx.Select(&lines, lineQuery, orderId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	linesByProduct := make(map[uuid.UUID]*domain.PurchaseOrderLine, len(lines))
+	productIds := make([]uuid.UUID, len(lines))
+	for i := range lines {
+		linesByProduct[lines[i].ProductId] = &lines[i]
+		productIds[i] = lines[i].ProductId
+	}
+
+	if err := stock.LockProducts(tx, productIds); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, item := range received {
+		line, ok := linesByProduct[item.ProductId]
+		if !ok {
+			_ = tx.Rollback()
+			return errors.ValidationError(fmt.Sprintf("Product %s is not on this purchase order", item.ProductId))
+		}
+
+		if err := receiveLine(tx, line, item, note, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	next := domain.PurchaseOrderStatusReceived
+	for _, line := range lines {
+		if line.QuantityReceived < line.QuantityOrdered {
+			next = domain.PurchaseOrderStatusPartiallyReceived
+			break
+		}
+	}
+
+	updateQuery := `UPDATE purchase_orders SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	if _, err := tx.Exec(updateQuery, next, orderId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// receiveLine books one received quantity against a line. The product must
+// already be locked.
+func receiveLine(tx *sqlx.Tx, line *domain.PurchaseOrderLine, item domain.ReceiptLineRequest, note string, actor domain.Actor) error {
+	// Everything below counts in the product's base unit
+	unit, quantity, err := stock.ToBaseUnit(tx, line.ProductId, item.Unit, item.Quantity)
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE purchase_order_lines
+		SET quantity_received = quantity_received + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING quantity_received, variance
+	`
+	if err := tx.QueryRowx(updateQuery, quantity, line.Id).Scan(&line.QuantityReceived, &line.Variance); err != nil {
+		return err
+	}
+
+	receipt := domain.NewPurchaseOrderReceipt(line, quantity, item.StorageUnitId, note, actor)
+	receipt.Unit, receipt.UnitQuantity = unit, item.Quantity
+
+	if item.Lot.LotNumber != "" {
+		lot := item.Lot.ToLot(line.ProductId)
+		if err := stock.EnsureLot(tx, lot); err != nil {
+			return err
+		}
+		if err := stock.ChangeLotQuantity(tx, lot.Id, lot.ProductId, quantity); err != nil {
+			return err
+		}
+		receipt.LotId = &lot.Id
+	}
+
+	receiptQuery := `
+		INSERT INTO purchase_order_receipts (
+			id, purchase_order_id, line_id, product_id, storage_unit_id, lot_id,
+			quantity, unit, unit_quantity, note, received_by, created_at
+		) VALUES (
+			:id, :purchase_order_id, :line_id, :product_id, :storage_unit_id, :lot_id,
+			:quantity, :unit, :unit_quantity, :note, :received_by, :created_at
+		)
+	`
+	if _, err := tx.NamedExec(receiptQuery, receipt); err != nil {
+		return err
+	}
+
+	err = stock.RegisterSerials(tx, line.ProductId, quantity, item.Serials, receipt.LotId, domain.SerialChange{
+		StorageUnitId: item.StorageUnitId,
+		SourceType:    domain.MovementSourcePurchase,
+		SourceId:      &line.PurchaseOrderId,
+		Actor:         actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	movement := domain.NewStockMovement(line.ProductId, quantity,
+		domain.MovementSourcePurchase, &line.PurchaseOrderId, "purchase order received", actor)
+	movement.StorageUnitId = item.StorageUnitId
+	// Lines ordered without a cost come in at the product's current cost
+	if line.UnitCost > 0 {
+		movement.InboundCost = &line.UnitCost
+	}
+	if err := stock.ApplyMovement(tx, movement); err != nil {
+		return err
+	}
+
+	if item.StorageUnitId != nil {
+		return stock.PutAwayLot(tx, *item.StorageUnitId, line.ProductId, receipt.LotId, quantity)
+	}
+
+	return nil
+}
+
+func getOrderLines(q sqlx.Queryer, orderId uuid.UUID) ([]domain.PurchaseOrderLine, error) {
+	lines := []domain.PurchaseOrderLine{}
+	query := `
+		SELECT l.id, l.purchase_order_id, l.product_id, l.quantity_ordered, l.quantity_received,
+			l.variance, l.unit_cost, l.created_at, l.updated_at,
+			p.id, p.name, p.description, p.sku, p.code, p.quantity, p.reserved, p.available,
+			p.restock_level, p.optimal_level, p.cost, p.price, p.inventory_id,
+			p.created_at, p.updated_at
+		FROM purchase_order_lines l
+		JOIN products p ON l.product_id = p.id
+		WHERE l.purchase_order_id = $1
+		ORDER BY p.name
+	`
+
+	rows, err := q.Queryx(query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line domain.PurchaseOrderLine
+		var product domain.Product
+
+		err := rows.Scan(
+			&line.Id, &line.PurchaseOrderId, &line.ProductId, &line.QuantityOrdered,
+			&line.QuantityReceived, &line.Variance, &line.UnitCost,
+			&line.CreatedAt, &line.UpdatedAt,
+			&product.Id, &product.Name, &product.Description, &product.SKU,
+			&product.Code, &product.Quantity, &product.Reserved, &product.Available,
+			&product.RestockLevel, &product.OptimalLevel, &product.Cost, &product.Price,
+			&product.InventoryId, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		line.Product = &product
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func insertOrderLines(tx *sqlx.Tx, lines []domain.PurchaseOrderLine) error {
+	query := `
+		INSERT INTO purchase_order_lines (
+			id, purchase_order_id, product_id, quantity_ordered, unit_cost, created_at, updated_at
+		) VALUES (
+			:id, :purchase_order_id, :product_id, :quantity_ordered, :unit_cost, :created_at, :updated_at
+		)
+	`
+
+	for _, line := range lines {
+		if _, err := tx.NamedExec(query, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOrderReferences makes sure the supplier and every product on the order
+// belong to the order's inventory, filling in catalog costs for lines without one.
+func checkOrderReferences(tx *sqlx.Tx, order *domain.PurchaseOrder) error {
+	var supplierInventory uuid.UUID
+	if err := tx.Get(&supplierInventory, `SELECT inventory_id FROM suppliers WHERE id = $1`, order.SupplierId); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Supplier not found")
+		}
+		return err
+	}
+
+	if supplierInventory != order.InventoryId {
+		return errors.ValidationError("Supplier belongs to a different inventory")
+	}
+
+	for i, line := range order.Lines {
+		var productInventory uuid.UUID
+		if err := tx.Get(&productInventory, `SELECT inventory_id FROM products WHERE id = $1`, line.ProductId); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.NotFoundError(fmt.Sprintf("Product %s not found", line.ProductId))
+			}
+			return err
+		}
+
+		if productInventory != order.InventoryId {
+			return errors.ValidationError(fmt.Sprintf("Product %s belongs to a different inventory", line.ProductId))
+		}
+
+		// Fall back to the supplier's catalog price when no cost was given
+		if line.UnitCost == 0 {
+			// Variants order from their parent's supplier catalog
+			costQuery := `
+				SELECT COALESCE(MAX(ps.unit_cost), 0)
+				FROM products p
+				JOIN product_suppliers ps ON ps.product_id = COALESCE(p.parent_id, p.id)
+				WHERE p.id = $1 AND ps.supplier_id = $2
+			`
+			if err := tx.Get(&order.Lines[i].UnitCost, costQuery, line.ProductId, order.SupplierId); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockOrder serialises concurrent changes to the same purchase order and
+// returns its current state.
+func lockOrder(tx *sqlx.Tx, orderId uuid.UUID) (*domain.PurchaseOrder, error) {
+	var order domain.PurchaseOrder
+	err := tx.Get(&order, `SELECT * FROM purchase_orders WHERE id = $1 FOR UPDATE`, orderId)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("Purchase order not found")
+	}
+	return &order, err
+}
@@ -0,0 +1,60 @@
+package purchases
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *PurchaseController) ListReorderSuggestions(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	groups, err := ctrl.repo.ListReorderSuggestions(inventoryId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to build reorder suggestions",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, groups)
+}
+
+func (ctrl *PurchaseController) CreateReorderOrders(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	var input domain.ReorderRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	orderIds, err := ctrl.repo.CreateReorderOrders(inventoryId, input.Items)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create reorder purchase orders",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Reorder Orders"))
+	}
+
+	orders := make([]*domain.PurchaseOrder, len(orderIds))
+	for i, orderId := range orderIds {
+		if orders[i], err = ctrl.repo.GetPurchaseOrder(orderId); err != nil {
+			return errors.Send(ctx, err)
+		}
+	}
+
+	logger.Info(ctx.Request().Context(), "Created reorder purchase orders",
+		logger.Field{Key: "inventory_id", Value: inventoryId},
+		logger.Field{Key: "count", Value: len(orders)})
+
+	return ctx.JSON(http.StatusCreated, orders)
+}
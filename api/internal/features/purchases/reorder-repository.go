@@ -0,0 +1,288 @@
+package purchases
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// ListReorderSuggestions returns the products that need reordering, grouped by
+// the supplier they should be bought from.
+func (repo *PurchaseRepository) ListReorderSuggestions(inventoryId uuid.UUID) ([]domain.ReorderGroup, error) {
+	suggestions, err := getReorderSuggestions(repo.db, inventoryId)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "List Reorder Suggestions")
+	}
+
+	return groupSuggestions(suggestions), nil
+}
+
+// CreateReorderOrders turns reorder suggestions into draft purchase orders, one
+// per supplier. With no items given every suggestion that has a supplier is
+// ordered; products without one are left out.
+func (repo *PurchaseRepository) CreateReorderOrders(inventoryId uuid.UUID, items []domain.ReorderItemRequest) ([]uuid.UUID, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Serialise reorder runs per inventory so two runs cannot both order the
+	// same shortfall
+	var locked uuid.UUID
+	if err := tx.Get(&locked, `SELECT id FROM inventories WHERE id = $1 FOR UPDATE`, inventoryId); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	suggestions, err := getReorderSuggestions(tx, inventoryId)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	lines, err := selectReorderLines(tx, suggestions, items)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		_ = tx.Rollback()
+		return nil, errors.ValidationError("Nothing to reorder")
+	}
+
+	orders := []*domain.PurchaseOrder{}
+	bySupplier := make(map[uuid.UUID]*domain.PurchaseOrder)
+	for _, line := range lines {
+		order, ok := bySupplier[*line.SupplierId]
+		if !ok {
+			order = &domain.PurchaseOrder{
+				Id:          uuid.New(),
+				InventoryId: inventoryId,
+				SupplierId:  *line.SupplierId,
+				Reference:   "Reorder " + time.Now().Format(time.DateOnly),
+				Status:      domain.PurchaseOrderStatusDraft,
+				OrderDate:   time.Now(),
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			bySupplier[*line.SupplierId] = order
+			orders = append(orders, order)
+		}
+
+		expected := time.Now().AddDate(0, 0, line.LeadTimeDays)
+		if order.ExpectedDate == nil || expected.After(*order.ExpectedDate) {
+			order.ExpectedDate = &expected
+		}
+
+		order.Lines = append(order.Lines, domain.PurchaseOrderLine{
+			Id:              uuid.New(),
+			PurchaseOrderId: order.Id,
+			ProductId:       line.ProductId,
+			QuantityOrdered: line.SuggestedQuantity,
+			Variance:        -line.SuggestedQuantity,
+			UnitCost:        line.UnitCost,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		})
+	}
+
+	orderQuery := `
+		INSERT INTO purchase_orders (
+			id, inventory_id, supplier_id, reference, status, order_date,
+			expected_date, note, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :supplier_id, :reference, :status, :order_date,
+			:expected_date, :note, :created_at, :updated_at
+		)
+	`
+
+	orderIds := make([]uuid.UUID, len(orders))
+	for i, order := range orders {
+		orderIds[i] = order.Id
+
+		if err := checkOrderReferences(tx, order); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		if _, err := tx.NamedExec(orderQuery, order); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		if err := insertOrderLines(tx, order.Lines); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return orderIds, tx.Commit()
+}
+
+// selectReorderLines picks the suggestions to order, applying any requested
+// quantity and supplier overrides. A line moved to another supplier is sized
+// again with that supplier's minimum order and pack size.
+func selectReorderLines(q sqlx.Queryer, suggestions []domain.ReorderSuggestion, items []domain.ReorderItemRequest) ([]domain.ReorderSuggestion, error) {
+	if len(items) == 0 {
+		lines := []domain.ReorderSuggestion{}
+		for _, suggestion := range suggestions {
+			if suggestion.SupplierId != nil {
+				lines = append(lines, suggestion)
+			}
+		}
+		return lines, nil
+	}
+
+	byProduct := make(map[uuid.UUID]domain.ReorderSuggestion, len(suggestions))
+	for _, suggestion := range suggestions {
+		byProduct[suggestion.ProductId] = suggestion
+	}
+
+	lines := make([]domain.ReorderSuggestion, 0, len(items))
+	for _, item := range items {
+		line, ok := byProduct[item.ProductId]
+		if !ok {
+			return nil, errors.ValidationError(fmt.Sprintf("Product %s does not need reordering", item.ProductId))
+		}
+
+		if item.SupplierId != nil && (line.SupplierId == nil || *item.SupplierId != *line.SupplierId) {
+			line.SupplierId = item.SupplierId
+			if err := applySupplierTerms(q, &line); err != nil {
+				return nil, err
+			}
+			line.SuggestedQuantity = line.Suggest()
+		}
+		if line.SupplierId == nil {
+			return nil, errors.ValidationError(fmt.Sprintf("No supplier known for product %s", line.SKU))
+		}
+
+		if item.Quantity > 0 {
+			line.SuggestedQuantity = item.Quantity
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// applySupplierTerms loads the line's supplier's catalog entry for the product,
+// or its parent for a variant. Without one the line keeps no minimum or pack
+// size and the order picks up a cost when it is created.
+func applySupplierTerms(q sqlx.Queryer, line *domain.ReorderSuggestion) error {
+	terms := struct {
+		UnitCost         float64 `db:"unit_cost"`
+		MinOrderQuantity int     `db:"min_order_quantity"`
+		PackSize         int     `db:"pack_size"`
+		LeadTimeDays     int     `db:"lead_time_days"`
+	}{0, 1, 1, 0}
+	query := `
+		SELECT ps.unit_cost, ps.min_order_quantity, ps.pack_size, ps.lead_time_days
+		FROM product_suppliers ps
+		JOIN products p ON ps.product_id = COALESCE(p.parent_id, p.id)
+		WHERE p.id = $1 AND ps.supplier_id = $2
+	`
+	if err := sqlx.Get(q, &terms, query, line.ProductId, *line.SupplierId); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	line.UnitCost = terms.UnitCost
+	line.MinOrderQuantity = terms.MinOrderQuantity
+	line.PackSize = terms.PackSize
+	line.LeadTimeDays = terms.LeadTimeDays
+	return nil
+}
+
+func getReorderSuggestions(q sqlx.Queryer, inventoryId uuid.UUID) ([]domain.ReorderSuggestion, error) {
+	suggestions := []domain.ReorderSuggestion{}
+	query := `
+		WITH on_order AS (
+			SELECT l.product_id, SUM(GREATEST(l.quantity_ordered - l.quantity_received, 0)) AS quantity
+			FROM purchase_order_lines l
+			JOIN purchase_orders po ON po.id = l.purchase_order_id
+			WHERE po.inventory_id = $1
+				AND po.status IN ('draft', 'ordered', 'partially_received')
+			GROUP BY l.product_id
+		), catalog AS (
+			SELECT DISTINCT ON (ps.product_id)
+				ps.product_id, ps.supplier_id, s.name AS supplier_name, ps.unit_cost,
+				ps.min_order_quantity, ps.pack_size, ps.lead_time_days
+			FROM product_suppliers ps
+			JOIN suppliers s ON s.id = ps.supplier_id
+			ORDER BY ps.product_id, ps.is_preferred DESC, ps.unit_cost ASC
+		)
+		SELECT p.id AS product_id, p.name AS product_name, p.sku, p.quantity, p.reserved, p.available,
+			COALESCE(o.quantity, 0) AS on_order, p.restock_level, p.optimal_level,
+			c.supplier_id, COALESCE(c.supplier_name, '') AS supplier_name,
+			COALESCE(c.unit_cost, p.cost) AS unit_cost,
+			COALESCE(c.min_order_quantity, 1) AS min_order_quantity,
+			COALESCE(c.pack_size, 1) AS pack_size,
+			COALESCE(c.lead_time_days, 0) AS lead_time_days
+		FROM products p
+		LEFT JOIN on_order o ON o.product_id = p.id
+		LEFT JOIN catalog c ON c.product_id = COALESCE(p.parent_id, p.id)
+		WHERE p.inventory_id = $1
+			AND NOT EXISTS (SELECT 1 FROM product_variant_axes a WHERE a.product_id = p.id)
+			AND NOT p.is_bundle
+			AND (p.restock_level > 0 OR p.optimal_level > 0)
+			AND p.available + COALESCE(o.quantity, 0) <= p.restock_level
+		ORDER BY supplier_name, p.name
+	`
+
+	if err := sqlx.Select(q, &suggestions, query, inventoryId); err != nil {
+		return nil, err
+	}
+
+	// Drop products already covered by what is on order
+	needed := suggestions[:0]
+	for _, suggestion := range suggestions {
+		if suggestion.SuggestedQuantity = suggestion.Suggest(); suggestion.SuggestedQuantity > 0 {
+			needed = append(needed, suggestion)
+		}
+	}
+
+	return needed, nil
+}
+
+func groupSuggestions(suggestions []domain.ReorderSuggestion) []domain.ReorderGroup {
+	groups := []domain.ReorderGroup{}
+	index := make(map[uuid.UUID]int)
+	unassigned := -1
+
+	for _, suggestion := range suggestions {
+		var position int
+		if suggestion.SupplierId == nil {
+			if unassigned < 0 {
+				unassigned = len(groups)
+				groups = append(groups, domain.ReorderGroup{})
+			}
+			position = unassigned
+		} else if i, ok := index[*suggestion.SupplierId]; ok {
+			position = i
+		} else {
+			position = len(groups)
+			index[*suggestion.SupplierId] = position
+			groups = append(groups, domain.ReorderGroup{
+				SupplierId:   suggestion.SupplierId,
+				SupplierName: suggestion.SupplierName,
+			})
+		}
+
+		groups[position].Items = append(groups[position].Items, suggestion)
+		groups[position].EstimatedCost += float64(suggestion.SuggestedQuantity) * suggestion.UnitCost
+	}
+
+	return groups
+}
@@ -2,11 +2,14 @@ package sales
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
 	"github.com/ventry/internal/utils"
 )
 
@@ -60,12 +63,11 @@ func (ctrl *SaleController) CreateSale(ctx echo.Context) error {
 
 	sale := input.ToSale()
 
-	err := ctrl.repo.CreateSale(sale)
+	err := ctrl.repo.CreateSale(sale, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to create sale",
-			"details": err.Error(),
-		})
+		logger.Error(ctx.Request().Context(), err, "Failed to create sale",
+			logger.Field{Key: "inventory_id", Value: sale.InventoryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Sale"))
 	}
 
 	// Fetch the complete sale with items
@@ -125,12 +127,11 @@ func (ctrl *SaleController) DeleteSale(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, "Invalid sale ID")
 	}
 
-	err = ctrl.repo.DeleteSale(saleId)
+	err = ctrl.repo.DeleteSale(saleId, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to delete sale",
-			"details": err.Error(),
-		})
+		logger.Error(ctx.Request().Context(), err, "Failed to delete sale",
+			logger.Field{Key: "sale_id", Value: saleId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Delete Sale"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
@@ -153,24 +154,25 @@ func (ctrl *SaleController) AddItemToSale(ctx echo.Context) error {
 		SaleId:    saleId,
 		ProductId: input.ProductId,
 		Quantity:  input.Quantity,
+		Unit:      strings.TrimSpace(input.Unit),
 		UnitPrice: input.UnitPrice,
+		Serials:   input.Serials,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err = ctrl.repo.AddItemToSale(item)
+	err = ctrl.repo.AddItemToSale(item, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to add item to sale",
-			"details": err.Error(),
-		})
+		logger.Error(ctx.Request().Context(), err, "Failed to add item to sale",
+			logger.Field{Key: "sale_id", Value: saleId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Add Item To Sale"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
 
 func (ctrl *SaleController) RemoveItemFromSale(ctx echo.Context) error {
-	saleId, err := uuid.Parse(ctx.Param("saleId"))
+	saleId, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		return ctx.JSON(http.StatusBadRequest, "Invalid sale ID")
 	}
@@ -180,12 +182,12 @@ func (ctrl *SaleController) RemoveItemFromSale(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, "Invalid item ID")
 	}
 
-	err = ctrl.repo.RemoveItemFromSale(saleId, itemId)
+	err = ctrl.repo.RemoveItemFromSale(saleId, itemId, utils.GetActor(ctx))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to remove item from sale",
-			"details": err.Error(),
-		})
+		logger.Error(ctx.Request().Context(), err, "Failed to remove item from sale",
+			logger.Field{Key: "sale_id", Value: saleId},
+			logger.Field{Key: "item_id", Value: itemId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Remove Item From Sale"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
@@ -1,12 +1,14 @@
 package sales
 
 import (
-	"errors"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
 )
 
 type SaleRepository struct {
@@ -59,7 +61,7 @@ func (repo *SaleRepository) GetSale(saleId uuid.UUID) (*domain.Sale, error) {
 	return &sale, nil
 }
 
-func (repo *SaleRepository) CreateSale(sale *domain.Sale) error {
+func (repo *SaleRepository) CreateSale(sale *domain.Sale, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -70,6 +72,19 @@ func (repo *SaleRepository) CreateSale(sale *domain.Sale) error {
 		}
 	}()
 
+	for i := range sale.Items {
+		if err := prepareItem(tx, &sale.Items[i]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	// Lock sold products and bundle components before checking their stock
+	if err := stock.LockProducts(tx, saleProductIds(sale.Items)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	// Insert the sale first
 	query := `
         INSERT INTO sales (
@@ -93,15 +108,20 @@ func (repo *SaleRepository) CreateSale(sale *domain.Sale) error {
 		item.SaleId = sale.Id
 		item.CreatedAt = time.Now()
 		item.UpdatedAt = time.Now()
-		item.Subtotal = float64(item.Quantity) * item.UnitPrice
+		item.Subtotal = float64(item.UnitQuantity) * item.UnitPrice
+
+		if err := deductItem(tx, item, "sale created", actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
 
 		itemQuery := `
             INSERT INTO sale_items (
-                id, sale_id, product_id, quantity,
-                unit_price, subtotal, created_at, updated_at
+                id, sale_id, product_id, quantity, unit, unit_quantity,
+                unit_price, subtotal, cogs, created_at, updated_at
             ) VALUES (
-                :id, :sale_id, :product_id, :quantity,
-                :unit_price, :subtotal, :created_at, :updated_at
+                :id, :sale_id, :product_id, :quantity, :unit, :unit_quantity,
+                :unit_price, :subtotal, :cogs, :created_at, :updated_at
             )
         `
 
@@ -109,6 +129,16 @@ func (repo *SaleRepository) CreateSale(sale *domain.Sale) error {
 			_ = tx.Rollback()
 			return err
 		}
+
+		if err := insertItemComponents(tx, item); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err := sellSerials(tx, item, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
 	}
 
 	// Update the total amount based on all items
@@ -145,7 +175,7 @@ func (repo *SaleRepository) EditSale(sale *domain.Sale) error {
 	return err
 }
 
-func (repo *SaleRepository) DeleteSale(saleId uuid.UUID) error {
+func (repo *SaleRepository) DeleteSale(saleId uuid.UUID, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -157,6 +187,37 @@ func (repo *SaleRepository) DeleteSale(saleId uuid.UUID) error {
 		}
 	}()
 
+	// Return sold quantities to stock
+	items := []domain.SaleItem{}
+	if err := tx.Select(&items, `SELECT * FROM sale_items WHERE sale_id = $1 FOR UPDATE`, saleId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for i := range items {
+		if err := tx.Select(&items[i].Components, itemComponentsQuery, items[i].Id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := stock.LockProducts(tx, saleProductIds(items)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, item := range items {
+		if err := returnItem(tx, item, "sale deleted", actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err := returnSerials(tx, item, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	if _, err := tx.Exec(`DELETE FROM sales WHERE id = $1`, saleId); err != nil {
 		_ = tx.Rollback()
 		return err
@@ -178,10 +239,26 @@ func (repo *SaleRepository) ListSaleItems(saleId uuid.UUID) ([]domain.SaleItem,
 		return nil, err
 	}
 
+	serialQuery := `
+		SELECT sn.serial_number
+		FROM sale_item_serials sis
+		JOIN serial_numbers sn ON sn.id = sis.serial_id
+		WHERE sis.sale_item_id = $1
+		ORDER BY sn.serial_number
+	`
+	for i := range items {
+		if err := repo.db.Select(&items[i].Serials, serialQuery, items[i].Id); err != nil {
+			return nil, err
+		}
+		if err := repo.db.Select(&items[i].Components, itemComponentsQuery, items[i].Id); err != nil {
+			return nil, err
+		}
+	}
+
 	return items, nil
 }
 
-func (repo *SaleRepository) AddItemToSale(item *domain.SaleItem) error {
+func (repo *SaleRepository) AddItemToSale(item *domain.SaleItem, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -193,17 +270,32 @@ func (repo *SaleRepository) AddItemToSale(item *domain.SaleItem) error {
 		}
 	}()
 
-	// Calculate subtotal
-	item.Subtotal = float64(item.Quantity) * item.UnitPrice
+	if err := prepareItem(tx, item); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := stock.LockProducts(tx, saleProductIds([]domain.SaleItem{*item})); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Calculate subtotal in the unit the item was sold in
+	item.Subtotal = float64(item.UnitQuantity) * item.UnitPrice
+
+	if err := deductItem(tx, item, "sale item added", actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
 	// Insert the sale item
 	itemQuery := `
 		INSERT INTO sale_items (
-			id, sale_id, product_id, quantity,
-			unit_price, subtotal, created_at, updated_at
+			id, sale_id, product_id, quantity, unit, unit_quantity,
+			unit_price, subtotal, cogs, created_at, updated_at
 		) VALUES (
-			:id, :sale_id, :product_id, :quantity,
-			:unit_price, :subtotal, :created_at, :updated_at
+			:id, :sale_id, :product_id, :quantity, :unit, :unit_quantity,
+			:unit_price, :subtotal, :cogs, :created_at, :updated_at
 		)
 	`
 
@@ -212,6 +304,16 @@ func (repo *SaleRepository) AddItemToSale(item *domain.SaleItem) error {
 		return err
 	}
 
+	if err := insertItemComponents(tx, item); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := sellSerials(tx, item, actor); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
 	// Update the total amount in the sale
 	updateQuery := `
 		UPDATE sales
@@ -232,7 +334,7 @@ func (repo *SaleRepository) AddItemToSale(item *domain.SaleItem) error {
 	return tx.Commit()
 }
 
-func (repo *SaleRepository) RemoveItemFromSale(saleId, itemId uuid.UUID) error {
+func (repo *SaleRepository) RemoveItemFromSale(saleId, itemId uuid.UUID, actor domain.Actor) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -244,22 +346,42 @@ func (repo *SaleRepository) RemoveItemFromSale(saleId, itemId uuid.UUID) error {
 		}
 	}()
 
-	// Delete the sale item
-	result, err := tx.Exec(`DELETE FROM sale_items WHERE id = $1 AND sale_id = $2`, itemId, saleId)
-	if err != nil {
+	// Put the item's serials back into stock before its links are deleted
+	var item domain.SaleItem
+	itemQuery := `SELECT * FROM sale_items WHERE id = $1 AND sale_id = $2 FOR UPDATE`
+	if err := tx.Get(&item, itemQuery, itemId, saleId); err != nil {
 		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Sale item not found")
+		}
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
+	if err := tx.Select(&item.Components, itemComponentsQuery, item.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := stock.LockProducts(tx, saleProductIds([]domain.SaleItem{item})); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if rows == 0 {
+	if err := returnSerials(tx, item, actor); err != nil {
 		_ = tx.Rollback()
-		return errors.New("sale item not found")
+		return err
+	}
+
+	// Delete the sale item
+	if _, err := tx.Exec(`DELETE FROM sale_items WHERE id = $1`, itemId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Return the item's quantity to stock
+	if err := returnItem(tx, item, "sale item removed", actor); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	// Update the total amount in the sale
@@ -281,3 +403,107 @@ func (repo *SaleRepository) RemoveItemFromSale(saleId, itemId uuid.UUID) error {
 
 	return tx.Commit()
 }
+
+// saleProductIds lists the sold products along with bundle components, whose
+// stock is what actually changes.
+func saleProductIds(items []domain.SaleItem) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductId)
+		for _, component := range item.Components {
+			ids = append(ids, component.ProductId)
+		}
+	}
+	return ids
+}
+
+// prepareItem converts an item's quantity from the unit it was sold in to the
+// product's base unit, keeping the original on the item, and works out the
+// component stock a bundle takes.
+func prepareItem(tx *sqlx.Tx, item *domain.SaleItem) error {
+	unit, quantity, err := stock.ToBaseUnit(tx, item.ProductId, item.Unit, item.Quantity)
+	if err != nil {
+		return err
+	}
+	item.Unit, item.UnitQuantity, item.Quantity = unit, item.Quantity, quantity
+
+	item.Components, err = stock.BundleComponents(tx, item.ProductId, item.Quantity)
+	return err
+}
+
+// deductItem takes a sold item out of stock and sets its cost of goods from
+// the movements' value. The products must already be locked.
+func deductItem(tx *sqlx.Tx, item *domain.SaleItem, reason string, actor domain.Actor) error {
+	item.Cogs = 0
+	for _, line := range item.StockLines() {
+		movement := domain.NewStockMovement(line.ProductId, -line.Quantity,
+			domain.MovementSourceSale, &item.SaleId, reason, actor)
+		if err := stock.DeductStock(tx, movement); err != nil {
+			return err
+		}
+		item.Cogs -= movement.Value
+	}
+	return nil
+}
+
+// returnItem puts a sold item back into stock. The products must already be
+// locked.
+func returnItem(tx *sqlx.Tx, item domain.SaleItem, reason string, actor domain.Actor) error {
+	for _, line := range item.StockLines() {
+		movement := domain.NewStockMovement(line.ProductId, line.Quantity,
+			domain.MovementSourceSale, &item.SaleId, reason, actor)
+		if err := stock.ReturnStock(tx, movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const itemComponentsQuery = `SELECT product_id, quantity FROM sale_item_components WHERE sale_item_id = $1 ORDER BY product_id`
+
+func insertItemComponents(tx *sqlx.Tx, item *domain.SaleItem) error {
+	query := `INSERT INTO sale_item_components (sale_item_id, product_id, quantity) VALUES ($1, $2, $3)`
+	for _, component := range item.Components {
+		if _, err := tx.Exec(query, item.Id, component.ProductId, component.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sellSerials takes a sold item's serials out of stock and links them to the
+// sale line. The product must already be locked.
+func sellSerials(tx *sqlx.Tx, item *domain.SaleItem, actor domain.Actor) error {
+	ids, err := stock.AssignSerials(tx, item.ProductId, item.Quantity, item.Serials, domain.SerialChange{
+		Event:      domain.SerialEventSold,
+		Status:     domain.SerialStatusSold,
+		SourceType: domain.MovementSourceSale,
+		SourceId:   &item.SaleId,
+		Actor:      actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`INSERT INTO sale_item_serials (sale_item_id, serial_id) VALUES ($1, $2)`, item.Id, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// returnSerials puts the serials sold on a sale line back into stock.
+func returnSerials(tx *sqlx.Tx, item domain.SaleItem, actor domain.Actor) error {
+	ids := []uuid.UUID{}
+	if err := tx.Select(&ids, `SELECT serial_id FROM sale_item_serials WHERE sale_item_id = $1`, item.Id); err != nil {
+		return err
+	}
+
+	return stock.ReturnSerials(tx, ids, domain.SerialChange{
+		SourceType: domain.MovementSourceSale,
+		SourceId:   &item.SaleId,
+		Actor:      actor,
+	})
+}
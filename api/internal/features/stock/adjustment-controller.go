@@ -0,0 +1,168 @@
+package stock
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *StockController) ListAdjustmentReasons(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	reasons, err := ctrl.repo.ListAdjustmentReasons(inventoryId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch adjustment reasons",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, reasons)
+}
+
+func (ctrl *StockController) CreateAdjustmentReason(ctx echo.Context) error {
+	var input domain.AdjustmentReasonRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	reason := input.ToCreateAdjustmentReasonRequest()
+
+	if err := ctrl.repo.CreateAdjustmentReason(reason); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create adjustment reason",
+			logger.Field{Key: "inventory_id", Value: reason.InventoryId},
+			logger.Field{Key: "code", Value: reason.Code})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, reason)
+}
+
+func (ctrl *StockController) EditAdjustmentReason(ctx echo.Context) error {
+	reasonId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid adjustment reason ID"))
+	}
+
+	var input domain.AdjustmentReasonRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	existingReason, err := ctrl.repo.GetAdjustmentReason(reasonId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	updatedReason := input.ToUpdateAdjustmentReasonRequest(existingReason)
+
+	if err := ctrl.repo.EditAdjustmentReason(updatedReason); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to update adjustment reason",
+			logger.Field{Key: "reason_id", Value: reasonId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, updatedReason)
+}
+
+func (ctrl *StockController) DeleteAdjustmentReason(ctx echo.Context) error {
+	reasonId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid adjustment reason ID"))
+	}
+
+	if err := ctrl.repo.DeleteAdjustmentReason(reasonId); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete adjustment reason",
+			logger.Field{Key: "reason_id", Value: reasonId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (ctrl *StockController) ListAdjustments(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	filter, err := parseDateFilter(ctx)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	adjustments, err := ctrl.repo.ListAdjustments(inventoryId, ctx.QueryParam("reason"), filter)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch stock adjustments",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, adjustments)
+}
+
+func (ctrl *StockController) AdjustStock(ctx echo.Context) error {
+	var input domain.StockAdjustmentRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	adjustment := input.ToStockAdjustment(utils.GetActor(ctx))
+
+	if err := ctrl.repo.AdjustStock(adjustment, input.ReasonCode, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to adjust stock",
+			logger.Field{Key: "product_id", Value: adjustment.ProductId},
+			logger.Field{Key: "reason", Value: input.ReasonCode})
+		return errors.Send(ctx, errors.DatabaseError(err, "Adjust Stock"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Adjusted stock",
+		logger.Field{Key: "product_id", Value: adjustment.ProductId},
+		logger.Field{Key: "delta", Value: adjustment.Delta},
+		logger.Field{Key: "reason", Value: adjustment.ReasonCode})
+
+	return ctx.JSON(http.StatusCreated, adjustment)
+}
+
+func (ctrl *StockController) ShrinkageReport(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	filter, err := parseDateFilter(ctx)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	entries, err := ctrl.repo.ShrinkageReport(inventoryId, filter)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to build shrinkage report",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, entries)
+}
+
+func parseDateFilter(ctx echo.Context) (domain.MovementFilter, error) {
+	var filter domain.MovementFilter
+	var err error
+	if filter.From, err = utils.ParseDateParam(ctx.QueryParam("from"), false); err != nil {
+		return filter, errors.ValidationError("Invalid 'from' date")
+	}
+	if filter.To, err = utils.ParseDateParam(ctx.QueryParam("to"), true); err != nil {
+		return filter, errors.ValidationError("Invalid 'to' date")
+	}
+	return filter, nil
+}
@@ -0,0 +1,316 @@
+package stock
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+func (repo *StockRepository) ListAdjustmentReasons(inventoryId uuid.UUID) ([]domain.AdjustmentReason, error) {
+	reasons := []domain.AdjustmentReason{}
+	query := `SELECT * FROM adjustment_reasons WHERE inventory_id = $1 ORDER BY code`
+
+	if err := repo.db.Select(&reasons, query, inventoryId); err != nil {
+		return nil, errors.DatabaseError(err, "List Adjustment Reasons")
+	}
+
+	return reasons, nil
+}
+
+func (repo *StockRepository) GetAdjustmentReason(reasonId uuid.UUID) (*domain.AdjustmentReason, error) {
+	var reason domain.AdjustmentReason
+	if err := repo.db.Get(&reason, `SELECT * FROM adjustment_reasons WHERE id = $1`, reasonId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Adjustment reason not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Adjustment Reason")
+	}
+
+	return &reason, nil
+}
+
+func (repo *StockRepository) CreateAdjustmentReason(reason *domain.AdjustmentReason) error {
+	if err := InsertAdjustmentReason(repo.db, reason); err != nil {
+		return errors.DatabaseError(err, "Create Adjustment Reason")
+	}
+
+	return nil
+}
+
+// EditAdjustmentReason updates a reason code. The direction of a code that has
+// been used is kept, so past adjustments still match the code they report
+// against.
+func (repo *StockRepository) EditAdjustmentReason(reason *domain.AdjustmentReason) error {
+	var current struct {
+		Direction domain.AdjustmentDirection `db:"direction"`
+		Used      bool                       `db:"used"`
+	}
+	currentQuery := `
+		SELECT r.direction, EXISTS (SELECT 1 FROM stock_adjustments a WHERE a.reason_id = r.id) AS used
+		FROM adjustment_reasons r WHERE r.id = $1
+	`
+	if err := repo.db.Get(&current, currentQuery, reason.Id); err != nil {
+		return errors.DatabaseError(err, "Edit Adjustment Reason")
+	}
+
+	if current.Used && current.Direction != reason.Direction {
+		return errors.ConflictError("Cannot change the direction of a reason code that has been used")
+	}
+
+	query := `
+		UPDATE adjustment_reasons SET
+			code = :code,
+			name = :name,
+			direction = :direction,
+			is_shrinkage = :is_shrinkage,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	if _, err := repo.db.NamedExec(query, reason); err != nil {
+		return errors.DatabaseError(err, "Edit Adjustment Reason")
+	}
+
+	return nil
+}
+
+// DeleteAdjustmentReason removes a reason code. Codes that have been used are
+// kept so past adjustments still report against them.
+func (repo *StockRepository) DeleteAdjustmentReason(reasonId uuid.UUID) error {
+	var used int
+	if err := repo.db.Get(&used, `SELECT COUNT(*) FROM stock_adjustments WHERE reason_id = $1`, reasonId); err != nil {
+		return errors.DatabaseError(err, "Delete Adjustment Reason")
+	}
+
+	if used > 0 {
+		return errors.ConflictError("Cannot delete a reason code that has been used")
+	}
+
+	if _, err := repo.db.Exec(`DELETE FROM adjustment_reasons WHERE id = $1`, reasonId); err != nil {
+		return errors.DatabaseError(err, "Delete Adjustment Reason")
+	}
+
+	return nil
+}
+
+// InsertAdjustmentReason stores a reason code. It is shared with inventory
+// creation, which seeds the default codes.
+func InsertAdjustmentReason(exec sqlx.Ext, reason *domain.AdjustmentReason) error {
+	query := `
+		INSERT INTO adjustment_reasons (
+			id, inventory_id, code, name, direction, is_shrinkage, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :code, :name, :direction, :is_shrinkage, :created_at, :updated_at
+		)
+	`
+	_, err := sqlx.NamedExec(exec, query, reason)
+	return err
+}
+
+// ListAdjustments returns the inventory's adjustments in the filter's date
+// range, optionally limited to one reason code.
+func (repo *StockRepository) ListAdjustments(inventoryId uuid.UUID, reasonCode string, filter domain.MovementFilter) ([]domain.StockAdjustment, error) {
+	adjustments := []domain.StockAdjustment{}
+	query := `
+		SELECT sa.*, ar.code AS reason_code
+		FROM stock_adjustments sa
+		JOIN adjustment_reasons ar ON ar.id = sa.reason_id
+		WHERE sa.inventory_id = $1
+			AND ($2 = '' OR ar.code = $2)
+			AND ($3::timestamptz IS NULL OR sa.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR sa.created_at < $4)
+		ORDER BY sa.created_at DESC
+	`
+	if err := repo.db.Select(&adjustments, query, inventoryId, reasonCode, filter.From, filter.To); err != nil {
+		return nil, errors.DatabaseError(err, "List Adjustments")
+	}
+
+	return adjustments, nil
+}
+
+// AdjustStock changes a product's stock by a signed delta for a reason code,
+// moving the difference in or out of a storage unit and lot when given. Like a
+// sale, a decrease may not exceed the product's unreserved stock, and
+// serialized products register or write off the serials adjusted.
+func (repo *StockRepository) AdjustStock(adjustment *domain.StockAdjustment, reasonCode string, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := LockProducts(tx, []uuid.UUID{adjustment.ProductId}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	productQuery := `SELECT inventory_id FROM products WHERE id = $1`
+	if err := tx.Get(&adjustment.InventoryId, productQuery, adjustment.ProductId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	var reason domain.AdjustmentReason
+	reasonQuery := `SELECT * FROM adjustment_reasons WHERE inventory_id = $1 AND code = $2`
+	if err := tx.Get(&reason, reasonQuery, adjustment.InventoryId, reasonCode); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.ValidationError(fmt.Sprintf("Unknown reason code '%s'", reasonCode))
+		}
+		return err
+	}
+
+	if !reason.Direction.Allows(adjustment.Delta) {
+		_ = tx.Rollback()
+		return errors.ValidationError(fmt.Sprintf("Reason '%s' only allows stock to %s", reason.Code, reason.Direction))
+	}
+
+	adjustment.ReasonId = reason.Id
+	adjustment.ReasonCode = reason.Code
+
+	// A write-off may not take stock already held for pending deliveries
+	if adjustment.Delta < 0 {
+		if err := checkAvailability(tx, adjustment.ProductId, -adjustment.Delta); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	// Stock found without a lot is booked to the product's unassigned lot
+	if adjustment.LotId == nil && adjustment.Delta > 0 {
+		lotId, err := unassignedLot(tx, adjustment.ProductId)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		adjustment.LotId = lotId
+	}
+
+	if adjustment.LotId != nil {
+		if err := ChangeLotQuantity(tx, *adjustment.LotId, adjustment.ProductId, adjustment.Delta); err != nil {
+			_ = tx.Rollback()
+			if err == sql.ErrNoRows {
+				return errors.NotFoundError("Lot not found")
+			}
+			return err
+		}
+	}
+
+	movement := domain.NewStockMovement(adjustment.ProductId, adjustment.Delta,
+		domain.MovementSourceAdjustment, &adjustment.Id, reason.Code, actor)
+	movement.StorageUnitId = adjustment.StorageUnitId
+
+	if adjustment.StorageUnitId != nil {
+		if err := adjustUnit(tx, adjustment); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	} else if adjustment.LotId == nil && adjustment.Delta < 0 {
+		if err := writeOffLots(tx, movement, -adjustment.Delta); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	// Serialized stock only changes together with the items' serial numbers
+	change := domain.SerialChange{
+		StorageUnitId: adjustment.StorageUnitId,
+		SourceType:    domain.MovementSourceAdjustment,
+		SourceId:      &adjustment.Id,
+		Actor:         actor,
+	}
+	if adjustment.Delta > 0 {
+		err = RegisterSerials(tx, adjustment.ProductId, adjustment.Delta, adjustment.Serials, adjustment.LotId, change)
+	} else {
+		err = writeOffSerials(tx, adjustment.ProductId, -adjustment.Delta, adjustment.Serials, adjustment.StorageUnitId, change)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// The movement values the adjustment, so apply it before recording the cost
+	if err := ApplyMovement(tx, movement); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	adjustment.UnitCost = movement.UnitCost
+
+	query := `
+		INSERT INTO stock_adjustments (
+			id, inventory_id, product_id, storage_unit_id, lot_id, reason_id, delta, unit_cost, note, user_id, created_at
+		) VALUES (
+			:id, :inventory_id, :product_id, :storage_unit_id, :lot_id, :reason_id, :delta, :unit_cost, :note, :user_id, :created_at
+		)
+	`
+	if _, err := tx.NamedExec(query, adjustment); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// adjustUnit books an adjustment's delta against its storage unit, and against
+// the adjusted lot within the unit when there is one. Without a lot, a decrease
+// also comes out of the lots the unit's stock belonged to.
+func adjustUnit(tx *sqlx.Tx, adjustment *domain.StockAdjustment) error {
+	unitId := *adjustment.StorageUnitId
+
+	if adjustment.Delta > 0 {
+		return PutAwayLot(tx, unitId, adjustment.ProductId, adjustment.LotId, adjustment.Delta)
+	}
+
+	if err := LockUnits(tx, []uuid.UUID{unitId}); err != nil {
+		return err
+	}
+
+	if adjustment.LotId != nil {
+		return TakeLotFromUnit(tx, unitId, adjustment.ProductId, *adjustment.LotId, -adjustment.Delta)
+	}
+
+	taken, err := TakeFromUnit(tx, unitId, adjustment.ProductId, -adjustment.Delta)
+	if err != nil {
+		return err
+	}
+
+	return takeUnitLots(tx, adjustment.ProductId, taken)
+}
+
+// ShrinkageReport totals the inventory's adjustments per reason code over the
+// filter's date range. Reasons without adjustments are listed with zeroes.
+func (repo *StockRepository) ShrinkageReport(inventoryId uuid.UUID, filter domain.MovementFilter) ([]domain.ShrinkageEntry, error) {
+	entries := []domain.ShrinkageEntry{}
+	query := `
+		SELECT ar.id AS reason_id, ar.code, ar.name, ar.is_shrinkage,
+			COUNT(sa.id) AS adjustments,
+			COALESCE(SUM(-sa.delta) FILTER (WHERE sa.delta < 0), 0) AS quantity_lost,
+			COALESCE(SUM(sa.delta) FILTER (WHERE sa.delta > 0), 0) AS quantity_found,
+			COALESCE(SUM(sa.delta), 0) AS net_quantity,
+			COALESCE(SUM(sa.delta * sa.unit_cost), 0) AS net_value
+		FROM adjustment_reasons ar
+		LEFT JOIN stock_adjustments sa ON sa.reason_id = ar.id
+			AND ($2::timestamptz IS NULL OR sa.created_at >= $2)
+			AND ($3::timestamptz IS NULL OR sa.created_at < $3)
+		WHERE ar.inventory_id = $1
+		GROUP BY ar.id
+		ORDER BY ar.is_shrinkage DESC, net_value
+	`
+	if err := repo.db.Select(&entries, query, inventoryId, filter.From, filter.To); err != nil {
+		return nil, errors.DatabaseError(err, "Shrinkage Report")
+	}
+
+	return entries, nil
+}
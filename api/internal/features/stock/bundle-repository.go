@@ -0,0 +1,28 @@
+package stock
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+)
+
+// BundleComponents returns the component stock a quantity of bundles takes,
+// or nil when the product is not a bundle.
+func BundleComponents(q sqlx.Queryer, productId uuid.UUID, quantity int) ([]domain.LineComponent, error) {
+	components := []domain.LineComponent{}
+	query := `
+		SELECT product_id, quantity * $2 AS quantity
+		FROM bundle_components
+		WHERE bundle_id = $1
+		ORDER BY product_id
+	`
+	if err := sqlx.Select(q, &components, query, productId, quantity); err != nil {
+		return nil, err
+	}
+
+	if len(components) == 0 {
+		return nil, nil
+	}
+
+	return components, nil
+}
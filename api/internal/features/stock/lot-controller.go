@@ -0,0 +1,54 @@
+package stock
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+)
+
+// defaultExpiryWindow is how many days ahead the expiring-soon report looks
+// when no window is given.
+const defaultExpiryWindow = 30
+
+func (ctrl *StockController) ListProductLots(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	lots, err := ctrl.repo.ListProductLots(productId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch product lots",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, lots)
+}
+
+func (ctrl *StockController) ListExpiringLots(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	days := defaultExpiryWindow
+	if value := ctx.QueryParam("days"); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days < 0 {
+			return errors.Send(ctx, errors.ValidationError("Invalid 'days' value"))
+		}
+	}
+
+	lots, err := ctrl.repo.ListExpiringLots(inventoryId, days)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch expiring lots",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, lots)
+}
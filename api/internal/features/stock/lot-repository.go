@@ -0,0 +1,320 @@
+package stock
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+func (repo *StockRepository) ListProductLots(productId uuid.UUID) ([]domain.Lot, error) {
+	lots := []domain.Lot{}
+	query := `
+		SELECT * FROM lots
+		WHERE product_id = $1
+		ORDER BY expires_on ASC NULLS LAST, created_at
+	`
+	if err := repo.db.Select(&lots, query, productId); err != nil {
+		return nil, errors.DatabaseError(err, "List Product Lots")
+	}
+
+	return lots, nil
+}
+
+// ListExpiringLots returns the inventory's lots with stock left that expire
+// within the given number of days, including those already expired.
+func (repo *StockRepository) ListExpiringLots(inventoryId uuid.UUID, days int) ([]domain.ExpiringLot, error) {
+	lots := []domain.ExpiringLot{}
+	query := `
+		SELECT l.*, p.name AS product_name, p.sku,
+			l.expires_on - CURRENT_DATE AS days_left,
+			l.expires_on < CURRENT_DATE AS expired
+		FROM lots l
+		JOIN products p ON p.id = l.product_id
+		WHERE p.inventory_id = $1
+			AND l.quantity > 0
+			AND l.expires_on <= CURRENT_DATE + $2::int
+		ORDER BY l.expires_on, p.name
+	`
+	if err := repo.db.Select(&lots, query, inventoryId, days); err != nil {
+		return nil, errors.DatabaseError(err, "List Expiring Lots")
+	}
+
+	return lots, nil
+}
+
+// EnsureLot loads the product's lot with the given number into lot, creating
+// it when it is new. Dates missing on an existing lot are filled in from lot.
+func EnsureLot(tx *sqlx.Tx, lot *domain.Lot) error {
+	query := `
+		INSERT INTO lots (
+			id, product_id, lot_number, manufactured_on, expires_on, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (product_id, lot_number) DO UPDATE SET
+			manufactured_on = COALESCE(lots.manufactured_on, EXCLUDED.manufactured_on),
+			expires_on = COALESCE(lots.expires_on, EXCLUDED.expires_on),
+			updated_at = EXCLUDED.updated_at
+		RETURNING *
+	`
+	return tx.Get(lot, query, lot.Id, lot.ProductId, lot.LotNumber,
+		lot.ManufacturedOn, lot.ExpiresOn, lot.CreatedAt, lot.UpdatedAt)
+}
+
+// ChangeLotQuantity moves a lot's share of stock by delta, refusing to take
+// more than the lot holds. The product total must be changed separately.
+func ChangeLotQuantity(tx *sqlx.Tx, lotId, productId uuid.UUID, delta int) error {
+	var lot domain.Lot
+	if err := tx.Get(&lot, `SELECT * FROM lots WHERE id = $1 FOR UPDATE`, lotId); err != nil {
+		return err
+	}
+
+	if lot.ProductId != productId {
+		return errors.ValidationError("Lot belongs to a different product")
+	}
+
+	if lot.Quantity+delta < 0 {
+		return errors.ConflictError(fmt.Sprintf(
+			"Insufficient quantity in lot %s: %d available, %d requested", lot.LotNumber, lot.Quantity, -delta,
+		))
+	}
+
+	updateQuery := `UPDATE lots SET quantity = quantity + $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err := tx.Exec(updateQuery, delta, lotId)
+	return err
+}
+
+// unassignedLot returns the lot that stock of a lot-tracked product goes to
+// when it arrives without one, creating it on first use. It returns nil for
+// products that do not track lots.
+func unassignedLot(tx *sqlx.Tx, productId uuid.UUID) (*uuid.UUID, error) {
+	var tracked bool
+	if err := tx.Get(&tracked, `SELECT EXISTS (SELECT 1 FROM lots WHERE product_id = $1)`, productId); err != nil {
+		return nil, err
+	}
+
+	if !tracked {
+		return nil, nil
+	}
+
+	lot := domain.Lot{
+		Id:        uuid.New(),
+		ProductId: productId,
+		LotNumber: domain.UnassignedLotNumber,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := EnsureLot(tx, &lot); err != nil {
+		return nil, err
+	}
+
+	return &lot.Id, nil
+}
+
+// takeUnitLots reduces the lots that stock taken out of a storage unit with
+// TakeFromUnit belonged to.
+func takeUnitLots(tx *sqlx.Tx, productId uuid.UUID, taken []domain.LotQuantity) error {
+	for _, part := range taken {
+		if part.LotId == nil {
+			continue
+		}
+		if err := ChangeLotQuantity(tx, *part.LotId, productId, -part.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocateLots takes quantity from the product's lots first-expired-first-out
+// and records which lots the movement's source drew from. Expired lots are
+// skipped; if only expired stock could cover the quantity the allocation is
+// refused unless the inventory allows backorders. Anything the lots cannot
+// cover comes from untracked stock, and beyond that is backordered from the
+// unassigned lot.
+func allocateLots(tx *sqlx.Tx, movement *domain.StockMovement, quantity int) error {
+	return drawLots(tx, movement, quantity, true)
+}
+
+// writeOffLots takes quantity that leaves without naming a lot, such as a
+// write-off, from the product's lots earliest-expiring first. Expired lots are
+// not skipped, as they are usually what is being written off.
+func writeOffLots(tx *sqlx.Tx, movement *domain.StockMovement, quantity int) error {
+	return drawLots(tx, movement, quantity, false)
+}
+
+func drawLots(tx *sqlx.Tx, movement *domain.StockMovement, quantity int, skipExpired bool) error {
+	lots := []struct {
+		domain.Lot
+		Expired bool `db:"expired"`
+	}{}
+	query := `
+		SELECT *, COALESCE(expires_on < CURRENT_DATE, false) AS expired
+		FROM lots
+		WHERE product_id = $1 AND quantity > 0
+		ORDER BY expires_on ASC NULLS LAST, created_at
+		FOR UPDATE
+	`
+	if err := tx.Select(&lots, query, movement.ProductId); err != nil {
+		return err
+	}
+
+	if len(lots) == 0 {
+		return nil
+	}
+
+	var untracked int
+	untrackedQuery := `
+		SELECT p.quantity - COALESCE((SELECT SUM(quantity) FROM lots WHERE product_id = p.id), 0)
+		FROM products p WHERE p.id = $1
+	`
+	if err := tx.Get(&untracked, untrackedQuery, movement.ProductId); err != nil {
+		return err
+	}
+
+	remaining, expired := quantity, 0
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+
+		if lot.Expired && skipExpired {
+			expired += lot.Quantity
+			continue
+		}
+
+		take := lot.Quantity
+		if take > remaining {
+			take = remaining
+		}
+
+		if err := ChangeLotQuantity(tx, lot.Id, lot.ProductId, -take); err != nil {
+			return err
+		}
+
+		if err := recordLotAllocation(tx, movement, lot.Id, take); err != nil {
+			return err
+		}
+
+		remaining -= take
+	}
+
+	shortfall := remaining - max(untracked, 0)
+	if shortfall <= 0 {
+		return nil
+	}
+
+	if expired > 0 {
+		var allowBackorders bool
+		backorderQuery := `
+			SELECT i.allow_backorders FROM products p
+			JOIN inventories i ON i.id = p.inventory_id
+			WHERE p.id = $1
+		`
+		if err := tx.Get(&allowBackorders, backorderQuery, movement.ProductId); err != nil {
+			return err
+		}
+
+		if !allowBackorders {
+			return errors.ConflictError(fmt.Sprintf(
+				"Insufficient unexpired stock: %d requested, %d of the remaining stock is past its expiry date",
+				quantity, expired,
+			))
+		}
+	}
+
+	// The lots sum to the product's stock only if the backordered shortfall is
+	// owed by one of them; the unassigned lot goes negative until stock without
+	// a lot arrives, and a return gives the shortfall back to it
+	lotId, err := unassignedLot(tx, movement.ProductId)
+	if err != nil {
+		return err
+	}
+
+	backorderQuery := `UPDATE lots SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	if _, err := tx.Exec(backorderQuery, shortfall, *lotId); err != nil {
+		return err
+	}
+
+	return recordLotAllocation(tx, movement, *lotId, shortfall)
+}
+
+// recordLotAllocation records that the movement's source drew quantity from a
+// lot, for movements that have a source to return it to.
+func recordLotAllocation(tx *sqlx.Tx, movement *domain.StockMovement, lotId uuid.UUID, quantity int) error {
+	if movement.SourceId == nil {
+		return nil
+	}
+
+	allocation := domain.LotAllocation{
+		Id:         uuid.New(),
+		LotId:      lotId,
+		ProductId:  movement.ProductId,
+		SourceType: movement.SourceType,
+		SourceId:   *movement.SourceId,
+		Quantity:   quantity,
+		CreatedAt:  movement.CreatedAt,
+	}
+	query := `
+		INSERT INTO lot_allocations (
+			id, lot_id, product_id, source_type, source_id, quantity, created_at
+		) VALUES (
+			:id, :lot_id, :product_id, :source_type, :source_id, :quantity, :created_at
+		)
+	`
+	_, err := tx.NamedExec(query, allocation)
+	return err
+}
+
+// releaseLotAllocations returns up to quantity of what the movement's source
+// allocated from lots, most recent allocations first.
+func releaseLotAllocations(tx *sqlx.Tx, movement *domain.StockMovement, quantity int) error {
+	if movement.SourceId == nil {
+		return nil
+	}
+
+	allocations := []domain.LotAllocation{}
+	query := `
+		SELECT * FROM lot_allocations
+		WHERE source_type = $1 AND source_id = $2 AND product_id = $3
+		ORDER BY created_at DESC
+		FOR UPDATE
+	`
+	if err := tx.Select(&allocations, query, movement.SourceType, *movement.SourceId, movement.ProductId); err != nil {
+		return err
+	}
+
+	remaining := quantity
+	for _, allocation := range allocations {
+		if remaining == 0 {
+			break
+		}
+
+		give := allocation.Quantity
+		if give > remaining {
+			give = remaining
+		}
+
+		if err := ChangeLotQuantity(tx, allocation.LotId, allocation.ProductId, give); err != nil {
+			return err
+		}
+
+		if give == allocation.Quantity {
+			if _, err := tx.Exec(`DELETE FROM lot_allocations WHERE id = $1`, allocation.Id); err != nil {
+				return err
+			}
+		} else {
+			updateQuery := `UPDATE lot_allocations SET quantity = quantity - $1 WHERE id = $2`
+			if _, err := tx.Exec(updateQuery, give, allocation.Id); err != nil {
+				return err
+			}
+		}
+
+		remaining -= give
+	}
+
+	return nil
+}
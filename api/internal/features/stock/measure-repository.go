@@ -0,0 +1,46 @@
+package stock
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// ToBaseUnit converts a quantity counted in one of the product's units into
+// its base unit. An empty unit means the base unit. It returns the unit's name
+// as configured so lines display it consistently.
+func ToBaseUnit(q sqlx.Queryer, productId uuid.UUID, unit string, quantity int) (string, int, error) {
+	var conversion struct {
+		BaseUnit string         `db:"base_unit"`
+		Name     sql.NullString `db:"name"`
+		Factor   sql.NullInt64  `db:"factor"`
+	}
+	// Variants count in their parent's units
+	query := `
+		SELECT p.base_unit, u.name, u.factor
+		FROM products p
+		LEFT JOIN product_units u ON u.product_id = COALESCE(p.parent_id, p.id)
+			AND lower(u.name) = lower($2)
+		WHERE p.id = $1
+	`
+	if err := sqlx.Get(q, &conversion, query, productId, unit); err != nil {
+		if err == sql.ErrNoRows {
+			return "", 0, errors.NotFoundError("Product not found")
+		}
+		return "", 0, err
+	}
+
+	if unit == "" || strings.EqualFold(unit, conversion.BaseUnit) {
+		return conversion.BaseUnit, quantity, nil
+	}
+
+	if !conversion.Name.Valid {
+		return "", 0, errors.ValidationError(fmt.Sprintf("Unknown unit '%s' for product %s", unit, productId))
+	}
+
+	return conversion.Name.String, quantity * int(conversion.Factor.Int64), nil
+}
@@ -0,0 +1,113 @@
+package stock
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// testDB connects to the migrated database named by TEST_DATABASE_URL, skipping
+// the test when it is not set.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// seedUnits creates a product counted in pieces and sold in boxes of 12, with
+// one variant, all removed again when the test ends.
+func seedUnits(t *testing.T, db *sqlx.DB) (productId, variantId uuid.UUID) {
+	t.Helper()
+
+	userId, inventoryId := uuid.New(), uuid.New()
+	productId, variantId = uuid.New(), uuid.New()
+	suffix := userId.String()[:8]
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`INSERT INTO users (id, username, email, password) VALUES ($1, $2, $3, 'x')`,
+			[]interface{}{userId, "measure-test-" + suffix, "measure-test-" + suffix + "@example.com"}},
+		{`INSERT INTO inventories (id, name, user_id) VALUES ($1, 'Measure test', $2)`,
+			[]interface{}{inventoryId, userId}},
+		{`INSERT INTO products (id, name, sku, base_unit, inventory_id) VALUES ($1, 'Screws', $2, 'piece', $3)`,
+			[]interface{}{productId, "MT-" + suffix, inventoryId}},
+		{`INSERT INTO products (id, name, sku, base_unit, inventory_id, parent_id) VALUES ($1, 'Screws M4', $2, 'piece', $3, $4)`,
+			[]interface{}{variantId, "MT-" + suffix + "-M4", inventoryId, productId}},
+		{`INSERT INTO product_units (id, product_id, name, factor) VALUES ($1, $2, 'Box', 12)`,
+			[]interface{}{uuid.New(), productId}},
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement.query, statement.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM users WHERE id = $1`, userId); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+
+	return productId, variantId
+}
+
+func TestToBaseUnit(t *testing.T) {
+	db := testDB(t)
+	productId, variantId := seedUnits(t, db)
+
+	tests := []struct {
+		name         string
+		productId    uuid.UUID
+		unit         string
+		quantity     int
+		wantUnit     string
+		wantQuantity int
+		wantCode     int
+	}{
+		{"no unit is the base unit", productId, "", 5, "piece", 5, 0},
+		{"base unit in another case", productId, "PIECE", 5, "piece", 5, 0},
+		{"pack unit", productId, "Box", 5, "Box", 60, 0},
+		{"pack unit in another case", productId, "box", 2, "Box", 24, 0},
+		{"variant uses its parent's units", variantId, "Box", 3, "Box", 36, 0},
+		{"unknown unit", productId, "Pallet", 1, "", 0, 400},
+		{"unknown product", uuid.New(), "", 1, "", 0, 404},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unit, quantity, err := ToBaseUnit(db, test.productId, test.unit, test.quantity)
+			if test.wantCode != 0 {
+				appErr, ok := err.(*errors.AppError)
+				if !ok || appErr.Code != test.wantCode {
+					t.Fatalf("ToBaseUnit(%q) error = %v, want code %d", test.unit, err, test.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToBaseUnit(%q): %v", test.unit, err)
+			}
+
+			if unit != test.wantUnit || quantity != test.wantQuantity {
+				t.Errorf("ToBaseUnit(%q, %d) = %q, %d, want %q, %d",
+					test.unit, test.quantity, unit, quantity, test.wantUnit, test.wantQuantity)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package stock
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// ReconcileInventory reports every product in the inventory whose on-hand total
+// differs from the stock located in its storage units.
+func (repo *StockRepository) ReconcileInventory(inventoryId uuid.UUID) ([]domain.ReconciliationEntry, error) {
+	entries, err := reconcile(repo.db, inventoryId, nil)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Reconcile Inventory")
+	}
+
+	return entries, nil
+}
+
+// FixReconciliation resolves the differences for the given products, or for
+// every product out of balance when none are given, and returns what was fixed.
+// In adjustment mode the product total is set to the located stock. In
+// unlocated mode surplus stock is booked into the inventory's unlocated storage
+// and any shortfall is first taken back out of it, with the rest adjusted.
+func (repo *StockRepository) FixReconciliation(inventoryId uuid.UUID, mode domain.ReconciliationFixMode, productIds []uuid.UUID, actor domain.Actor) ([]domain.ReconciliationEntry, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Serialise fixes per inventory so the unlocated storage is only created once
+	var locked uuid.UUID
+	if err := tx.Get(&locked, `SELECT id FROM inventories WHERE id = $1 FOR UPDATE`, inventoryId); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	entries, err := reconcile(tx, inventoryId, productIds)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	affected := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		affected[i] = entry.ProductId
+	}
+
+	if err := LockProducts(tx, affected); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Read the balances again now that nothing else can change them
+	if entries, err = reconcile(tx, inventoryId, affected); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	var bucket uuid.UUID
+	for _, entry := range entries {
+		adjustment := -entry.Difference
+
+		if mode == domain.ReconciliationFixUnlocated {
+			if bucket == uuid.Nil {
+				if bucket, err = unlocatedUnit(tx, inventoryId); err != nil {
+					_ = tx.Rollback()
+					return nil, err
+				}
+			}
+
+			if adjustment, err = rebalanceUnlocated(tx, bucket, entry); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+		}
+
+		if adjustment != 0 {
+			movement := domain.NewStockMovement(entry.ProductId, adjustment,
+				domain.MovementSourceAdjustment, nil, "reconciliation", actor)
+			if err := ApplyCorrection(tx, movement); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	return entries, tx.Commit()
+}
+
+// rebalanceUnlocated moves the entry's difference in or out of the unlocated
+// unit and returns whatever still has to be adjusted on the product total.
+func rebalanceUnlocated(tx *sqlx.Tx, unitId uuid.UUID, entry domain.ReconciliationEntry) (int, error) {
+	if entry.Difference > 0 {
+		return 0, PutAway(tx, unitId, entry.ProductId, entry.Difference)
+	}
+
+	var held int
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM unit_items WHERE storage_unit_id = $1 AND product_id = $2`
+	if err := tx.Get(&held, query, unitId, entry.ProductId); err != nil {
+		return 0, err
+	}
+
+	surplus := -entry.Difference
+	take := min(held, surplus)
+	if take > 0 {
+		if _, err := TakeFromUnit(tx, unitId, entry.ProductId, take); err != nil {
+			return 0, err
+		}
+	}
+
+	return surplus - take, nil
+}
+
+// unlocatedUnit returns the unit of the inventory's unlocated storage, creating
+// the storage on first use. The storage is found by its flag rather than its
+// name, which only has to avoid the names of the inventory's own storages.
+func unlocatedUnit(tx *sqlx.Tx, inventoryId uuid.UUID) (uuid.UUID, error) {
+	var unitId uuid.UUID
+	query := `
+		SELECT su.id FROM storage_units su
+		JOIN storages s ON s.id = su.storage_id
+		WHERE s.inventory_id = $1 AND s.is_unlocated
+		ORDER BY su.created_at
+		LIMIT 1
+	`
+	err := tx.Get(&unitId, query, inventoryId)
+	if err != sql.ErrNoRows {
+		return unitId, err
+	}
+
+	name, err := unlocatedStorageName(tx, inventoryId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	storage := domain.Storage{
+		Id:          uuid.New(),
+		Name:        name,
+		Location:    name,
+		Capacity:    1,
+		InventoryId: inventoryId,
+		IsUnlocated: true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	storageQuery := `
+		INSERT INTO storages (
+			id, name, location, capacity, inventory_id, is_unlocated, created_at, updated_at
+		) VALUES (
+			:id, :name, :location, :capacity, :inventory_id, :is_unlocated, :created_at, :updated_at
+		)
+	`
+	if _, err := tx.NamedExec(storageQuery, storage); err != nil {
+		return uuid.Nil, err
+	}
+
+	unit := domain.StorageUnit{
+		Id:        uuid.New(),
+		Name:      "Unlocated",
+		Label:     "Unlocated",
+		StorageId: storage.Id,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	unitQuery := `
+		INSERT INTO storage_units (
+			id, name, label, storage_id, is_occupied, created_at, updated_at
+		) VALUES (
+			:id, :name, :label, :storage_id, :is_occupied, :created_at, :updated_at
+		)
+	`
+	if _, err := tx.NamedExec(unitQuery, unit); err != nil {
+		return uuid.Nil, err
+	}
+
+	return unit.Id, nil
+}
+
+// unlocatedStorageName picks a name for the unlocated storage that neither the
+// name nor the location of an existing storage in the inventory already uses.
+func unlocatedStorageName(tx *sqlx.Tx, inventoryId uuid.UUID) (string, error) {
+	name := "Unlocated"
+	query := `SELECT EXISTS (SELECT 1 FROM storages WHERE inventory_id = $1 AND (name = $2 OR location = $2))`
+	for n := 2; ; n++ {
+		var taken bool
+		if err := tx.Get(&taken, query, inventoryId, name); err != nil {
+			return "", err
+		}
+		if !taken {
+			return name, nil
+		}
+		name = fmt.Sprintf("Unlocated %d", n)
+	}
+}
+
+// reconcile compares product totals with located stock, optionally limited to
+// the given products, and attaches the per-storage breakdown.
+func reconcile(q sqlx.Queryer, inventoryId uuid.UUID, productIds []uuid.UUID) ([]domain.ReconciliationEntry, error) {
+	entries := []domain.ReconciliationEntry{}
+	query := `
+		SELECT p.id AS product_id, p.name AS product_name, p.sku,
+			p.quantity AS on_hand,
+			COALESCE(SUM(ui.quantity), 0) AS located,
+			p.quantity - COALESCE(SUM(ui.quantity), 0) AS difference
+		FROM products p
+		LEFT JOIN unit_items ui ON ui.product_id = p.id
+		WHERE p.inventory_id = $1
+			AND (cardinality($2::uuid[]) = 0 OR p.id = ANY($2::uuid[]))
+		GROUP BY p.id
+		HAVING p.quantity <> COALESCE(SUM(ui.quantity), 0)
+		ORDER BY p.name
+	`
+	if err := sqlx.Select(q, &entries, query, inventoryId, uuidArray(productIds)); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ProductId
+	}
+
+	located := []domain.LocatedStock{}
+	breakdownQuery := `
+		SELECT ui.product_id, s.id AS storage_id, s.name AS storage_name, SUM(ui.quantity) AS quantity
+		FROM unit_items ui
+		JOIN storage_units su ON su.id = ui.storage_unit_id
+		JOIN storages s ON s.id = su.storage_id
+		WHERE ui.product_id = ANY($1::uuid[])
+		GROUP BY ui.product_id, s.id, s.name
+		ORDER BY s.name
+	`
+	if err := sqlx.Select(q, &located, breakdownQuery, uuidArray(ids)); err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[uuid.UUID][]domain.LocatedStock, len(entries))
+	for _, location := range located {
+		byProduct[location.ProductId] = append(byProduct[location.ProductId], location)
+	}
+
+	for i := range entries {
+		entries[i].Storages = byProduct[entries[i].ProductId]
+		if entries[i].Storages == nil {
+			entries[i].Storages = []domain.LocatedStock{}
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,153 @@
+package stock
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/logger"
+)
+
+// Reserve holds stock for a delivery. The product must already be locked with
+// LockProducts.
+func Reserve(tx *sqlx.Tx, reservation *domain.StockReservation) error {
+	if err := checkAvailability(tx, reservation.ProductId, reservation.Quantity); err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE products
+		SET reserved = reserved + $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(updateQuery, reservation.Quantity, reservation.ProductId); err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO stock_reservations (
+			id, product_id, delivery_id, quantity, status, expires_at, created_at, updated_at
+		) VALUES (
+			:id, :product_id, :delivery_id, :quantity, :status, :expires_at, :created_at, :updated_at
+		)
+	`
+	_, err := tx.NamedExec(insertQuery, reservation)
+	return err
+}
+
+// ReleaseReservations closes every active reservation of a delivery with the
+// given status and gives the held quantity back to the products. The products
+// must already be locked with LockProducts.
+func ReleaseReservations(tx *sqlx.Tx, deliveryId uuid.UUID, status domain.ReservationStatus) error {
+	reservations := []domain.StockReservation{}
+	query := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $2 AND status = $3
+		RETURNING *
+	`
+	if err := tx.Select(&reservations, query, status, deliveryId, domain.ReservationStatusActive); err != nil {
+		return err
+	}
+
+	return releaseReserved(tx, reservations)
+}
+
+func releaseReserved(tx *sqlx.Tx, reservations []domain.StockReservation) error {
+	updateQuery := `
+		UPDATE products
+		SET reserved = GREATEST(reserved - $1, 0),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+	for _, reservation := range reservations {
+		if _, err := tx.Exec(updateQuery, reservation.Quantity, reservation.ProductId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpireReservations releases every active reservation whose expiry has passed
+// and returns how many were released.
+func (repo *StockRepository) ExpireReservations() (int, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	productIds := []uuid.UUID{}
+	selectQuery := `
+		SELECT DISTINCT product_id FROM stock_reservations
+		WHERE status = $1 AND expires_at <= CURRENT_TIMESTAMP
+	`
+	if err := tx.Select(&productIds, selectQuery, domain.ReservationStatusActive); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	if len(productIds) == 0 {
+		return 0, tx.Rollback()
+	}
+
+	if err := LockProducts(tx, productIds); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	reservations := []domain.StockReservation{}
+	expireQuery := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE status = $2 AND expires_at <= CURRENT_TIMESTAMP
+			AND product_id = ANY($3::uuid[])
+		RETURNING *
+	`
+	if err := tx.Select(&reservations, expireQuery,
+		domain.ReservationStatusExpired, domain.ReservationStatusActive, uuidArray(productIds),
+	); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	if err := releaseReserved(tx, reservations); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	return len(reservations), tx.Commit()
+}
+
+// RunReservationExpiry releases expired reservations every interval until the
+// context is cancelled.
+func (repo *StockRepository) RunReservationExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := repo.ExpireReservations()
+			if err != nil {
+				logger.Error(ctx, err, "Failed to expire stock reservations")
+				continue
+			}
+			if count > 0 {
+				logger.Info(ctx, "Expired stock reservations",
+					logger.Field{Key: "count", Value: count})
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package stock
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+)
+
+func (ctrl *StockController) LookupSerial(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	serialNumber := ctx.Param("serial")
+	if serialNumber == "" {
+		return errors.Send(ctx, errors.ValidationError("Serial number is required"))
+	}
+
+	serials, err := ctrl.repo.LookupSerial(inventoryId, serialNumber)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to look up serial number",
+			logger.Field{Key: "inventory_id", Value: inventoryId},
+			logger.Field{Key: "serial_number", Value: serialNumber})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, serials)
+}
+
+func (ctrl *StockController) ListProductSerials(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	status := domain.SerialStatus(ctx.QueryParam("status"))
+	switch status {
+	case "", domain.SerialStatusInStock, domain.SerialStatusReserved, domain.SerialStatusSold, domain.SerialStatusShipped,
+		domain.SerialStatusWrittenOff:
+	default:
+		return errors.Send(ctx, errors.ValidationError("Invalid 'status' value"))
+	}
+
+	serials, err := ctrl.repo.ListProductSerials(productId, status)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch product serials",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, serials)
+}
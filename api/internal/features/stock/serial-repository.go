@@ -0,0 +1,264 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// LookupSerial finds every item in the inventory carrying the serial number,
+// across products, with its full history.
+func (repo *StockRepository) LookupSerial(inventoryId uuid.UUID, serialNumber string) ([]domain.SerialNumber, error) {
+	serials := []domain.SerialNumber{}
+	query := `
+		SELECT sn.* FROM serial_numbers sn
+		JOIN products p ON p.id = sn.product_id
+		WHERE p.inventory_id = $1 AND sn.serial_number = $2
+		ORDER BY p.name
+	`
+	if err := repo.db.Select(&serials, query, inventoryId, serialNumber); err != nil {
+		return nil, errors.DatabaseError(err, "Lookup Serial")
+	}
+
+	if len(serials) == 0 {
+		return nil, errors.NotFoundError("Serial number not found")
+	}
+
+	eventQuery := `SELECT * FROM serial_events WHERE serial_id = $1 ORDER BY created_at`
+	for i := range serials {
+		serials[i].Events = []domain.SerialEvent{}
+		if err := repo.db.Select(&serials[i].Events, eventQuery, serials[i].Id); err != nil {
+			return nil, errors.DatabaseError(err, "Lookup Serial")
+		}
+	}
+
+	return serials, nil
+}
+
+// ListProductSerials returns the product's serialized items, optionally
+// limited to one status.
+func (repo *StockRepository) ListProductSerials(productId uuid.UUID, status domain.SerialStatus) ([]domain.SerialNumber, error) {
+	serials := []domain.SerialNumber{}
+	query := `
+		SELECT * FROM serial_numbers
+		WHERE product_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY serial_number
+	`
+	if err := repo.db.Select(&serials, query, productId, status); err != nil {
+		return nil, errors.DatabaseError(err, "List Product Serials")
+	}
+
+	return serials, nil
+}
+
+// RegisterSerials records newly received items of a serialized product, in
+// stock at the given unit. Products that are not serialized take no serials.
+func RegisterSerials(tx *sqlx.Tx, productId uuid.UUID, quantity int, serials []string, lotId *uuid.UUID, change domain.SerialChange) error {
+	serialized, err := checkSerials(tx, productId, quantity, serials)
+	if err != nil || !serialized {
+		return err
+	}
+
+	change.Event = domain.SerialEventReceived
+	change.Status = domain.SerialStatusInStock
+
+	query := `
+		INSERT INTO serial_numbers (
+			id, product_id, serial_number, status, storage_unit_id, lot_id, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		)
+		ON CONFLICT (product_id, serial_number) DO NOTHING
+	`
+	ids := make([]uuid.UUID, len(serials))
+	for i, serial := range serials {
+		ids[i] = uuid.New()
+		result, err := tx.Exec(query, ids[i], productId, serial, change.Status, change.StorageUnitId, lotId)
+		if err != nil {
+			return err
+		}
+
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errors.ConflictError(fmt.Sprintf("Serial number %s is already registered", serial))
+		}
+	}
+
+	return recordSerialEvents(tx, ids, change)
+}
+
+// AssignSerials takes the given in-stock serials out of stock for a sale or
+// delivery and returns their IDs so the caller can link them to its line.
+// Products that are not serialized take no serials and return none.
+func AssignSerials(tx *sqlx.Tx, productId uuid.UUID, quantity int, serials []string, change domain.SerialChange) ([]uuid.UUID, error) {
+	serialized, err := checkSerials(tx, productId, quantity, serials)
+	if err != nil || !serialized {
+		return nil, err
+	}
+
+	ids, err := lockSerials(tx, productId, serials, domain.SerialStatusInStock, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, UpdateSerials(tx, ids, change)
+}
+
+// writeOffSerials takes the given in-stock serials out of stock for good, from
+// the given storage unit when one is set. Products that are not serialized
+// take no serials.
+func writeOffSerials(tx *sqlx.Tx, productId uuid.UUID, quantity int, serials []string, unitId *uuid.UUID, change domain.SerialChange) error {
+	serialized, err := checkSerials(tx, productId, quantity, serials)
+	if err != nil || !serialized {
+		return err
+	}
+
+	ids, err := lockSerials(tx, productId, serials, domain.SerialStatusInStock, unitId)
+	if err != nil {
+		return err
+	}
+
+	change.Event = domain.SerialEventWrittenOff
+	change.Status = domain.SerialStatusWrittenOff
+	change.StorageUnitId = nil
+
+	return UpdateSerials(tx, ids, change)
+}
+
+// MoveSerials relocates in-stock serials from one storage unit to another.
+func MoveSerials(tx *sqlx.Tx, productId uuid.UUID, quantity int, serials []string, fromUnitId uuid.UUID, change domain.SerialChange) error {
+	serialized, err := checkSerials(tx, productId, quantity, serials)
+	if err != nil || !serialized {
+		return err
+	}
+
+	ids, err := lockSerials(tx, productId, serials, domain.SerialStatusInStock, &fromUnitId)
+	if err != nil {
+		return err
+	}
+
+	change.Event = domain.SerialEventMoved
+	change.Status = domain.SerialStatusInStock
+
+	return UpdateSerials(tx, ids, change)
+}
+
+// UpdateSerials moves serials to the change's status and location, holding
+// them against its source document, and records the event.
+func UpdateSerials(tx *sqlx.Tx, ids []uuid.UUID, change domain.SerialChange) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE serial_numbers
+		SET status = $1, storage_unit_id = $2, source_type = $3, source_id = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($5::uuid[])
+	`
+	if _, err := tx.Exec(query, change.Status, change.StorageUnitId, change.SourceType, change.SourceId, uuidArray(ids)); err != nil {
+		return err
+	}
+
+	return recordSerialEvents(tx, ids, change)
+}
+
+// ReturnSerials puts serials back into stock, without a known location.
+func ReturnSerials(tx *sqlx.Tx, ids []uuid.UUID, change domain.SerialChange) error {
+	change.Event = domain.SerialEventReturned
+	change.Status = domain.SerialStatusInStock
+	change.StorageUnitId = nil
+
+	return UpdateSerials(tx, ids, change)
+}
+
+// checkSerials makes sure a line of a serialized product names exactly one
+// distinct serial per item, and that other products name none.
+func checkSerials(tx *sqlx.Tx, productId uuid.UUID, quantity int, serials []string) (bool, error) {
+	var serialized bool
+	if err := tx.Get(&serialized, `SELECT is_serialized FROM products WHERE id = $1`, productId); err != nil {
+		return false, err
+	}
+
+	if !serialized {
+		if len(serials) > 0 {
+			return false, errors.ValidationError("Serial numbers were given for a product that is not serialized")
+		}
+		return false, nil
+	}
+
+	if len(serials) != quantity {
+		return true, errors.ValidationError(fmt.Sprintf(
+			"Serialized product needs %d serial numbers, %d given", quantity, len(serials),
+		))
+	}
+
+	seen := make(map[string]bool, len(serials))
+	for _, serial := range serials {
+		if strings.TrimSpace(serial) == "" {
+			return true, errors.ValidationError("Serial numbers cannot be empty")
+		}
+		if seen[serial] {
+			return true, errors.ValidationError(fmt.Sprintf("Serial number %s is listed twice", serial))
+		}
+		seen[serial] = true
+	}
+
+	return true, nil
+}
+
+// lockSerials locks the product's serials and checks they are all in the
+// expected status, and in the given unit when one is set.
+func lockSerials(tx *sqlx.Tx, productId uuid.UUID, serials []string, status domain.SerialStatus, unitId *uuid.UUID) ([]uuid.UUID, error) {
+	found := []domain.SerialNumber{}
+	query := `
+		SELECT * FROM serial_numbers
+		WHERE product_id = $1 AND serial_number = ANY($2)
+		ORDER BY id
+		FOR UPDATE
+	`
+	if err := tx.Select(&found, query, productId, pq.Array(serials)); err != nil {
+		return nil, err
+	}
+
+	bySerial := make(map[string]domain.SerialNumber, len(found))
+	for _, serial := range found {
+		bySerial[serial.SerialNumber] = serial
+	}
+
+	ids := make([]uuid.UUID, len(serials))
+	for i, number := range serials {
+		serial, ok := bySerial[number]
+		switch {
+		case !ok:
+			return nil, errors.NotFoundError(fmt.Sprintf("Serial number %s is not registered for this product", number))
+		case serial.Status != status:
+			return nil, errors.ConflictError(fmt.Sprintf("Serial number %s is %s", number, serial.Status))
+		case unitId != nil && (serial.StorageUnitId == nil || *serial.StorageUnitId != *unitId):
+			return nil, errors.ConflictError(fmt.Sprintf("Serial number %s is not in the source storage unit", number))
+		}
+		ids[i] = serial.Id
+	}
+
+	return ids, nil
+}
+
+func recordSerialEvents(tx *sqlx.Tx, ids []uuid.UUID, change domain.SerialChange) error {
+	query := `
+		INSERT INTO serial_events (
+			id, serial_id, event, status, storage_unit_id, source_type, source_id, user_id, request_id, created_at
+		) VALUES (
+			:id, :serial_id, :event, :status, :storage_unit_id, :source_type, :source_id, :user_id, :request_id, :created_at
+		)
+	`
+	for _, id := range ids {
+		if _, err := tx.NamedExec(query, domain.NewSerialEvent(id, change)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package stock
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+type StockController struct {
+	repo *StockRepository
+}
+
+func NewStockController(stockRepo *StockRepository) *StockController {
+	return &StockController{repo: stockRepo}
+}
+
+func (ctrl *StockController) ListProductMovements(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	filter, err := parseDateFilter(ctx)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	movements, err := ctrl.repo.ListProductMovements(productId, filter)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch stock movements",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, movements)
+}
+
+func (ctrl *StockController) ReconcileInventory(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	entries, err := ctrl.repo.ReconcileInventory(inventoryId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to reconcile inventory",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, entries)
+}
+
+func (ctrl *StockController) FixReconciliation(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	var input domain.ReconciliationFixRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	fixed, err := ctrl.repo.FixReconciliation(inventoryId, input.Mode, input.ProductIds, utils.GetActor(ctx))
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fix reconciliation differences",
+			logger.Field{Key: "inventory_id", Value: inventoryId},
+			logger.Field{Key: "mode", Value: input.Mode})
+		return errors.Send(ctx, errors.DatabaseError(err, "Fix Reconciliation"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Fixed reconciliation differences",
+		logger.Field{Key: "inventory_id", Value: inventoryId},
+		logger.Field{Key: "mode", Value: input.Mode},
+		logger.Field{Key: "count", Value: len(fixed)})
+
+	return ctx.JSON(http.StatusOK, fixed)
+}
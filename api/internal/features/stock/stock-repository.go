@@ -0,0 +1,392 @@
+package stock
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+type StockRepository struct {
+	db *sqlx.DB
+}
+
+func NewStockRepository(db *sqlx.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+func (repo *StockRepository) ListProductMovements(productId uuid.UUID, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	movements := []domain.StockMovement{}
+	query := `
+		SELECT * FROM stock_movements
+		WHERE product_id = $1
+			AND ($2::timestamptz IS NULL OR created_at >= $2)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY created_at DESC
+	`
+
+	if err := repo.db.Select(&movements, query, productId, filter.From, filter.To); err != nil {
+		return nil, errors.DatabaseError(err, "List Product Movements")
+	}
+
+	return movements, nil
+}
+
+// LockProducts takes row locks on the given products in a deterministic order so
+// that concurrent stock changes touching the same products cannot deadlock.
+func LockProducts(tx *sqlx.Tx, productIds []uuid.UUID) error {
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	query := `SELECT id FROM products WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`
+	locked := []uuid.UUID{}
+	return tx.Select(&locked, query, uuidArray(productIds))
+}
+
+// uuidArray converts ids to a Postgres array parameter, dropping duplicates.
+func uuidArray(ids []uuid.UUID) interface{} {
+	values := make([]string, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			values = append(values, id.String())
+		}
+	}
+	return pq.Array(values)
+}
+
+// ApplyMovement changes the product's quantity by movement.Delta, values the
+// change against the product's cost layers and records the movement in the
+// ledger. It must run inside the transaction making the change. Products with
+// variants and bundles hold no stock themselves and are refused.
+func ApplyMovement(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	updateQuery := `
+		UPDATE products
+		SET quantity = quantity + $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING quantity, EXISTS (SELECT 1 FROM product_variant_axes a WHERE a.product_id = products.id), is_bundle
+	`
+	var hasVariants, isBundle bool
+	if err := tx.QueryRowx(updateQuery, movement.Delta, movement.ProductId).Scan(&movement.Balance, &hasVariants, &isBundle); err != nil {
+		return err
+	}
+
+	if hasVariants {
+		return errors.ValidationError("Stock is kept on a product's variants, not on the product itself")
+	}
+
+	if isBundle {
+		return errors.ValidationError("Stock is kept on a bundle's components, not on the bundle itself")
+	}
+
+	if err := applyCost(tx, movement); err != nil {
+		return err
+	}
+
+	return recordMovement(tx, movement)
+}
+
+// DeductStock applies a negative movement, refusing it when it exceeds the
+// product's unreserved stock and its inventory does not allow backorders. The
+// quantity is allocated to the product's lots first-expired-first-out.
+// Callers must lock the product with LockProducts first so the check cannot race.
+func DeductStock(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	if err := checkAvailability(tx, movement.ProductId, -movement.Delta); err != nil {
+		return err
+	}
+
+	if err := allocateLots(tx, movement, -movement.Delta); err != nil {
+		return err
+	}
+
+	return ApplyMovement(tx, movement)
+}
+
+// ReturnStock reverses stock taken with DeductStock for the same source,
+// putting it back into the lots it was allocated from at the cost it left at.
+func ReturnStock(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	if err := releaseLotAllocations(tx, movement, movement.Delta); err != nil {
+		return err
+	}
+
+	if err := returnCost(tx, movement); err != nil {
+		return err
+	}
+
+	return ApplyMovement(tx, movement)
+}
+
+// ApplyCorrection applies a movement that names neither a lot nor a storage
+// unit, such as an edited quantity, keeping a lot-tracked product's lots in
+// step with its stock: a decrease comes out of the lots earliest-expiring
+// first and an increase goes to the unassigned lot. Serialized products are
+// refused, as their stock only changes together with serial numbers.
+func ApplyCorrection(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	var serialized bool
+	if err := tx.Get(&serialized, `SELECT is_serialized FROM products WHERE id = $1`, movement.ProductId); err != nil {
+		return err
+	}
+
+	if serialized {
+		return errors.ValidationError("The stock of a serialized product cannot be edited directly; adjust it with the serial numbers instead")
+	}
+
+	if movement.Delta < 0 {
+		if err := writeOffLots(tx, movement, -movement.Delta); err != nil {
+			return err
+		}
+	} else {
+		lotId, err := unassignedLot(tx, movement.ProductId)
+		if err != nil {
+			return err
+		}
+		if lotId != nil {
+			if err := ChangeLotQuantity(tx, *lotId, movement.ProductId, movement.Delta); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ApplyMovement(tx, movement)
+}
+
+// checkAvailability fails when quantity exceeds the product's unreserved stock
+// and its inventory does not allow backorders.
+func checkAvailability(tx *sqlx.Tx, productId uuid.UUID, quantity int) error {
+	var product struct {
+		Name            string `db:"name"`
+		Available       int    `db:"available"`
+		AllowBackorders bool   `db:"allow_backorders"`
+	}
+	query := `
+		SELECT p.name, p.available, i.allow_backorders
+		FROM products p
+		JOIN inventories i ON i.id = p.inventory_id
+		WHERE p.id = $1
+	`
+	if err := tx.Get(&product, query, productId); err != nil {
+		return err
+	}
+
+	if !product.AllowBackorders && product.Available < quantity {
+		return errors.ConflictError(fmt.Sprintf(
+			"Insufficient stock for %s: %d available, %d requested",
+			product.Name, product.Available, quantity,
+		))
+	}
+
+	return nil
+}
+
+// PutAway places quantity of a product into a storage unit belonging to the
+// product's inventory. A unit may hold several products, but the result must
+// fit its capacity. It only books the location; the product total must be
+// changed separately with ApplyMovement.
+func PutAway(tx *sqlx.Tx, unitId, productId uuid.UUID, quantity int) error {
+	return PutAwayLot(tx, unitId, productId, nil, quantity)
+}
+
+// PutAwayLot is PutAway for stock of a particular lot, or untracked stock when
+// lotId is nil.
+func PutAwayLot(tx *sqlx.Tx, unitId, productId uuid.UUID, lotId *uuid.UUID, quantity int) error {
+	var sameInventory bool
+	checkQuery := `
+		SELECT s.inventory_id = p.inventory_id
+		FROM storage_units su
+		JOIN storages s ON s.id = su.storage_id
+		JOIN products p ON p.id = $2
+		WHERE su.id = $1
+		FOR UPDATE OF su
+	`
+	if err := tx.Get(&sameInventory, checkQuery, unitId, productId); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Storage unit not found")
+		}
+		return err
+	}
+
+	if !sameInventory {
+		return errors.ValidationError("Storage unit belongs to a different inventory")
+	}
+
+	item := domain.UnitItem{
+		Id:            uuid.New(),
+		StorageUnitId: unitId,
+		ProductId:     productId,
+		LotId:         lotId,
+		Quantity:      quantity,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	query := `
+		INSERT INTO unit_items (
+			id, storage_unit_id, product_id, lot_id, quantity, created_at, updated_at
+		) VALUES (
+			:id, :storage_unit_id, :product_id, :lot_id, :quantity, :created_at, :updated_at
+		)
+		ON CONFLICT (storage_unit_id, product_id, COALESCE(lot_id, '00000000-0000-0000-0000-000000000000'))
+		DO UPDATE SET quantity = unit_items.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.NamedExec(query, item); err != nil {
+		return err
+	}
+
+	return checkUnitCapacity(tx, unitId)
+}
+
+// checkUnitCapacity fails when a unit's contents exceed any of its limits.
+func checkUnitCapacity(tx *sqlx.Tx, unitId uuid.UUID) error {
+	var usage struct {
+		CapacityCount  *int     `db:"capacity_count"`
+		CapacityVolume *float64 `db:"capacity_volume"`
+		CapacityWeight *float64 `db:"capacity_weight"`
+		Count          int      `db:"count"`
+		Volume         float64  `db:"volume"`
+		Weight         float64  `db:"weight"`
+	}
+	query := `
+		SELECT su.capacity_count, su.capacity_volume, su.capacity_weight,
+			COALESCE(SUM(ui.quantity), 0) AS count,
+			COALESCE(SUM(ui.quantity * p.unit_volume), 0) AS volume,
+			COALESCE(SUM(ui.quantity * p.unit_weight), 0) AS weight
+		FROM storage_units su
+		LEFT JOIN unit_items ui ON ui.storage_unit_id = su.id
+		LEFT JOIN products p ON p.id = ui.product_id
+		WHERE su.id = $1
+		GROUP BY su.id
+	`
+	if err := tx.Get(&usage, query, unitId); err != nil {
+		return err
+	}
+
+	switch {
+	case usage.CapacityCount != nil && usage.Count > *usage.CapacityCount:
+		return errors.ConflictError(fmt.Sprintf(
+			"Storage unit capacity exceeded: %d items, limit %d", usage.Count, *usage.CapacityCount,
+		))
+	case usage.CapacityVolume != nil && usage.Volume > *usage.CapacityVolume:
+		return errors.ConflictError(fmt.Sprintf(
+			"Storage unit capacity exceeded: volume %.3f, limit %.3f", usage.Volume, *usage.CapacityVolume,
+		))
+	case usage.CapacityWeight != nil && usage.Weight > *usage.CapacityWeight:
+		return errors.ConflictError(fmt.Sprintf(
+			"Storage unit capacity exceeded: weight %.3f, limit %.3f", usage.Weight, *usage.CapacityWeight,
+		))
+	}
+
+	return nil
+}
+
+// TakeFromUnit removes quantity of a product from a storage unit, refusing to
+// take more than the unit holds. When the unit holds several lots of the
+// product the earliest expiring go first; the lots taken are returned so they
+// can be put away elsewhere. Like PutAway it leaves the product total alone.
+func TakeFromUnit(tx *sqlx.Tx, unitId, productId uuid.UUID, quantity int) ([]domain.LotQuantity, error) {
+	return takeFromUnit(tx, unitId, productId, nil, quantity)
+}
+
+// TakeLotFromUnit removes quantity of one lot of a product from a storage unit.
+func TakeLotFromUnit(tx *sqlx.Tx, unitId, productId, lotId uuid.UUID, quantity int) error {
+	_, err := takeFromUnit(tx, unitId, productId, &lotId, quantity)
+	return err
+}
+
+func takeFromUnit(tx *sqlx.Tx, unitId, productId uuid.UUID, lotId *uuid.UUID, quantity int) ([]domain.LotQuantity, error) {
+	rows := []struct {
+		Id       uuid.UUID  `db:"id"`
+		LotId    *uuid.UUID `db:"lot_id"`
+		Quantity int        `db:"quantity"`
+	}{}
+	query := `
+		SELECT ui.id, ui.lot_id, ui.quantity
+		FROM unit_items ui
+		LEFT JOIN lots l ON l.id = ui.lot_id
+		WHERE ui.storage_unit_id = $1 AND ui.product_id = $2
+			AND ($3::uuid IS NULL OR ui.lot_id = $3)
+		ORDER BY l.expires_on ASC NULLS LAST, l.created_at, ui.created_at
+		FOR UPDATE OF ui
+	`
+	if err := tx.Select(&rows, query, unitId, productId, lotId); err != nil {
+		return nil, err
+	}
+
+	current := 0
+	for _, row := range rows {
+		current += row.Quantity
+	}
+
+	if current < quantity {
+		return nil, errors.ConflictError(fmt.Sprintf(
+			"Insufficient quantity in storage unit: %d available, %d requested", current, quantity,
+		))
+	}
+
+	taken := []domain.LotQuantity{}
+	remaining := quantity
+	for _, row := range rows {
+		if remaining == 0 {
+			break
+		}
+
+		take := row.Quantity
+		if take > remaining {
+			take = remaining
+		}
+
+		if take == row.Quantity {
+			if _, err := tx.Exec(`DELETE FROM unit_items WHERE id = $1`, row.Id); err != nil {
+				return nil, err
+			}
+		} else {
+			updateQuery := `UPDATE unit_items SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+			if _, err := tx.Exec(updateQuery, take, row.Id); err != nil {
+				return nil, err
+			}
+		}
+
+		taken = append(taken, domain.LotQuantity{LotId: row.LotId, Quantity: take})
+		remaining -= take
+	}
+
+	return taken, nil
+}
+
+// LockUnits takes row locks on the given distinct storage units in a
+// deterministic order, failing if any of them does not exist.
+func LockUnits(tx *sqlx.Tx, unitIds []uuid.UUID) error {
+	query := `SELECT id FROM storage_units WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`
+	locked := []uuid.UUID{}
+	if err := tx.Select(&locked, query, uuidArray(unitIds)); err != nil {
+		return err
+	}
+
+	if len(locked) != len(unitIds) {
+		return errors.NotFoundError("Storage unit not found")
+	}
+
+	return nil
+}
+
+func recordMovement(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	query := `
+		INSERT INTO stock_movements (
+			id, product_id, storage_unit_id, delta, balance, unit_cost, value, reason,
+			source_type, source_id, user_id, request_id, created_at
+		) VALUES (
+			:id, :product_id, :storage_unit_id, :delta, :balance, :unit_cost, :value, :reason,
+			:source_type, :source_id, :user_id, :request_id, :created_at
+		)
+	`
+
+	_, err := tx.NamedExec(query, movement)
+	return err
+}
@@ -0,0 +1,159 @@
+package stock
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *StockController) ListStocktakes(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	status := domain.StocktakeStatus(ctx.QueryParam("status"))
+
+	stocktakes, err := ctrl.repo.ListStocktakes(inventoryId, status)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch stocktakes",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, stocktakes)
+}
+
+func (ctrl *StockController) GetStocktake(ctx echo.Context) error {
+	stocktakeId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid stocktake ID"))
+	}
+
+	stocktake, err := ctrl.repo.GetStocktake(stocktakeId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to retrieve stocktake",
+			logger.Field{Key: "stocktake_id", Value: stocktakeId})
+		return errors.Send(ctx, err)
+	}
+	stocktake.HideExpected()
+
+	return ctx.JSON(http.StatusOK, stocktake)
+}
+
+func (ctrl *StockController) CreateStocktake(ctx echo.Context) error {
+	var input domain.StocktakeRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	newStocktake := input.ToCreateStocktakeRequest(utils.GetActor(ctx))
+
+	if err := ctrl.repo.CreateStocktake(newStocktake); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create stocktake",
+			logger.Field{Key: "inventory_id", Value: newStocktake.InventoryId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Create Stocktake"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Successfully created stocktake",
+		logger.Field{Key: "stocktake_id", Value: newStocktake.Id},
+		logger.Field{Key: "scope", Value: newStocktake.Scope})
+
+	return ctrl.respondWithStocktake(ctx, newStocktake.Id, http.StatusCreated)
+}
+
+func (ctrl *StockController) RecordStocktakeCounts(ctx echo.Context) error {
+	stocktakeId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid stocktake ID"))
+	}
+
+	var input domain.StocktakeCountRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	if err := ctrl.repo.RecordCounts(stocktakeId, input.Counts, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to record stocktake counts",
+			logger.Field{Key: "stocktake_id", Value: stocktakeId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Record Stocktake Counts"))
+	}
+
+	return ctrl.respondWithStocktake(ctx, stocktakeId, http.StatusOK)
+}
+
+func (ctrl *StockController) ApproveStocktakeLines(ctx echo.Context) error {
+	stocktakeId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid stocktake ID"))
+	}
+
+	var input domain.StocktakeApprovalRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	if err := ctrl.repo.ApproveStocktakeLines(stocktakeId, input.Lines); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to approve stocktake lines",
+			logger.Field{Key: "stocktake_id", Value: stocktakeId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Approve Stocktake Lines"))
+	}
+
+	return ctrl.respondWithStocktake(ctx, stocktakeId, http.StatusOK)
+}
+
+func (ctrl *StockController) TransitionStocktake(ctx echo.Context) error {
+	stocktakeId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid stocktake ID"))
+	}
+
+	var input domain.StocktakeTransitionRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+
+	if err := ctrl.repo.TransitionStocktake(stocktakeId, input.Status, utils.GetActor(ctx)); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to change stocktake status",
+			logger.Field{Key: "stocktake_id", Value: stocktakeId},
+			logger.Field{Key: "status", Value: input.Status})
+		return errors.Send(ctx, errors.DatabaseError(err, "Transition Stocktake"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Changed stocktake status",
+		logger.Field{Key: "stocktake_id", Value: stocktakeId},
+		logger.Field{Key: "status", Value: input.Status})
+
+	return ctrl.respondWithStocktake(ctx, stocktakeId, http.StatusOK)
+}
+
+func (ctrl *StockController) DeleteStocktake(ctx echo.Context) error {
+	stocktakeId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid stocktake ID"))
+	}
+
+	if err := ctrl.repo.DeleteStocktake(stocktakeId); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete stocktake",
+			logger.Field{Key: "stocktake_id", Value: stocktakeId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Delete Stocktake"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (ctrl *StockController) respondWithStocktake(ctx echo.Context, stocktakeId uuid.UUID, status int) error {
+	stocktake, err := ctrl.repo.GetStocktake(stocktakeId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+	stocktake.HideExpected()
+
+	return ctx.JSON(status, stocktake)
+}
@@ -0,0 +1,574 @@
+package stock
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// ListStocktakes returns the inventory's stocktakes, optionally limited to a
+// single status. Lines are only loaded for a single stocktake.
+func (repo *StockRepository) ListStocktakes(inventoryId uuid.UUID, status domain.StocktakeStatus) ([]domain.Stocktake, error) {
+	stocktakes := []domain.Stocktake{}
+	query := `
+		SELECT * FROM stocktakes
+		WHERE inventory_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+	if err := repo.db.Select(&stocktakes, query, inventoryId, status); err != nil {
+		return nil, errors.DatabaseError(err, "List Stocktakes")
+	}
+
+	return stocktakes, nil
+}
+
+func (repo *StockRepository) GetStocktake(stocktakeId uuid.UUID) (*domain.Stocktake, error) {
+	var stocktake domain.Stocktake
+	if err := repo.db.Get(&stocktake, `SELECT * FROM stocktakes WHERE id = $1`, stocktakeId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Stocktake not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Stocktake")
+	}
+
+	unitQuery := `SELECT storage_unit_id FROM stocktake_units WHERE stocktake_id = $1`
+	if err := repo.db.Select(&stocktake.UnitIds, unitQuery, stocktakeId); err != nil {
+		return nil, errors.DatabaseError(err, "Get Stocktake")
+	}
+
+	stocktake.Lines = []domain.StocktakeLine{}
+	lineQuery := `
+		SELECT sl.* FROM stocktake_lines sl
+		JOIN products p ON p.id = sl.product_id
+		LEFT JOIN storage_units su ON su.id = sl.storage_unit_id
+		WHERE sl.stocktake_id = $1
+		ORDER BY su.name NULLS FIRST, p.name
+	`
+	if err := repo.db.Select(&stocktake.Lines, lineQuery, stocktakeId); err != nil {
+		return nil, errors.DatabaseError(err, "Get Stocktake")
+	}
+
+	return &stocktake, nil
+}
+
+// CreateStocktake opens a stocktake and snapshots the quantities expected in
+// its scope: product totals for a whole inventory, unit contents otherwise.
+func (repo *StockRepository) CreateStocktake(stocktake *domain.Stocktake) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var locked uuid.UUID
+	if err := tx.Get(&locked, `SELECT id FROM inventories WHERE id = $1 FOR UPDATE`, stocktake.InventoryId); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Inventory not found")
+		}
+		return err
+	}
+
+	if err := resolveStocktakeUnits(tx, stocktake); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO stocktakes (
+			id, inventory_id, name, status, scope, storage_id, blind, note, created_by, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :name, :status, :scope, :storage_id, :blind, :note, :created_by, :created_at, :updated_at
+		)
+	`
+	if _, err := tx.NamedExec(query, stocktake); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	unitQuery := `INSERT INTO stocktake_units (stocktake_id, storage_unit_id) VALUES ($1, $2)`
+	for _, unitId := range stocktake.UnitIds {
+		if _, err := tx.Exec(unitQuery, stocktake.Id, unitId); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := snapshotStocktake(tx, stocktake); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RecordCounts stores counted quantities. Counting a product the snapshot did
+// not expect at a location adds a line for it, expecting what is booked there
+// now. The first count moves an open stocktake to counting.
+func (repo *StockRepository) RecordCounts(stocktakeId uuid.UUID, counts []domain.StocktakeCountItem, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stocktake, err := lockStocktake(tx, stocktakeId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if !stocktake.Status.AcceptsCounts() {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot record counts on a stocktake in %s", stocktake.Status))
+	}
+
+	for _, count := range counts {
+		if err := recordCount(tx, stocktake, count, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if stocktake.Status == domain.StocktakeStatusOpen {
+		updateQuery := `UPDATE stocktakes SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+		if _, err := tx.Exec(updateQuery, domain.StocktakeStatusCounting, stocktakeId); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ApproveStocktakeLines marks which variances will be posted. Lines are
+// approved by default, so this is mostly used to leave variances out.
+func (repo *StockRepository) ApproveStocktakeLines(stocktakeId uuid.UUID, approvals []domain.StocktakeApprovalItem) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stocktake, err := lockStocktake(tx, stocktakeId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if stocktake.Status != domain.StocktakeStatusReview {
+		_ = tx.Rollback()
+		return errors.ConflictError("Variances can only be approved while the stocktake is in review")
+	}
+
+	query := `
+		UPDATE stocktake_lines SET approved = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND stocktake_id = $3
+	`
+	for _, approval := range approvals {
+		result, err := tx.Exec(query, approval.Approved, approval.LineId, stocktakeId)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			_ = tx.Rollback()
+			return errors.NotFoundError(fmt.Sprintf("Line %s is not on this stocktake", approval.LineId))
+		}
+	}
+
+	return tx.Commit()
+}
+
+// TransitionStocktake moves a stocktake to its next status. Posting applies
+// every approved variance as a stock adjustment in the same transaction.
+func (repo *StockRepository) TransitionStocktake(stocktakeId uuid.UUID, next domain.StocktakeStatus, actor domain.Actor) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stocktake, err := lockStocktake(tx, stocktakeId)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if !stocktake.Status.CanTransitionTo(next) {
+		_ = tx.Rollback()
+		return errors.ConflictError(fmt.Sprintf("Cannot change stocktake status from %s to %s", stocktake.Status, next))
+	}
+
+	if next == domain.StocktakeStatusPosted {
+		if err := postStocktake(tx, stocktake, actor); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		postQuery := `
+			UPDATE stocktakes
+			SET status = $1, posted_by = $2, posted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.Exec(postQuery, next, actor.UserId, stocktakeId); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	updateQuery := `UPDATE stocktakes SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	if _, err := tx.Exec(updateQuery, next, stocktakeId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteStocktake discards a stocktake that has not been posted yet.
+func (repo *StockRepository) DeleteStocktake(stocktakeId uuid.UUID) error {
+	result, err := repo.db.Exec(`DELETE FROM stocktakes WHERE id = $1 AND status <> $2`, stocktakeId, domain.StocktakeStatusPosted)
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := repo.db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM stocktakes WHERE id = $1)`, stocktakeId); err != nil {
+			return err
+		}
+		if exists {
+			return errors.ConflictError("Posted stocktakes cannot be deleted")
+		}
+		return errors.NotFoundError("Stocktake not found")
+	}
+
+	return nil
+}
+
+// resolveStocktakeUnits checks the stocktake's scope belongs to its inventory
+// and expands a storage scope into the units the storage holds.
+func resolveStocktakeUnits(tx *sqlx.Tx, stocktake *domain.Stocktake) error {
+	switch stocktake.Scope {
+	case domain.StocktakeScopeStorage:
+		var inventoryId uuid.UUID
+		if err := tx.Get(&inventoryId, `SELECT inventory_id FROM storages WHERE id = $1`, *stocktake.StorageId); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.NotFoundError("Storage not found")
+			}
+			return err
+		}
+
+		if inventoryId != stocktake.InventoryId {
+			return errors.ValidationError("Storage belongs to a different inventory")
+		}
+
+		stocktake.UnitIds = []uuid.UUID{}
+		unitQuery := `SELECT id FROM storage_units WHERE storage_id = $1 ORDER BY id`
+		return tx.Select(&stocktake.UnitIds, unitQuery, *stocktake.StorageId)
+
+	case domain.StocktakeScopeUnits:
+		unique := make(map[uuid.UUID]bool, len(stocktake.UnitIds))
+		unitIds := make([]uuid.UUID, 0, len(stocktake.UnitIds))
+		for _, unitId := range stocktake.UnitIds {
+			if !unique[unitId] {
+				unique[unitId] = true
+				unitIds = append(unitIds, unitId)
+			}
+		}
+		stocktake.UnitIds = unitIds
+
+		var found int
+		query := `
+			SELECT COUNT(*) FROM storage_units su
+			JOIN storages s ON s.id = su.storage_id
+			WHERE su.id = ANY($1::uuid[]) AND s.inventory_id = $2
+		`
+		if err := tx.Get(&found, query, uuidArray(unitIds), stocktake.InventoryId); err != nil {
+			return err
+		}
+
+		if found != len(unitIds) {
+			return errors.NotFoundError("Storage unit not found in this inventory")
+		}
+	}
+
+	return nil
+}
+
+func snapshotStocktake(tx *sqlx.Tx, stocktake *domain.Stocktake) error {
+	lines := []domain.StocktakeLine{}
+
+	if stocktake.Scope == domain.StocktakeScopeInventory {
+		// Bundles and products with variants hold no stock of their own
+		query := `
+			SELECT id AS product_id, quantity AS expected_quantity
+			FROM products p WHERE inventory_id = $1
+				AND NOT is_bundle
+				AND NOT EXISTS (SELECT 1 FROM product_variant_axes a WHERE a.product_id = p.id)
+		`
+		if err := tx.Select(&lines, query, stocktake.InventoryId); err != nil {
+			return err
+		}
+	} else {
+		query := `
+			SELECT ui.product_id, ui.storage_unit_id, SUM(ui.quantity) AS expected_quantity
+			FROM unit_items ui
+			JOIN stocktake_units stu ON stu.storage_unit_id = ui.storage_unit_id
+			WHERE stu.stocktake_id = $1
+			GROUP BY ui.product_id, ui.storage_unit_id
+		`
+		if err := tx.Select(&lines, query, stocktake.Id); err != nil {
+			return err
+		}
+	}
+
+	for i := range lines {
+		lines[i].Id = uuid.New()
+		lines[i].StocktakeId = stocktake.Id
+		if err := insertStocktakeLine(tx, &lines[i]); err != nil {
+			return err
+		}
+	}
+
+	stocktake.Lines = lines
+	return nil
+}
+
+func recordCount(tx *sqlx.Tx, stocktake *domain.Stocktake, count domain.StocktakeCountItem, actor domain.Actor) error {
+	if err := checkCountLocation(tx, stocktake, count); err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE stocktake_lines
+		SET counted_quantity = $1, counted_by = $2, counted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE stocktake_id = $3 AND product_id = $4 AND storage_unit_id IS NOT DISTINCT FROM $5
+	`
+	result, err := tx.Exec(updateQuery, count.Quantity, actor.UserId, stocktake.Id, count.ProductId, count.StorageUnitId)
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	var productInventory uuid.UUID
+	if err := tx.Get(&productInventory, `SELECT inventory_id FROM products WHERE id = $1`, count.ProductId); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return err
+	}
+
+	if productInventory != stocktake.InventoryId {
+		return errors.ValidationError("Product belongs to a different inventory")
+	}
+
+	var expected int
+	expectedQuery := `SELECT quantity FROM products WHERE id = $1`
+	args := []interface{}{count.ProductId}
+	if count.StorageUnitId != nil {
+		expectedQuery = `
+			SELECT COALESCE(SUM(quantity), 0) FROM unit_items
+			WHERE product_id = $1 AND storage_unit_id = $2
+		`
+		args = append(args, *count.StorageUnitId)
+	}
+	if err := tx.Get(&expected, expectedQuery, args...); err != nil {
+		return err
+	}
+
+	countedAt := time.Now()
+	line := domain.StocktakeLine{
+		Id:               uuid.New(),
+		StocktakeId:      stocktake.Id,
+		ProductId:        count.ProductId,
+		StorageUnitId:    count.StorageUnitId,
+		ExpectedQuantity: &expected,
+		CountedQuantity:  &count.Quantity,
+		CountedBy:        actor.UserId,
+		CountedAt:        &countedAt,
+	}
+
+	return insertStocktakeLine(tx, &line)
+}
+
+// checkCountLocation makes sure a count was taken inside the stocktake's scope.
+func checkCountLocation(tx *sqlx.Tx, stocktake *domain.Stocktake, count domain.StocktakeCountItem) error {
+	if stocktake.Scope == domain.StocktakeScopeInventory {
+		if count.StorageUnitId != nil {
+			return errors.ValidationError("Inventory stocktakes count product totals, not storage units")
+		}
+		return nil
+	}
+
+	if count.StorageUnitId == nil {
+		return errors.ValidationError("A storage unit is required for this stocktake")
+	}
+
+	var inScope bool
+	query := `SELECT EXISTS (SELECT 1 FROM stocktake_units WHERE stocktake_id = $1 AND storage_unit_id = $2)`
+	if err := tx.Get(&inScope, query, stocktake.Id, *count.StorageUnitId); err != nil {
+		return err
+	}
+
+	if !inScope {
+		return errors.ValidationError(fmt.Sprintf("Storage unit %s is not part of this stocktake", *count.StorageUnitId))
+	}
+
+	return nil
+}
+
+// postStocktake books every approved, counted variance. Variances are applied
+// relative to current stock, so movements made while counting are preserved.
+// Counts name no lot, so a lot-tracked product's surplus goes to its
+// unassigned lot and a shortfall comes out of the lots that are missing it.
+// Counts name no serial numbers either, so variances on serialized products
+// are refused and have to be rejected and adjusted with their serials instead.
+func postStocktake(tx *sqlx.Tx, stocktake *domain.Stocktake, actor domain.Actor) error {
+	lines := []domain.StocktakeLine{}
+	query := `
+		SELECT * FROM stocktake_lines
+		WHERE stocktake_id = $1 AND approved AND variance IS NOT NULL AND variance <> 0
+		ORDER BY product_id
+	`
+	if err := tx.Select(&lines, query, stocktake.Id); err != nil {
+		return err
+	}
+
+	productIds := make([]uuid.UUID, 0, len(lines))
+	unitIds := []uuid.UUID{}
+	seenUnits := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		productIds = append(productIds, line.ProductId)
+		if line.StorageUnitId != nil && !seenUnits[*line.StorageUnitId] {
+			seenUnits[*line.StorageUnitId] = true
+			unitIds = append(unitIds, *line.StorageUnitId)
+		}
+	}
+
+	if err := LockProducts(tx, productIds); err != nil {
+		return err
+	}
+	if err := LockUnits(tx, unitIds); err != nil {
+		return err
+	}
+
+	serialized := []string{}
+	serializedQuery := `SELECT name FROM products WHERE id = ANY($1::uuid[]) AND is_serialized ORDER BY name`
+	if err := tx.Select(&serialized, serializedQuery, uuidArray(productIds)); err != nil {
+		return err
+	}
+
+	if len(serialized) > 0 {
+		return errors.ValidationError(fmt.Sprintf(
+			"Variances on serialized products cannot be posted without serial numbers; reject them and adjust the stock instead: %s",
+			strings.Join(serialized, ", ")))
+	}
+
+	for _, line := range lines {
+		variance := *line.Variance
+
+		movement := domain.NewStockMovement(line.ProductId, variance,
+			domain.MovementSourceStocktake, &stocktake.Id, "stocktake variance", actor)
+		movement.StorageUnitId = line.StorageUnitId
+
+		if err := bookVarianceLots(tx, line, movement); err != nil {
+			return err
+		}
+
+		if err := ApplyMovement(tx, movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bookVarianceLots moves a variance in or out of the line's storage unit, and
+// keeps the product's lots in step with it.
+func bookVarianceLots(tx *sqlx.Tx, line domain.StocktakeLine, movement *domain.StockMovement) error {
+	variance := *line.Variance
+
+	if variance > 0 {
+		lotId, err := unassignedLot(tx, line.ProductId)
+		if err != nil {
+			return err
+		}
+
+		if lotId != nil {
+			if err := ChangeLotQuantity(tx, *lotId, line.ProductId, variance); err != nil {
+				return err
+			}
+		}
+
+		if line.StorageUnitId == nil {
+			return nil
+		}
+		return PutAwayLot(tx, *line.StorageUnitId, line.ProductId, lotId, variance)
+	}
+
+	if line.StorageUnitId == nil {
+		return writeOffLots(tx, movement, -variance)
+	}
+
+	taken, err := TakeFromUnit(tx, *line.StorageUnitId, line.ProductId, -variance)
+	if err != nil {
+		return err
+	}
+
+	return takeUnitLots(tx, line.ProductId, taken)
+}
+
+func insertStocktakeLine(tx *sqlx.Tx, line *domain.StocktakeLine) error {
+	query := `
+		INSERT INTO stocktake_lines (
+			id, stocktake_id, product_id, storage_unit_id, expected_quantity, counted_quantity, counted_by, counted_at
+		) VALUES (
+			:id, :stocktake_id, :product_id, :storage_unit_id, :expected_quantity, :counted_quantity, :counted_by, :counted_at
+		)
+	`
+	_, err := tx.NamedExec(query, line)
+	return err
+}
+
+func lockStocktake(tx *sqlx.Tx, stocktakeId uuid.UUID) (*domain.Stocktake, error) {
+	var stocktake domain.Stocktake
+	err := tx.Get(&stocktake, `SELECT * FROM stocktakes WHERE id = $1 FOR UPDATE`, stocktakeId)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundError("Stocktake not found")
+	}
+	return &stocktake, err
+}
@@ -0,0 +1,55 @@
+package stock
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+// InventoryValuation reports stock value as of the 'asOf' query parameter, a
+// date (valued at the end of that day) or timestamp. It defaults to now.
+func (ctrl *StockController) InventoryValuation(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	asOf := time.Now()
+	parsed, err := utils.ParseDateParam(ctx.QueryParam("asOf"), true)
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid 'asOf' date"))
+	}
+	if parsed != nil {
+		asOf = *parsed
+	}
+
+	valuation, err := ctrl.repo.InventoryValuation(inventoryId, asOf)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to value inventory",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, valuation)
+}
+
+func (ctrl *StockController) ListProductCostLayers(ctx echo.Context) error {
+	productId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid product ID"))
+	}
+
+	layers, err := ctrl.repo.ListProductCostLayers(productId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch product cost layers",
+			logger.Field{Key: "product_id", Value: productId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, layers)
+}
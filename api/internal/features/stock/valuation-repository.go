@@ -0,0 +1,231 @@
+package stock
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+// InventoryValuation values the inventory's stock as of the given time from
+// the quantity and value of every movement recorded before it.
+func (repo *StockRepository) InventoryValuation(inventoryId uuid.UUID, asOf time.Time) (*domain.InventoryValuation, error) {
+	valuation := domain.InventoryValuation{
+		InventoryId: inventoryId,
+		AsOf:        asOf,
+		Products:    []domain.ProductValuation{},
+	}
+
+	if err := repo.db.Get(&valuation.CostingMethod, `SELECT costing_method FROM inventories WHERE id = $1`, inventoryId); err != nil {
+		return nil, errors.DatabaseError(err, "Inventory Valuation")
+	}
+
+	query := `
+		SELECT p.id AS product_id, p.name, p.sku,
+			COALESCE(SUM(m.delta), 0) AS quantity,
+			COALESCE(SUM(m.value), 0) AS value
+		FROM products p
+		JOIN stock_movements m ON m.product_id = p.id AND m.created_at < $2
+		WHERE p.inventory_id = $1
+		GROUP BY p.id, p.name, p.sku
+		HAVING SUM(m.delta) <> 0 OR SUM(m.value) <> 0
+		ORDER BY p.name
+	`
+	if err := repo.db.Select(&valuation.Products, query, inventoryId, asOf); err != nil {
+		return nil, errors.DatabaseError(err, "Inventory Valuation")
+	}
+
+	for _, product := range valuation.Products {
+		valuation.TotalQuantity += product.Quantity
+		valuation.TotalValue += product.Value
+	}
+	valuation.TotalValue = roundCost(valuation.TotalValue)
+
+	return &valuation, nil
+}
+
+// ListProductCostLayers returns the product's layers that still have stock,
+// oldest first.
+func (repo *StockRepository) ListProductCostLayers(productId uuid.UUID) ([]domain.CostLayer, error) {
+	layers := []domain.CostLayer{}
+	query := `
+		SELECT * FROM cost_layers
+		WHERE product_id = $1 AND remaining > 0
+		ORDER BY created_at, id
+	`
+
+	if err := repo.db.Select(&layers, query, productId); err != nil {
+		return nil, errors.DatabaseError(err, "List Product Cost Layers")
+	}
+
+	return layers, nil
+}
+
+// applyCost values a movement whose quantity change has just been applied.
+// Incoming stock opens a cost layer and updates the moving average; outgoing
+// stock uses up layers oldest first and is valued by the inventory's costing
+// method. Units that went out on backorder are settled by the next stock in.
+func applyCost(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	var product struct {
+		Cost          float64              `db:"cost"`
+		AverageCost   float64              `db:"average_cost"`
+		CostingMethod domain.CostingMethod `db:"costing_method"`
+	}
+	query := `
+		SELECT p.cost, p.average_cost, i.costing_method
+		FROM products p
+		JOIN inventories i ON i.id = p.inventory_id
+		WHERE p.id = $1
+	`
+	if err := tx.Get(&product, query, movement.ProductId); err != nil {
+		return err
+	}
+
+	currentCost := product.AverageCost
+	if currentCost == 0 {
+		currentCost = product.Cost
+	}
+
+	previous := movement.Balance - movement.Delta
+
+	switch {
+	case movement.Delta > 0:
+		unitCost := currentCost
+		if movement.InboundCost != nil {
+			unitCost = *movement.InboundCost
+		}
+		movement.UnitCost = roundCost(unitCost)
+		movement.Value = roundCost(float64(movement.Delta) * movement.UnitCost)
+
+		if movement.Balance > 0 {
+			layer := domain.CostLayer{
+				Id:         uuid.New(),
+				ProductId:  movement.ProductId,
+				SourceType: &movement.SourceType,
+				SourceId:   movement.SourceId,
+				Quantity:   movement.Delta,
+				Remaining:  min(movement.Delta, movement.Balance),
+				UnitCost:   movement.UnitCost,
+				CreatedAt:  movement.CreatedAt,
+			}
+			layerQuery := `
+				INSERT INTO cost_layers (
+					id, product_id, source_type, source_id, quantity, remaining, unit_cost, created_at
+				) VALUES (
+					:id, :product_id, :source_type, :source_id, :quantity, :remaining, :unit_cost, :created_at
+				)
+			`
+			if _, err := tx.NamedExec(layerQuery, layer); err != nil {
+				return err
+			}
+		}
+
+		average := movement.UnitCost
+		if previous > 0 {
+			average = (float64(previous)*product.AverageCost + movement.Value) / float64(movement.Balance)
+		}
+		return setAverageCost(tx, movement.ProductId, average)
+
+	case movement.Delta < 0:
+		quantity := -movement.Delta
+
+		layerValue, consumed, err := consumeCostLayers(tx, movement.ProductId, quantity)
+		if err != nil {
+			return err
+		}
+
+		value := float64(quantity) * currentCost
+		if product.CostingMethod == domain.CostingMethodFIFO {
+			value = layerValue + float64(quantity-consumed)*currentCost
+		}
+		movement.Value = -roundCost(value)
+		movement.UnitCost = roundCost(value / float64(quantity))
+
+		if product.CostingMethod == domain.CostingMethodFIFO {
+			averageQuery := `
+				UPDATE products SET average_cost = COALESCE((
+					SELECT SUM(remaining * unit_cost) / NULLIF(SUM(remaining), 0)
+					FROM cost_layers WHERE product_id = $1
+				), average_cost)
+				WHERE id = $1
+			`
+			_, err := tx.Exec(averageQuery, movement.ProductId)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consumeCostLayers uses up quantity from the product's open layers oldest
+// first, returning the value taken and how much of the quantity they covered.
+func consumeCostLayers(tx *sqlx.Tx, productId uuid.UUID, quantity int) (float64, int, error) {
+	layers := []domain.CostLayer{}
+	query := `
+		SELECT * FROM cost_layers
+		WHERE product_id = $1 AND remaining > 0
+		ORDER BY created_at, id
+		FOR UPDATE
+	`
+	if err := tx.Select(&layers, query, productId); err != nil {
+		return 0, 0, err
+	}
+
+	value, consumed := 0.0, 0
+	for _, layer := range layers {
+		if consumed == quantity {
+			break
+		}
+
+		take := min(layer.Remaining, quantity-consumed)
+		if _, err := tx.Exec(`UPDATE cost_layers SET remaining = remaining - $1 WHERE id = $2`, take, layer.Id); err != nil {
+			return 0, 0, err
+		}
+
+		value += float64(take) * layer.UnitCost
+		consumed += take
+	}
+
+	return value, consumed, nil
+}
+
+// returnCost sets a returning movement's inbound cost to what its source took
+// the product out at, so a return puts back the value that left.
+func returnCost(tx *sqlx.Tx, movement *domain.StockMovement) error {
+	if movement.SourceId == nil {
+		return nil
+	}
+
+	var taken struct {
+		Quantity int     `db:"quantity"`
+		Value    float64 `db:"value"`
+	}
+	query := `
+		SELECT COALESCE(SUM(delta), 0) AS quantity, COALESCE(SUM(value), 0) AS value
+		FROM stock_movements
+		WHERE source_type = $1 AND source_id = $2 AND product_id = $3
+	`
+	if err := tx.Get(&taken, query, movement.SourceType, *movement.SourceId, movement.ProductId); err != nil {
+		return err
+	}
+
+	if taken.Quantity < 0 {
+		unitCost := taken.Value / float64(taken.Quantity)
+		movement.InboundCost = &unitCost
+	}
+
+	return nil
+}
+
+func setAverageCost(tx *sqlx.Tx, productId uuid.UUID, average float64) error {
+	_, err := tx.Exec(`UPDATE products SET average_cost = $1 WHERE id = $2`, roundCost(average), productId)
+	return err
+}
+
+// roundCost rounds to the four decimal places costs are stored with.
+func roundCost(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,76 @@
+package storages
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+func (ctrl *StorageController) ListTransfers(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	from, err := utils.ParseDateParam(ctx.QueryParam("from"), false)
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid 'from' date"))
+	}
+
+	to, err := utils.ParseDateParam(ctx.QueryParam("to"), true)
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid 'to' date"))
+	}
+
+	transfers, err := ctrl.repo.ListTransfers(inventoryId, domain.MovementFilter{From: from, To: to})
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch transfers",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, transfers)
+}
+
+func (ctrl *StorageController) GetTransfer(ctx echo.Context) error {
+	transferId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid transfer ID"))
+	}
+
+	transfer, err := ctrl.repo.GetTransfer(transferId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, transfer)
+}
+
+func (ctrl *StorageController) TransferStock(ctx echo.Context) error {
+	var input domain.StockTransferRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	transfer := input.ToStockTransfer(utils.GetActor(ctx))
+
+	if err := ctrl.repo.TransferStock(transfer); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to transfer stock",
+			logger.Field{Key: "product_id", Value: transfer.ProductId},
+			logger.Field{Key: "from_unit_id", Value: transfer.FromUnitId},
+			logger.Field{Key: "to_unit_id", Value: transfer.ToUnitId})
+		return errors.Send(ctx, errors.DatabaseError(err, "Transfer Stock"))
+	}
+
+	logger.Info(ctx.Request().Context(), "Transferred stock between storage units",
+		logger.Field{Key: "transfer_id", Value: transfer.Id},
+		logger.Field{Key: "quantity", Value: transfer.Quantity})
+
+	return ctx.JSON(http.StatusCreated, transfer)
+}
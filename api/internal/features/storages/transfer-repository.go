@@ -0,0 +1,119 @@
+package storages
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+func (repo *StorageRepository) ListTransfers(inventoryId uuid.UUID, filter domain.MovementFilter) ([]domain.StockTransfer, error) {
+	transfers := []domain.StockTransfer{}
+	query := `
+		SELECT * FROM stock_transfers
+		WHERE inventory_id = $1
+			AND ($2::timestamptz IS NULL OR created_at >= $2)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY created_at DESC
+	`
+
+	if err := repo.db.Select(&transfers, query, inventoryId, filter.From, filter.To); err != nil {
+		return nil, errors.DatabaseError(err, "List Transfers")
+	}
+
+	return transfers, nil
+}
+
+func (repo *StorageRepository) GetTransfer(transferId uuid.UUID) (*domain.StockTransfer, error) {
+	var transfer domain.StockTransfer
+	if err := repo.db.Get(&transfer, `SELECT * FROM stock_transfers WHERE id = $1`, transferId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Transfer not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Transfer")
+	}
+
+	return &transfer, nil
+}
+
+// TransferStock moves a quantity of a product between two storage units of the
+// same inventory in one transaction and records the transfer document.
+func (repo *StorageRepository) TransferStock(transfer *domain.StockTransfer) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := stock.LockUnits(tx, []uuid.UUID{*transfer.FromUnitId, *transfer.ToUnitId}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Both units must sit in storages of the same inventory
+	inventoryIds := []uuid.UUID{}
+	inventoryQuery := `
+		SELECT DISTINCT s.inventory_id
+		FROM storage_units su
+		JOIN storages s ON s.id = su.storage_id
+		WHERE su.id IN ($1, $2)
+	`
+	if err := tx.Select(&inventoryIds, inventoryQuery, *transfer.FromUnitId, *transfer.ToUnitId); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if len(inventoryIds) != 1 {
+		_ = tx.Rollback()
+		return errors.ValidationError("Storage units belong to different inventories")
+	}
+	transfer.InventoryId = inventoryIds[0]
+
+	taken, err := stock.TakeFromUnit(tx, *transfer.FromUnitId, transfer.ProductId, transfer.Quantity)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Lots keep their identity when they move
+	for _, part := range taken {
+		if err := stock.PutAwayLot(tx, *transfer.ToUnitId, transfer.ProductId, part.LotId, part.Quantity); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	err = stock.MoveSerials(tx, transfer.ProductId, transfer.Quantity, transfer.Serials, *transfer.FromUnitId, domain.SerialChange{
+		StorageUnitId: transfer.ToUnitId,
+		SourceType:    domain.MovementSourceTransfer,
+		SourceId:      &transfer.Id,
+		Actor:         domain.Actor{UserId: transfer.UserId, RequestId: transfer.RequestId},
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO stock_transfers (
+			id, inventory_id, product_id, from_unit_id, to_unit_id,
+			quantity, note, user_id, request_id, created_at
+		) VALUES (
+			:id, :inventory_id, :product_id, :from_unit_id, :to_unit_id,
+			:quantity, :note, :user_id, :request_id, :created_at
+		)
+	`
+	if _, err := tx.NamedExec(query, transfer); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
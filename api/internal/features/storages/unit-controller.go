@@ -2,10 +2,12 @@ package storages
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
 	"github.com/ventry/internal/utils"
 )
 
@@ -140,12 +142,9 @@ func (ctrl *StorageController) AddItemToUnit(ctx echo.Context) error {
 		return err
 	}
 
-	err = ctrl.repo.AddItemToUnit(unitId, input.ProductId, input.Quantity)
+	err = ctrl.repo.AddItemToUnit(unitId, input.ProductId, input.Quantity, strings.TrimSpace(input.Unit))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to add item to unit",
-			"details": err.Error(),
-		})
+		return errors.Send(ctx, errors.DatabaseError(err, "Add Item To Unit"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
@@ -162,12 +161,9 @@ func (ctrl *StorageController) RemoveItemFromUnit(ctx echo.Context) error {
 		return err
 	}
 
-	err = ctrl.repo.RemoveItemFromUnit(unitId, input.Quantity)
+	err = ctrl.repo.RemoveItemFromUnit(unitId, input.ProductId, input.Quantity, strings.TrimSpace(input.Unit))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to remove item from unit",
-			"details": err.Error(),
-		})
+		return errors.Send(ctx, errors.DatabaseError(err, "Remove Item From Unit"))
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
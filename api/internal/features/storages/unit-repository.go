@@ -2,12 +2,13 @@ package storages
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
-	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/errors"
 )
 
 func (repo *StorageRepository) ListUnits(storageId uuid.UUID) ([]domain.StorageUnit, error) {
@@ -53,9 +54,11 @@ func (repo *StorageRepository) GetUnit(unitId uuid.UUID) (*domain.StorageUnit, e
 func (repo *StorageRepository) CreateUnit(unit *domain.StorageUnit) error {
 	query := `
 		INSERT INTO storage_units (
-			id, name, label, storage_id, is_occupied, created_at, updated_at
+			id, name, label, storage_id, is_occupied, capacity_count,
+			capacity_volume, capacity_weight, created_at, updated_at
 		) VALUES (
-			:id, :name, :label, :storage_id, :is_occupied, :created_at, :updated_at
+			:id, :name, :label, :storage_id, :is_occupied, :capacity_count,
+			:capacity_volume, :capacity_weight, :created_at, :updated_at
 		)
 	`
 
@@ -65,7 +68,9 @@ func (repo *StorageRepository) CreateUnit(unit *domain.StorageUnit) error {
 
 func (repo *StorageRepository) EditUnit(unit *domain.StorageUnit) error {
 	query := `UPDATE storage_units
-				SET name = :name, label = :label, is_occupied = :is_occupied, updated_at = :updated_at
+				SET name = :name, label = :label, capacity_count = :capacity_count,
+					capacity_volume = :capacity_volume, capacity_weight = :capacity_weight,
+					updated_at = :updated_at
 				WHERE id = :id`
 
 	_, err := repo.db.NamedExec(query, unit)
@@ -93,7 +98,10 @@ func (repo *StorageRepository) ListUnitItems(unitId uuid.UUID) ([]domain.UnitIte
 	return items, nil
 }
 
-func (repo *StorageRepository) AddItemToUnit(unitId, productId uuid.UUID, quantity int) error {
+// AddItemToUnit puts a quantity of a product, counted in one of its units of
+// measure, away into a unit alongside whatever else it holds, enforcing the
+// unit's capacity.
+func (repo *StorageRepository) AddItemToUnit(unitId, productId uuid.UUID, quantity int, measure string) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -105,76 +113,28 @@ func (repo *StorageRepository) AddItemToUnit(unitId, productId uuid.UUID, quanti
 		}
 	}()
 
-	var unit domain.StorageUnit
-	if err := tx.Get(&unit, `SELECT * FROM storage_units WHERE id = $1`, unitId); err != nil {
+	if err := checkUntrackedProduct(tx, productId); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	// Check if there's already an item in this unit
-	var existingItem domain.UnitItem
-	err = tx.Get(&existingItem, `SELECT * FROM unit_items WHERE storage_unit_id = $1`, unitId)
-
-	if err != nil && err != sql.ErrNoRows {
+	_, quantity, err = stock.ToBaseUnit(tx, productId, measure, quantity)
+	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if err == sql.ErrNoRows {
-		// Create new item
-		item := domain.UnitItem{
-			Id:            uuid.New(),
-			StorageUnitId: unitId,
-			ProductId:     productId,
-			Quantity:      quantity,
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
-		}
-
-		insertQuery := `
-			INSERT INTO unit_items (
-				id, storage_unit_id, product_id, quantity, created_at, updated_at
-			) VALUES (
-				:id, :storage_unit_id, :product_id, :quantity, :created_at, :updated_at
-			)
-		`
-
-		if _, err := tx.NamedExec(insertQuery, item); err != nil {
-			_ = tx.Rollback()
-			return err
-		}
-
-		// Update unit status
-		if _, err := tx.Exec(
-			`UPDATE storage_units SET is_occupied = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
-			unitId,
-		); err != nil {
-			_ = tx.Rollback()
-			return err
-		}
-	} else {
-		// Update existing item if it's the same product
-		if existingItem.ProductId != productId {
-			_ = tx.Rollback()
-			return errors.New("unit already contains a different product")
-		}
-
-		updateQuery := `
-			UPDATE unit_items 
-			SET quantity = quantity + $1, updated_at = CURRENT_TIMESTAMP
-			WHERE storage_unit_id = $2
-		`
-
-		if _, err := tx.Exec(updateQuery, quantity, unitId); err != nil {
-			_ = tx.Rollback()
-			return err
-		}
+	if err := stock.PutAway(tx, unitId, productId, quantity); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (repo *StorageRepository) RemoveItemFromUnit(unitId uuid.UUID, quantity int) error {
+// RemoveItemFromUnit takes a quantity of one product, counted in one of its
+// units of measure, out of a unit.
+func (repo *StorageRepository) RemoveItemFromUnit(unitId, productId uuid.UUID, quantity int, measure string) error {
 	tx, err := repo.db.Beginx()
 	if err != nil {
 		return err
@@ -186,42 +146,55 @@ func (repo *StorageRepository) RemoveItemFromUnit(unitId uuid.UUID, quantity int
 		}
 	}()
 
-	// Get current item quantity
-	var currentQuantity int
-	if err := tx.Get(
-		&currentQuantity,
-		`SELECT quantity FROM unit_items WHERE storage_unit_id = $1`,
-		unitId,
-	); err != nil {
+	if err := checkUntrackedProduct(tx, productId); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if currentQuantity < quantity {
+	_, quantity, err = stock.ToBaseUnit(tx, productId, measure, quantity)
+	if err != nil {
 		_ = tx.Rollback()
-		return errors.New("not enough quantity to remove")
+		return err
 	}
 
-	// Update the quantity
-	updateQuery := `
-		UPDATE unit_items 
-		SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP
-		WHERE storage_unit_id = $2
-	`
+	if err := stock.LockUnits(tx, []uuid.UUID{unitId}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
-	if _, err := tx.Exec(updateQuery, quantity, unitId); err != nil {
+	if _, err := stock.TakeFromUnit(tx, unitId, productId, quantity); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	// Check if the item quantity is now zero and remove it if necessary
-	if currentQuantity == quantity {
-		deleteQuery := `DELETE FROM unit_items WHERE storage_unit_id = $1`
-		if _, err := tx.Exec(deleteQuery, unitId); err != nil {
-			_ = tx.Rollback()
-			return err
+	return tx.Commit()
+}
+
+// checkUntrackedProduct refuses serialized and lot-tracked products, whose unit
+// stock only moves together with its serial numbers or lot, as adjustments and
+// transfers do.
+func checkUntrackedProduct(tx *sqlx.Tx, productId uuid.UUID) error {
+	var product struct {
+		IsSerialized bool `db:"is_serialized"`
+		HasLots      bool `db:"has_lots"`
+	}
+	query := `
+		SELECT p.is_serialized, EXISTS (SELECT 1 FROM lots l WHERE l.product_id = p.id) AS has_lots
+		FROM products p WHERE p.id = $1
+	`
+	if err := tx.Get(&product, query, productId); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
 		}
+		return err
 	}
 
-	return tx.Commit()
+	if product.IsSerialized {
+		return errors.ValidationError("Serialized products move between units with their serial numbers; use a transfer or adjustment instead")
+	}
+	if product.HasLots {
+		return errors.ValidationError("Lot-tracked products move between units by lot; use a transfer or adjustment instead")
+	}
+
+	return nil
 }
@@ -0,0 +1,117 @@
+package suppliers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+	"github.com/ventry/internal/pkg/logger"
+	"github.com/ventry/internal/utils"
+)
+
+type SupplierController struct {
+	repo *SupplierRepository
+}
+
+func NewSupplierController(supplierRepo *SupplierRepository) *SupplierController {
+	return &SupplierController{repo: supplierRepo}
+}
+
+func (ctrl *SupplierController) ListSuppliers(ctx echo.Context) error {
+	inventoryId, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid inventory ID"))
+	}
+
+	suppliers, err := ctrl.repo.ListSuppliers(inventoryId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to fetch suppliers",
+			logger.Field{Key: "inventory_id", Value: inventoryId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, suppliers)
+}
+
+func (ctrl *SupplierController) GetSupplier(ctx echo.Context) error {
+	supplierId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid supplier ID"))
+	}
+
+	supplier, err := ctrl.repo.GetSupplier(supplierId)
+	if err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to retrieve supplier",
+			logger.Field{Key: "supplier_id", Value: supplierId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, supplier)
+}
+
+func (ctrl *SupplierController) CreateSupplier(ctx echo.Context) error {
+	var input domain.SupplierRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	newSupplier := input.ToCreateSupplierRequest()
+
+	if err := ctrl.repo.CreateSupplier(newSupplier); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to create supplier",
+			logger.Field{Key: "supplier_name", Value: newSupplier.Name})
+		return errors.Send(ctx, err)
+	}
+
+	logger.Info(ctx.Request().Context(), "Successfully created supplier",
+		logger.Field{Key: "supplier_id", Value: newSupplier.Id},
+		logger.Field{Key: "supplier_name", Value: newSupplier.Name})
+
+	return ctx.JSON(http.StatusCreated, newSupplier)
+}
+
+func (ctrl *SupplierController) EditSupplier(ctx echo.Context) error {
+	supplierId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid supplier ID"))
+	}
+
+	var input domain.SupplierRequest
+	if err := utils.BindAndValidateInput(ctx, &input); err != nil {
+		return err
+	}
+	input.Sanitize()
+
+	existingSupplier, err := ctrl.repo.GetSupplier(supplierId)
+	if err != nil {
+		return errors.Send(ctx, err)
+	}
+
+	updatedSupplier := input.ToUpdateSupplierRequest(existingSupplier)
+
+	if err := ctrl.repo.EditSupplier(updatedSupplier); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to update supplier",
+			logger.Field{Key: "supplier_id", Value: supplierId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, updatedSupplier)
+}
+
+func (ctrl *SupplierController) DeleteSupplier(ctx echo.Context) error {
+	supplierId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return errors.Send(ctx, errors.ValidationError("Invalid supplier ID"))
+	}
+
+	if err := ctrl.repo.DeleteSupplier(supplierId); err != nil {
+		logger.Error(ctx.Request().Context(), err, "Failed to delete supplier",
+			logger.Field{Key: "supplier_id", Value: supplierId})
+		return errors.Send(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
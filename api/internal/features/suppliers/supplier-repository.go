@@ -0,0 +1,98 @@
+package suppliers
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ventry/internal/domain"
+	"github.com/ventry/internal/pkg/errors"
+)
+
+type SupplierRepository struct {
+	db *sqlx.DB
+}
+
+func NewSupplierRepository(data *sqlx.DB) *SupplierRepository {
+	return &SupplierRepository{db: data}
+}
+
+func (repo *SupplierRepository) ListSuppliers(inventoryId uuid.UUID) ([]domain.Supplier, error) {
+	suppliers := []domain.Supplier{}
+	query := `SELECT * FROM suppliers WHERE inventory_id = $1 ORDER BY name`
+
+	if err := repo.db.Select(&suppliers, query, inventoryId); err != nil {
+		return nil, errors.DatabaseError(err, "List Suppliers")
+	}
+
+	return suppliers, nil
+}
+
+func (repo *SupplierRepository) GetSupplier(supplierId uuid.UUID) (*domain.Supplier, error) {
+	var supplier domain.Supplier
+	query := `SELECT * FROM suppliers WHERE id = $1`
+
+	if err := repo.db.Get(&supplier, query, supplierId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Supplier not found")
+		}
+		return nil, errors.DatabaseError(err, "Get Supplier")
+	}
+
+	return &supplier, nil
+}
+
+func (repo *SupplierRepository) CreateSupplier(supplier *domain.Supplier) error {
+	query := `
+		INSERT INTO suppliers (
+			id, inventory_id, name, contact_name, email, phone, address, note, created_at, updated_at
+		) VALUES (
+			:id, :inventory_id, :name, :contact_name, :email, :phone, :address, :note, :created_at, :updated_at
+		)
+	`
+
+	if _, err := repo.db.NamedExec(query, supplier); err != nil {
+		return errors.DatabaseError(err, "Create Supplier")
+	}
+
+	return nil
+}
+
+func (repo *SupplierRepository) EditSupplier(supplier *domain.Supplier) error {
+	query := `
+		UPDATE suppliers SET
+			name = :name,
+			contact_name = :contact_name,
+			email = :email,
+			phone = :phone,
+			address = :address,
+			note = :note,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	if _, err := repo.db.NamedExec(query, supplier); err != nil {
+		return errors.DatabaseError(err, "Edit Supplier")
+	}
+
+	return nil
+}
+
+// DeleteSupplier removes a supplier. Suppliers referenced by purchase orders are
+// kept so the order history stays intact.
+func (repo *SupplierRepository) DeleteSupplier(supplierId uuid.UUID) error {
+	var orders int
+	if err := repo.db.Get(&orders, `SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = $1`, supplierId); err != nil {
+		return errors.DatabaseError(err, "Delete Supplier")
+	}
+
+	if orders > 0 {
+		return errors.ConflictError("Cannot delete a supplier with purchase orders")
+	}
+
+	if _, err := repo.db.Exec(`DELETE FROM suppliers WHERE id = $1`, supplierId); err != nil {
+		return errors.DatabaseError(err, "Delete Supplier")
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package barcode encodes Code 128, EAN-13 and UPC-A barcodes and renders
+// them as PNG or SVG images.
+package barcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+type Symbology string
+
+const (
+	Code128 Symbology = "code128"
+	EAN13   Symbology = "ean13"
+	UPCA    Symbology = "upca"
+)
+
+// QuietZone is the blank margin, in modules, kept on each side of the bars.
+// Eleven modules satisfy every supported symbology.
+const QuietZone = 11
+
+// Barcode is an encoded barcode: Modules holds one entry per module, true for
+// a bar, without the quiet zones.
+type Barcode struct {
+	Symbology Symbology
+	Data      string
+	Modules   []bool
+}
+
+// Encode encodes data in the given symbology. EAN-13 and UPC-A accept the code
+// with or without its check digit and fail when a given check digit is wrong.
+func Encode(symbology Symbology, data string) (*Barcode, error) {
+	var modules []bool
+	var err error
+
+	switch symbology {
+	case Code128:
+		modules, err = encodeCode128(data)
+	case EAN13:
+		data, err = completeGTIN(data, 13)
+		if err == nil {
+			modules = encodeEAN13(data)
+		}
+	case UPCA:
+		data, err = completeGTIN(data, 12)
+		if err == nil {
+			// UPC-A is EAN-13 with a leading zero
+			modules = encodeEAN13("0" + data)
+		}
+	default:
+		err = fmt.Errorf("unsupported symbology %q", symbology)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Barcode{Symbology: symbology, Data: data, Modules: modules}, nil
+}
+
+// Detect picks the symbology a product code is best printed in: EAN-13 or
+// UPC-A for numeric codes of that length, Code 128 otherwise.
+func Detect(code string) Symbology {
+	if isDigits(code) {
+		switch len(code) {
+		case 13:
+			return EAN13
+		case 12:
+			return UPCA
+		}
+	}
+	return Code128
+}
+
+// Width is the barcode's width in modules, including both quiet zones.
+func (b *Barcode) Width() int {
+	return len(b.Modules) + 2*QuietZone
+}
+
+// WritePNG renders the barcode as a PNG, scale pixels per module wide and
+// height pixels tall.
+func (b *Barcode) WritePNG(w io.Writer, scale, height int) error {
+	img := image.NewGray(image.Rect(0, 0, b.Width()*scale, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for i, bar := range b.Modules {
+		if !bar {
+			continue
+		}
+		left := (QuietZone + i) * scale
+		for x := left; x < left+scale; x++ {
+			for y := 0; y < height; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// WriteSVG renders the barcode as an SVG, scale units per module wide and
+// height units tall. Adjacent bars are merged into one rectangle.
+func (b *Barcode) WriteSVG(w io.Writer, scale, height int) error {
+	width := b.Width() * scale
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		width, height, width, height)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+
+	for _, run := range b.Bars() {
+		fmt.Fprintf(&svg, `<rect x="%d" width="%d" height="%d"/>`,
+			(QuietZone+run[0])*scale, run[1]*scale, height)
+	}
+	svg.WriteString(`</svg>`)
+
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+// Bars lists the barcode's bars as [start, width] pairs in modules, not
+// counting the leading quiet zone.
+func (b *Barcode) Bars() [][2]int {
+	bars := [][2]int{}
+	for i := 0; i < len(b.Modules); i++ {
+		if !b.Modules[i] {
+			continue
+		}
+		start := i
+		for i < len(b.Modules) && b.Modules[i] {
+			i++
+		}
+		bars = append(bars, [2]int{start, i - start})
+	}
+	return bars
+}
+
+// appendWidths appends alternating bars and spaces of the given module widths,
+// starting with a bar.
+func appendWidths(modules []bool, widths string) []bool {
+	bar := true
+	for _, width := range widths {
+		for n := 0; n < int(width-'0'); n++ {
+			modules = append(modules, bar)
+		}
+		bar = !bar
+	}
+	return modules
+}
+
+// appendPattern appends modules written as a string of 0s and 1s.
+func appendPattern(modules []bool, pattern string) []bool {
+	for _, module := range pattern {
+		modules = append(modules, module == '1')
+	}
+	return modules
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package barcode
+
+import "testing"
+
+func TestCode128Checksum(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		// 104 + 48×1 + 42×2 + 42×3 + 17×4 + 18×5 + 19×6 + 35×7 = 879, 879 mod 103 = 55
+		{"PJJ123C", 55},
+		{"Wikipedia", 88},
+		{"ventry-001", 6},
+		// 104 + 0×1 = 104, 104 mod 103 = 1
+		{" ", 1},
+	}
+
+	for _, test := range tests {
+		values, err := code128Values(test.data)
+		if err != nil {
+			t.Fatalf("code128Values(%q): %v", test.data, err)
+		}
+
+		if values[0] != code128StartB || values[len(values)-1] != code128Stop {
+			t.Errorf("code128Values(%q) = %v, want it framed by start B and stop", test.data, values)
+		}
+		if got := values[len(values)-2]; got != test.want {
+			t.Errorf("code128Values(%q) checksum = %d, want %d", test.data, got, test.want)
+		}
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"text", "SKU-0042", false},
+		{"every printable character", " ~", false},
+		{"empty", "", true},
+		{"control character", "a\tb", true},
+		{"non-ASCII", "café", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			barcode, err := Encode(Code128, test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Encode(%q) succeeded, want an error", test.data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode(%q): %v", test.data, err)
+			}
+
+			// Eleven modules per symbol for start, data and checksum, thirteen for stop
+			want := 11*(len(test.data)+2) + 13
+			if len(barcode.Modules) != want {
+				t.Errorf("Encode(%q) has %d modules, want %d", test.data, len(barcode.Modules), want)
+			}
+		})
+	}
+}
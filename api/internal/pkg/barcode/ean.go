@@ -0,0 +1,96 @@
+package barcode
+
+import "fmt"
+
+// eanLeft holds the odd parity (L) patterns of the digits; the even parity (G)
+// and right-hand (R) patterns are derived from them.
+var eanLeft = [10]string{
+	"0001101", "0011001", "0010011", "0111101", "0100011",
+	"0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// eanParity tells, for each leading digit, which of the six left-hand digits
+// use even parity.
+var eanParity = [10]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// CheckDigit computes the GS1 check digit of a code given without it.
+func CheckDigit(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10 - sum%10) % 10
+}
+
+// ValidGTIN reports whether a numeric code ends in a correct check digit.
+func ValidGTIN(code string) bool {
+	if !isDigits(code) || len(code) < 2 {
+		return false
+	}
+	last := len(code) - 1
+	return CheckDigit(code[:last]) == int(code[last]-'0')
+}
+
+// completeGTIN returns the code with its check digit, adding it when the code
+// is one digit short and validating it otherwise.
+func completeGTIN(code string, length int) (string, error) {
+	if !isDigits(code) || (len(code) != length && len(code) != length-1) {
+		return "", fmt.Errorf("expected %d or %d digits", length-1, length)
+	}
+
+	if len(code) == length-1 {
+		return fmt.Sprintf("%s%d", code, CheckDigit(code)), nil
+	}
+
+	if !ValidGTIN(code) {
+		return "", fmt.Errorf("invalid check digit")
+	}
+	return code, nil
+}
+
+// encodeEAN13 encodes a complete 13 digit code.
+func encodeEAN13(code string) []bool {
+	parity := eanParity[code[0]-'0']
+
+	modules := appendPattern(nil, "101")
+	for i := 1; i <= 6; i++ {
+		pattern := eanLeft[code[i]-'0']
+		if parity[i-1] == 'G' {
+			pattern = evenParity(pattern)
+		}
+		modules = appendPattern(modules, pattern)
+	}
+
+	modules = appendPattern(modules, "01010")
+	for i := 7; i <= 12; i++ {
+		modules = appendPattern(modules, rightHand(eanLeft[code[i]-'0']))
+	}
+
+	return appendPattern(modules, "101")
+}
+
+// rightHand turns an L pattern into its R pattern by inverting every module.
+func rightHand(pattern string) string {
+	inverted := []byte(pattern)
+	for i := range inverted {
+		inverted[i] = '0' + '1' - inverted[i]
+	}
+	return string(inverted)
+}
+
+// evenParity turns an L pattern into its G pattern, the R pattern reversed.
+func evenParity(pattern string) string {
+	right := rightHand(pattern)
+	reversed := []byte(right)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return string(reversed)
+}
@@ -0,0 +1,90 @@
+package barcode
+
+import "testing"
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		name   string
+		digits string
+		want   int
+	}{
+		{"EAN-13", "400638133393", 1},
+		{"ISBN-13", "978020137962", 4},
+		{"UPC-A", "03600029145", 2},
+		{"UPC-A all zeros", "00000000000", 0},
+		{"EAN-8", "9638507", 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CheckDigit(test.digits); got != test.want {
+				t.Errorf("CheckDigit(%q) = %d, want %d", test.digits, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidGTIN(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"4006381333931", true},
+		{"5901234123457", true},
+		{"036000291452", true},
+		{"012345678905", true},
+		{"96385074", true},
+		{"4006381333932", false},
+		{"036000291453", false},
+		{"40063813339a1", false},
+		{"4", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := ValidGTIN(test.code); got != test.want {
+			t.Errorf("ValidGTIN(%q) = %v, want %v", test.code, got, test.want)
+		}
+	}
+}
+
+func TestEncodeGTIN(t *testing.T) {
+	tests := []struct {
+		name      string
+		symbology Symbology
+		data      string
+		want      string
+		wantErr   bool
+	}{
+		{"EAN-13 without check digit", EAN13, "400638133393", "4006381333931", false},
+		{"EAN-13 with check digit", EAN13, "4006381333931", "4006381333931", false},
+		{"EAN-13 with wrong check digit", EAN13, "4006381333932", "", true},
+		{"EAN-13 too short", EAN13, "40063813339", "", true},
+		{"UPC-A without check digit", UPCA, "03600029145", "036000291452", false},
+		{"UPC-A with check digit", UPCA, "036000291452", "036000291452", false},
+		{"UPC-A with letters", UPCA, "03600029145X", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			barcode, err := Encode(test.symbology, test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Encode(%q) succeeded, want an error", test.data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode(%q): %v", test.data, err)
+			}
+
+			if barcode.Data != test.want {
+				t.Errorf("Encode(%q) data = %q, want %q", test.data, barcode.Data, test.want)
+			}
+			// Guards, twelve digits of seven modules and the centre guard
+			if len(barcode.Modules) != 95 {
+				t.Errorf("Encode(%q) has %d modules, want 95", test.data, len(barcode.Modules))
+			}
+		})
+	}
+}
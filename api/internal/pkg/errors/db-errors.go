@@ -67,7 +67,16 @@ func MapPostgresError(err error, operation string) *AppError {
 			Operation: operation,
 			Code:      400,
 		}
-	case strings.Contains(details, "could not serialize access due to concurrent update"):
+	case strings.Contains(details, "products_quantity_non_negative"):
+		return &AppError{
+			Type:      DatabaseErr,
+			Message:   "Insufficient stock",
+			Details:   details,
+			Operation: operation,
+			Code:      409, // Conflict
+		}
+	case strings.Contains(details, "could not serialize access"),
+		strings.Contains(details, "deadlock detected"):
 		return &AppError{
 			Type:      DatabaseErr,
 			Message:   "Concurrent update conflict",
@@ -14,6 +14,7 @@ const (
 	Unauthorized  ErrorType = "UNAUTHORIZED"
 	BadRequest    ErrorType = "BAD_REQUEST"
 	Forbidden     ErrorType = "FORBIDDEN"
+	Conflict      ErrorType = "CONFLICT"
 	DatabaseErr   ErrorType = "DATABASE_ERROR"
 )
 
@@ -71,11 +72,18 @@ func ValidationError(message string) *AppError {
 	return New(ValidationErr, message, 400)
 }
 
+func ConflictError(message string) *AppError {
+	return New(Conflict, message, 409)
+}
+
 func InternalError(err error, message string) *AppError {
 	return Wrap(err, InternalErr, message, 500)
 }
 
 func DatabaseError(err error, operation string) *AppError {
+	if appErr, ok := err.(*AppError); ok {
+		return appErr
+	}
 	if pgErr := MapPostgresError(err, operation); pgErr != nil {
 		return pgErr
 	}
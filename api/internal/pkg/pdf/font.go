@@ -0,0 +1,67 @@
+package pdf
+
+import "strings"
+
+// helveticaWidths holds the advance widths, in thousandths of the font size,
+// of the printable ASCII characters in Helvetica.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// TextWidth measures text set in Helvetica at the given size. Characters
+// outside ASCII are counted as the width of a digit.
+func TextWidth(text string, size float64) float64 {
+	total := 0
+	for _, b := range encode(text) {
+		if b >= 32 && b <= 126 {
+			total += helveticaWidths[b-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Fit shortens text with an ellipsis until it fits in width at the given size.
+func Fit(text string, size, width float64) string {
+	if TextWidth(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if fitted := string(runes) + "..."; TextWidth(fitted, size) <= width {
+			return fitted
+		}
+	}
+	return ""
+}
+
+// Wrap breaks text into lines that fit in width at the given size, breaking
+// between words and at newlines. A word too long for a line is shortened.
+func Wrap(text string, size, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && TextWidth(line+" "+word, size) <= width {
+				line += " " + word
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = Fit(word, size, width)
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,124 @@
+// Package pdf writes simple PDF documents: pages of filled rectangles and
+// Helvetica text, which is all printable labels need. Coordinates are in
+// points from the bottom left corner of the page.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Points per millimetre and per inch, for page and label sizes.
+const (
+	MM   = 72 / 25.4
+	Inch = 72.0
+)
+
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+// New starts a document whose pages are width by height points.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+func (doc *Document) AddPage() *Page {
+	page := &Page{}
+	doc.pages = append(doc.pages, page)
+	return page
+}
+
+// Rect fills a black rectangle with its bottom left corner at x, y.
+func (page *Page) Rect(x, y, width, height float64) {
+	fmt.Fprintf(&page.content, "%s %s %s %s re f\n", num(x), num(y), num(width), num(height))
+}
+
+// Text writes a line of Helvetica with its baseline starting at x, y.
+func (page *Page) Text(x, y, size float64, text string) {
+	fmt.Fprintf(&page.content, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", num(size), num(x), num(y), escape(encode(text)))
+}
+
+// Write writes the document: a catalog, the page tree, the font and every page
+// with its content stream, followed by the cross-reference table.
+func (doc *Document) Write(w io.Writer) error {
+	var out bytes.Buffer
+	offsets := []int{}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(doc.pages))
+	for i := range doc.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(doc.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range doc.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(doc.width), num(doc.height), 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// num formats a coordinate to a hundredth of a point, far finer than any
+// printer resolves.
+func num(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', 2, 64)
+	formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	if formatted == "-0" {
+		return "0"
+	}
+	return formatted
+}
+
+// encode converts text to WinAnsi, which matches Latin-1 for the characters
+// labels use; anything else prints as a question mark.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if (r >= 32 && r <= 126) || (r >= 160 && r <= 255) {
+			encoded = append(encoded, byte(r))
+		} else {
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func escape(text []byte) string {
+	var escaped strings.Builder
+	for _, b := range text {
+		if b == '(' || b == ')' || b == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(b)
+	}
+	return escaped.String()
+}
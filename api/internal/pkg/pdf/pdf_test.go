@@ -0,0 +1,69 @@
+package pdf
+
+import "testing"
+
+func TestNum(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "0"},
+		{12, "12"},
+		{100, "100"},
+		{-40, "-40"},
+		{72.5, "72.5"},
+		{595.28, "595.28"},
+		{1.005, "1"},
+		{2.999, "3"},
+		{0.004, "0"},
+		{-0.004, "0"},
+		{-12.25, "-12.25"},
+	}
+
+	for _, test := range tests {
+		if got := num(test.value); got != test.want {
+			t.Errorf("num(%v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Shelf A-12", "Shelf A-12"},
+		{"parentheses", "Box (small)", `Box \(small\)`},
+		{"backslash", `C:\labels`, `C:\\labels`},
+		{"unbalanced", ")(", `\)\(`},
+		{"empty", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := escape(encode(test.text)); got != test.want {
+				t.Errorf("escape(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"SKU-1", "SKU-1"},
+		{"Café", "Caf\xe9"},
+		{"½ kg", "\xbd kg"},
+		{"€5", "?5"},
+		{"a\tb", "a?b"},
+	}
+
+	for _, test := range tests {
+		if got := string(encode(test.text)); got != test.want {
+			t.Errorf("encode(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
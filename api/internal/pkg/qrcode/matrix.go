@@ -0,0 +1,223 @@
+package qrcode
+
+// matrix is a QR code being built: the modules and which of them belong to
+// function patterns and must not carry data or be masked.
+type matrix struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func build(number int, codewords []byte) *QRCode {
+	size := number*4 + 17
+	m := &matrix{size: size, modules: grid(size), isFunction: grid(size)}
+
+	m.drawFunctionPatterns(number)
+	m.drawCodewords(codewords)
+
+	// Pick the mask leaving the fewest scanner-confusing patterns
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask)
+	}
+
+	m.applyMask(best)
+	m.drawFormatBits(best)
+
+	return &QRCode{Size: size, modules: m.modules}
+}
+
+func grid(size int) [][]bool {
+	rows := make([][]bool, size)
+	for y := range rows {
+		rows[y] = make([]bool, size)
+	}
+	return rows
+}
+
+func (m *matrix) setFunction(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.isFunction[y][x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(number int) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	positions := versions[number].alignment
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners taken by finder patterns
+			last := len(positions) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas now; drawFormatBits fills them in
+	m.drawFormatBits(0)
+	m.drawVersion(number)
+}
+
+// drawFinder draws a finder pattern and its separator centred on x, y.
+func (m *matrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			px, py := x+dx, y+dy
+			if px < 0 || px >= m.size || py < 0 || py >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.setFunction(px, py, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (m *matrix) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the level M format information for the
+// given mask, and the dark module.
+func (m *matrix) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(bits, i))
+	}
+	m.setFunction(8, 7, bit(bits, 6))
+	m.setFunction(8, 8, bit(bits, 7))
+	m.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(bits, i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+// formatBits returns the 15 bit level M format information for the mask: the
+// level and mask, their BCH error correction and the standard's XOR pattern.
+func formatBits(mask int) int {
+	const levelM = 0b00
+	data := levelM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// drawVersion draws the two version information blocks of versions 7 and up.
+func (m *matrix) drawVersion(number int) {
+	if number < 7 {
+		return
+	}
+
+	bits := versionBits(number)
+	for i := 0; i < 18; i++ {
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, bit(bits, i))
+		m.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// versionBits returns the 18 bit version information: the version number and
+// its Golay error correction.
+func versionBits(number int) int {
+	remainder := number
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1f25)
+	}
+	return number<<12 | remainder
+}
+
+// drawCodewords places the data bits in the zigzag order of the standard, two
+// columns at a time from the bottom right, skipping function patterns.
+func (m *matrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert
+				}
+				if !m.isFunction[y][x] && i < len(codewords)*8 {
+					m.modules[y][x] = bit(int(codewords[i/8]), 7-i%8)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by the mask; applying it twice
+// undoes it.
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.isFunction[y][x] {
+				continue
+			}
+
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			m.modules[y][x] = m.modules[y][x] != flip
+		}
+	}
+}
+
+func bit(value, i int) bool {
+	return (value>>i)&1 == 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,91 @@
+package qrcode
+
+import "testing"
+
+func TestFormatBits(t *testing.T) {
+	// Level M format information from the standard's table
+	tests := []struct {
+		mask int
+		want int
+	}{
+		{0, 0b101010000010010},
+		{1, 0b101000100100101},
+		{2, 0b101111001111100},
+		{3, 0b101101101001011},
+		{4, 0b100010111111001},
+		{5, 0b100000011001110},
+		{6, 0b100111110010111},
+		{7, 0b100101010100000},
+	}
+
+	for _, test := range tests {
+		if got := formatBits(test.mask); got != test.want {
+			t.Errorf("formatBits(%d) = %015b, want %015b", test.mask, got, test.want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	tests := []struct {
+		number int
+		want   int
+	}{
+		{7, 0x07c94},
+		{8, 0x085bc},
+		{9, 0x09a99},
+		{10, 0x0a4d3},
+	}
+
+	for _, test := range tests {
+		if got := versionBits(test.number); got != test.want {
+			t.Errorf("versionBits(%d) = %#05x, want %#05x", test.number, got, test.want)
+		}
+	}
+}
+
+func TestDrawnFormatBits(t *testing.T) {
+	qr, err := Encode("https://example.com/units/42")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// Read the copy around the top left finder back, bit 0 first
+	read := func(x, y int) int {
+		if qr.Dark(x, y) {
+			return 1
+		}
+		return 0
+	}
+	first, second := 0, 0
+	for i := 0; i <= 5; i++ {
+		first |= read(8, i) << i
+	}
+	first |= read(8, 7)<<6 | read(8, 8)<<7 | read(7, 8)<<8
+	for i := 9; i < 15; i++ {
+		first |= read(14-i, 8) << i
+	}
+
+	// And the copy split between the other two finders
+	for i := 0; i < 8; i++ {
+		second |= read(qr.Size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= read(8, qr.Size-15+i) << i
+	}
+
+	if first != second {
+		t.Errorf("format copies differ: %015b and %015b", first, second)
+	}
+
+	valid := false
+	for mask := 0; mask < 8; mask++ {
+		valid = valid || first == formatBits(mask)
+	}
+	if !valid {
+		t.Errorf("drawn format information %015b is not level M with any mask", first)
+	}
+
+	if !qr.Dark(8, qr.Size-8) {
+		t.Error("dark module is not dark")
+	}
+}
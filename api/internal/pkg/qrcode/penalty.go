@@ -0,0 +1,83 @@
+package qrcode
+
+// finderLike is the 1:1:3:1:1 dark-light ratio of a finder pattern with four
+// light modules on one side, which scanners may mistake for a real finder.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores the symbol by the four rules of the standard; the mask with
+// the lowest score is used.
+func (m *matrix) penalty() int {
+	total := 0
+
+	for i := 0; i < m.size; i++ {
+		row := make([]bool, m.size)
+		column := make([]bool, m.size)
+		for j := 0; j < m.size; j++ {
+			row[j] = m.modules[i][j]
+			column[j] = m.modules[j][i]
+		}
+		total += linePenalty(row) + linePenalty(column)
+	}
+
+	// Rule 2: 2x2 blocks of one colour
+	for y := 0; y < m.size-1; y++ {
+		for x := 0; x < m.size-1; x++ {
+			dark := m.modules[y][x]
+			if dark == m.modules[y][x+1] && dark == m.modules[y+1][x] && dark == m.modules[y+1][x+1] {
+				total += 3
+			}
+		}
+	}
+
+	// Rule 4: dark modules straying from half of the symbol
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+		}
+	}
+	percent := dark * 100 / (m.size * m.size)
+	total += abs(percent-50) / 5 * 10
+
+	return total
+}
+
+// linePenalty applies rule 1, runs of five or more modules of one colour, and
+// rule 3, finder-like patterns, to a row or column.
+func linePenalty(line []bool) int {
+	total := 0
+
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			total += 3 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				total += 40
+			}
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,133 @@
+// Package qrcode encodes text as a QR code in byte mode with error correction
+// level M, the level most label printers and phone scanners default to.
+package qrcode
+
+import "fmt"
+
+// QuietZone is the blank margin, in modules, a QR code needs on each side.
+const QuietZone = 4
+
+// QRCode is an encoded QR code, Size modules square.
+type QRCode struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark.
+func (qr *QRCode) Dark(x, y int) bool {
+	return qr.modules[y][x]
+}
+
+// version describes the codeword layout of one QR version at level M: the
+// error correction codewords per block and the data codewords of each block.
+type version struct {
+	ecPerBlock int
+	blocks     []int
+	alignment  []int
+}
+
+var versions = []version{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	total := 0
+	for _, block := range v.blocks {
+		total += block
+	}
+	return total
+}
+
+// Encode encodes data in the smallest version, up to 10, that holds it.
+func Encode(data string) (*QRCode, error) {
+	for number := 1; number < len(versions); number++ {
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*versions[number].dataCodewords() {
+			continue
+		}
+
+		codewords := encodeData([]byte(data), countBits, versions[number].dataCodewords())
+		return build(number, interleave(versions[number], codewords)), nil
+	}
+
+	return nil, fmt.Errorf("%d bytes do not fit in a QR code", len(data))
+}
+
+// encodeData writes the byte mode segment, terminator and padding.
+func encodeData(data []byte, countBits, capacity int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	bits.append(0, min(4, capacity*8-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+
+	codewords := bits.bytes()
+	for pad := 0; len(codewords) < capacity; pad++ {
+		codewords = append(codewords, [2]byte{0xec, 0x11}[pad%2])
+	}
+	return codewords
+}
+
+// interleave splits the data into blocks, adds each block's error correction
+// and interleaves the blocks codeword by codeword.
+func interleave(v version, data []byte) []byte {
+	generator := rsGenerator(v.ecPerBlock)
+
+	blocks := make([][]byte, len(v.blocks))
+	ec := make([][]byte, len(v.blocks))
+	offset := 0
+	for i, size := range v.blocks {
+		blocks[i] = data[offset : offset+size]
+		ec[i] = rsRemainder(blocks[i], generator)
+		offset += size
+	}
+
+	result := []byte{}
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ec {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return result
+}
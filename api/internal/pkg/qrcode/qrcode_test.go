@@ -0,0 +1,88 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionCapacity(t *testing.T) {
+	// Data and total codewords of versions 1 to 10 at level M, from the standard
+	tests := []struct {
+		number int
+		data   int
+		total  int
+	}{
+		{1, 16, 26},
+		{2, 28, 44},
+		{3, 44, 70},
+		{4, 64, 100},
+		{5, 86, 134},
+		{6, 108, 172},
+		{7, 124, 196},
+		{8, 154, 242},
+		{9, 182, 292},
+		{10, 216, 346},
+	}
+
+	for _, test := range tests {
+		v := versions[test.number]
+		if got := v.dataCodewords(); got != test.data {
+			t.Errorf("version %d has %d data codewords, want %d", test.number, got, test.data)
+		}
+		if got := v.dataCodewords() + v.ecPerBlock*len(v.blocks); got != test.total {
+			t.Errorf("version %d has %d codewords in total, want %d", test.number, got, test.total)
+		}
+	}
+}
+
+func TestEncodeVersionSelection(t *testing.T) {
+	// Byte mode capacities at level M: 14 bytes fit version 1, 213 version 10
+	tests := []struct {
+		name     string
+		length   int
+		wantSize int
+		wantErr  bool
+	}{
+		{"empty", 0, 21, false},
+		{"version 1 full", 14, 21, false},
+		{"version 2", 15, 25, false},
+		{"version 2 full", 26, 25, false},
+		{"version 3", 27, 29, false},
+		{"version 6 full", 106, 41, false},
+		{"version 7", 107, 45, false},
+		{"version 9 full", 180, 53, false},
+		{"version 10", 181, 57, false},
+		{"version 10 full", 213, 57, false},
+		{"too long", 214, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			qr, err := Encode(strings.Repeat("a", test.length))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Encode(%d bytes) succeeded, want an error", test.length)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode(%d bytes): %v", test.length, err)
+			}
+
+			if qr.Size != test.wantSize {
+				t.Errorf("Encode(%d bytes) size = %d, want %d", test.length, qr.Size, test.wantSize)
+			}
+		})
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+	// "AB" in byte mode: mode 0100, count 00000010, the two bytes, the
+	// terminator and then alternating pad codewords up to capacity
+	got := encodeData([]byte("AB"), 8, 6)
+	want := []byte{0x40, 0x24, 0x14, 0x20, 0xec, 0x11}
+
+	if string(got) != string(want) {
+		t.Errorf("encodeData = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package qrcode
+
+// gfMultiply multiplies in GF(256) modulo the QR code polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var result byte
+	for i := 7; i >= 0; i-- {
+		carry := result & 0x80
+		result <<= 1
+		if carry != 0 {
+			result ^= 0x1d
+		}
+		if (y>>i)&1 == 1 {
+			result ^= x
+		}
+	}
+	return result
+}
+
+// rsGenerator returns the coefficients, highest power first and without the
+// leading 1, of the generator polynomial for the given number of error
+// correction codewords.
+func rsGenerator(degree int) []byte {
+	generator := make([]byte, degree)
+	generator[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range generator {
+			generator[j] = gfMultiply(generator[j], root)
+			if j+1 < len(generator) {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return generator
+}
+
+// rsRemainder computes the error correction codewords of a block.
+func rsRemainder(data, generator []byte) []byte {
+	remainder := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[len(remainder)-1] = 0
+		for i := range remainder {
+			remainder[i] ^= gfMultiply(generator[i], factor)
+		}
+	}
+	return remainder
+}
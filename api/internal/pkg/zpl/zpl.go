@@ -0,0 +1,90 @@
+// Package zpl writes labels in ZPL II, the command language of Zebra thermal
+// printers. Positions and sizes are in printer dots from the label's top left.
+package zpl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ventry/internal/pkg/barcode"
+)
+
+// MaxQRMagnification is the largest module size ^BQ accepts, in dots.
+const MaxQRMagnification = 10
+
+type Label struct {
+	Width  int
+	Height int
+	DPI    int
+	fields strings.Builder
+}
+
+// New starts a label of the given size in millimetres for a printer of the
+// given resolution.
+func New(widthMM, heightMM float64, dpi int) *Label {
+	label := &Label{DPI: dpi}
+	label.Width = label.Dots(widthMM)
+	label.Height = label.Dots(heightMM)
+	return label
+}
+
+// Dots converts millimetres to printer dots.
+func (label *Label) Dots(mm float64) int {
+	return int(math.Round(mm * float64(label.DPI) / 25.4))
+}
+
+// Text writes one line of the scalable font, size dots tall, cut off at width.
+func (label *Label) Text(x, y, size, width int, text string) {
+	label.TextBlock(x, y, size, width, 1, text)
+}
+
+// TextBlock writes text wrapped to width over at most lines lines; newlines in
+// the text start a new line.
+func (label *Label) TextBlock(x, y, size, width, lines int, text string) {
+	text = strings.ReplaceAll(escape(text), "\n", `\&`)
+	fmt.Fprintf(&label.fields, "^FO%d,%d^A0N,%d,%d^FB%d,%d,0,L,0^FH^FD%s^FS\n", x, y, size, size, width, lines, text)
+}
+
+// QRCode draws a QR code of data with each module magnification dots wide,
+// between 1 and MaxQRMagnification.
+func (label *Label) QRCode(x, y, magnification int, data string) {
+	fmt.Fprintf(&label.fields, "^FO%d,%d^BQN,2,%d^FH^FDMA,%s^FS\n", x, y, magnification, escape(data))
+}
+
+// Barcode draws a Code 128, EAN-13 or UPC-A barcode, height dots tall and
+// module dots per narrow bar, with its text printed below.
+func (label *Label) Barcode(x, y, height, module int, code *barcode.Barcode) {
+	fmt.Fprintf(&label.fields, "^BY%d^FO%d,%d", module, x, y)
+
+	// The printer adds the check digit of EAN-13 and UPC-A itself
+	switch code.Symbology {
+	case barcode.EAN13:
+		fmt.Fprintf(&label.fields, "^BEN,%d,Y,N^FD%s^FS\n", height, code.Data[:12])
+	case barcode.UPCA:
+		fmt.Fprintf(&label.fields, "^BUN,%d,Y,N,Y^FD%s^FS\n", height, code.Data[:11])
+	default:
+		// ">" starts a code set switch in Code 128 data; "><" prints it
+		data := strings.ReplaceAll(escape(code.Data), ">", "><")
+		fmt.Fprintf(&label.fields, "^BCN,%d,Y,N,N^FH^FD%s^FS\n", height, data)
+	}
+}
+
+// Box draws a filled rectangle, as a rule or a block.
+func (label *Label) Box(x, y, width, height int) {
+	fmt.Fprintf(&label.fields, "^FO%d,%d^GB%d,%d,%d^FS\n", x, y, width, height, min(width, height))
+}
+
+// Write writes the label, to be printed copies times, in UTF-8.
+func (label *Label) Write(w io.Writer, copies int) error {
+	_, err := fmt.Fprintf(w, "^XA\n^CI28\n^PW%d\n^LL%d\n^LH0,0\n%s^PQ%d\n^XZ\n",
+		label.Width, label.Height, label.fields.String(), max(copies, 1))
+	return err
+}
+
+// escape hex-encodes the characters ZPL treats as commands in field data,
+// which ^FH turns back into text.
+func escape(text string) string {
+	return strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(text)
+}
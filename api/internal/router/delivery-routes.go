@@ -15,4 +15,5 @@ func DeliveryRoutes(e *echo.Echo, dc deliveries.DeliveryController, authService
 	api.POST("", dc.CreateDelivery)
 	api.PUT("/:id", dc.UpdateDelivery)
 	api.DELETE("/:id", dc.DeleteDelivery)
+	api.POST("/:id/transition", dc.TransitionDelivery)
 }
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/features/labels"
+	"github.com/ventry/internal/pkg/auth"
+)
+
+func LabelRoutes(e *echo.Echo, lc labels.LabelController, authService auth.AuthService) {
+	api := e.Group("/api/labels")
+	api.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	api.GET("/templates", lc.ListLabelTemplates)
+	api.POST("/sheets", lc.PrintLabelSheets)
+
+	deliveries := e.Group("/api/deliveries")
+	deliveries.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	deliveries.GET("/:id/label", lc.PrintShippingLabel)
+}
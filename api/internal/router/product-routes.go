@@ -15,4 +15,27 @@ func ProductRoutes(e *echo.Echo, pc products.ProductController, authService auth
 	api.POST("", pc.CreateProduct)
 	api.PUT("/:id", pc.EditProduct)
 	api.DELETE("/:id", pc.DeleteProduct)
+	api.PUT("/:id/variants", pc.GenerateVariants)
+	api.PUT("/:id/variants/:variantId", pc.EditVariant)
+	api.PUT("/:id/units", pc.SetProductUnits)
+	api.PUT("/:id/components", pc.SetBundleComponents)
+	api.GET("/:id/barcode", pc.RenderBarcode)
+	api.GET("/:id/prices", pc.ListPriceHistory)
+	api.GET("/:id/price-changes", pc.ListScheduledPriceChanges)
+	api.POST("/:id/price-changes", pc.SchedulePriceChange)
+	api.DELETE("/:id/price-changes/:changeId", pc.CancelPriceChange)
+
+	inventories := e.Group("/api/inventories")
+	inventories.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	inventories.GET("/:id/products/lookup", pc.LookupProduct)
+	inventories.GET("/:id/products/next-sku", pc.PreviewSKU)
+
+	attributes := e.Group("/api/attributes")
+	attributes.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	attributes.GET("/inventory/:inventoryId", pc.ListAttributeDefinitions)
+	attributes.POST("", pc.CreateAttributeDefinition)
+	attributes.PUT("/:id", pc.EditAttributeDefinition)
+	attributes.DELETE("/:id", pc.DeleteAttributeDefinition)
 }
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/features/purchases"
+	"github.com/ventry/internal/pkg/auth"
+)
+
+func PurchaseRoutes(e *echo.Echo, pc purchases.PurchaseController, authService auth.AuthService) {
+	api := e.Group("/api/purchase-orders")
+	api.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	api.GET("/inventory/:inventoryId", pc.ListPurchaseOrders)
+	api.GET("/:id", pc.GetPurchaseOrder)
+	api.POST("", pc.CreatePurchaseOrder)
+	api.PUT("/:id", pc.UpdatePurchaseOrder)
+	api.DELETE("/:id", pc.DeletePurchaseOrder)
+	api.POST("/:id/transition", pc.TransitionPurchaseOrder)
+	api.POST("/:id/receive", pc.ReceivePurchaseOrder)
+
+	reorder := e.Group("/api/inventories/:id/reorder-suggestions")
+	reorder.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	reorder.GET("", pc.ListReorderSuggestions)
+	reorder.POST("/orders", pc.CreateReorderOrders)
+}
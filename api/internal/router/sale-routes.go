@@ -18,5 +18,5 @@ func SaleRoutes(e *echo.Echo, sc sales.SaleController, authService auth.AuthServ
 
 	items := api.Group("/:id/items")
 	items.POST("", sc.AddItemToSale)
-	items.DELETE("/:id/items/:itemId", sc.RemoveItemFromSale)
+	items.DELETE("/:itemId", sc.RemoveItemFromSale)
 }
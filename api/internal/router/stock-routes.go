@@ -0,0 +1,52 @@
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/features/stock"
+	"github.com/ventry/internal/pkg/auth"
+)
+
+func StockRoutes(e *echo.Echo, sc stock.StockController, authService auth.AuthService) {
+	products := e.Group("/api/products")
+	products.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	products.GET("/:id/movements", sc.ListProductMovements)
+	products.GET("/:id/lots", sc.ListProductLots)
+	products.GET("/:id/serials", sc.ListProductSerials)
+	products.GET("/:id/cost-layers", sc.ListProductCostLayers)
+
+	inventories := e.Group("/api/inventories")
+	inventories.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	inventories.GET("/:id/reconciliation", sc.ReconcileInventory)
+	inventories.POST("/:id/reconciliation/fix", sc.FixReconciliation)
+	inventories.GET("/:id/shrinkage", sc.ShrinkageReport)
+	inventories.GET("/:id/lots/expiring", sc.ListExpiringLots)
+	inventories.GET("/:id/serials/:serial", sc.LookupSerial)
+	inventories.GET("/:id/valuation", sc.InventoryValuation)
+
+	stocktakes := e.Group("/api/stocktakes")
+	stocktakes.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	stocktakes.GET("/inventory/:inventoryId", sc.ListStocktakes)
+	stocktakes.GET("/:id", sc.GetStocktake)
+	stocktakes.POST("", sc.CreateStocktake)
+	stocktakes.DELETE("/:id", sc.DeleteStocktake)
+	stocktakes.POST("/:id/counts", sc.RecordStocktakeCounts)
+	stocktakes.POST("/:id/approvals", sc.ApproveStocktakeLines)
+	stocktakes.POST("/:id/transition", sc.TransitionStocktake)
+
+	adjustments := e.Group("/api/adjustments")
+	adjustments.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	adjustments.GET("/inventory/:inventoryId", sc.ListAdjustments)
+	adjustments.POST("", sc.AdjustStock)
+
+	reasons := e.Group("/api/adjustment-reasons")
+	reasons.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	reasons.GET("/inventory/:inventoryId", sc.ListAdjustmentReasons)
+	reasons.POST("", sc.CreateAdjustmentReason)
+	reasons.PUT("/:id", sc.EditAdjustmentReason)
+	reasons.DELETE("/:id", sc.DeleteAdjustmentReason)
+}
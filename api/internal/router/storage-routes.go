@@ -22,4 +22,13 @@ func StorageRoutes(e *echo.Echo, sc storages.StorageController, authService auth
 	units.POST("", sc.CreateUnit)
 	units.PUT("/:id", sc.EditUnit)
 	units.DELETE("/:id", sc.DeleteUnit)
+	units.POST("/:id/items", sc.AddItemToUnit)
+	units.POST("/:id/items/remove", sc.RemoveItemFromUnit)
+
+	transfers := e.Group("/api/transfers")
+	transfers.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	transfers.GET("/inventory/:inventoryId", sc.ListTransfers)
+	transfers.GET("/:id", sc.GetTransfer)
+	transfers.POST("", sc.TransferStock)
 }
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/features/suppliers"
+	"github.com/ventry/internal/pkg/auth"
+)
+
+func SupplierRoutes(e *echo.Echo, sc suppliers.SupplierController, authService auth.AuthService) {
+	api := e.Group("/api/suppliers")
+	api.Use(auth.AuthMiddleware(&authService), auth.RoleMiddleware("user"))
+
+	api.GET("/inventory/:inventoryId", sc.ListSuppliers)
+	api.GET("/:id", sc.GetSupplier)
+	api.POST("", sc.CreateSupplier)
+	api.PUT("/:id", sc.EditSupplier)
+	api.DELETE("/:id", sc.DeleteSupplier)
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ventry/internal/domain"
 	"github.com/ventry/internal/pkg/errors"
 	"github.com/ventry/internal/pkg/logger"
 )
@@ -36,3 +38,42 @@ func BindAndValidateInput(ctx echo.Context, input interface{}) error {
 
 	return nil
 }
+
+// GetActor returns the authenticated user and request ID for audit records.
+func GetActor(ctx echo.Context) domain.Actor {
+	var actor domain.Actor
+
+	if user, ok := ctx.Get("user").(*domain.User); ok {
+		actor.UserId = &user.Id
+	}
+
+	if requestId, ok := ctx.Request().Context().Value(logger.RequestIDKey).(string); ok {
+		actor.RequestId = &requestId
+	}
+
+	return actor
+}
+
+// ParseDateParam parses an optional RFC 3339 timestamp or YYYY-MM-DD date.
+// With endOfDay set, a bare date is moved to the start of the following day so
+// it can be used as an exclusive upper bound.
+func ParseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}